@@ -0,0 +1,141 @@
+package application
+
+import (
+	"sort"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/encoding"
+	"gonum.org/v1/gonum/graph/encoding/dot"
+	"gonum.org/v1/gonum/graph/iterator"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// DotGraph は自己ループ(直接再帰)を辺として保持できる有向グラフ
+// simple.DirectedGraphは自己ループを追加するとpanicするため別に管理する
+type DotGraph struct {
+	*simple.DirectedGraph
+	selfLoops map[int64]graph.Edge
+	clusters  []*dotCluster
+}
+
+func newDotGraph() *DotGraph {
+	return &DotGraph{
+		DirectedGraph: simple.NewDirectedGraph(),
+		selfLoops:     make(map[int64]graph.Edge),
+	}
+}
+
+// Structure はパッケージごとのクラスタをDOTのsubgraphとして出力する
+func (g *DotGraph) Structure() []dot.Graph {
+	subgraphs := make([]dot.Graph, 0, len(g.clusters))
+	for _, cluster := range g.clusters {
+		subgraphs = append(subgraphs, cluster)
+	}
+	return subgraphs
+}
+
+func (g *DotGraph) addToCluster(packagePath string, n graph.Node) {
+	for _, cluster := range g.clusters {
+		if cluster.packagePath == packagePath {
+			cluster.AddNode(n)
+			return
+		}
+	}
+	cluster := &dotCluster{
+		DirectedGraph: simple.NewDirectedGraph(),
+		packagePath:   packagePath,
+	}
+	cluster.AddNode(n)
+	g.clusters = append(g.clusters, cluster)
+}
+
+func (g *DotGraph) SetEdge(e graph.Edge) {
+	if e.From().ID() == e.To().ID() {
+		if g.Node(e.From().ID()) == nil {
+			g.AddNode(e.From())
+		}
+		g.selfLoops[e.From().ID()] = e
+		return
+	}
+	g.DirectedGraph.SetEdge(e)
+}
+
+func (g *DotGraph) Edge(uid, vid int64) graph.Edge {
+	if uid == vid {
+		return g.selfLoops[uid]
+	}
+	return g.DirectedGraph.Edge(uid, vid)
+}
+
+func (g *DotGraph) HasEdgeBetween(xid, yid int64) bool {
+	if xid == yid {
+		return g.selfLoops[xid] != nil
+	}
+	return g.DirectedGraph.HasEdgeBetween(xid, yid)
+}
+
+func (g *DotGraph) HasEdgeFromTo(uid, vid int64) bool {
+	if uid == vid {
+		return g.selfLoops[uid] != nil
+	}
+	return g.DirectedGraph.HasEdgeFromTo(uid, vid)
+}
+
+func (g *DotGraph) From(id int64) graph.Nodes {
+	return g.withSelfLoop(id, g.DirectedGraph.From(id))
+}
+
+func (g *DotGraph) To(id int64) graph.Nodes {
+	return g.withSelfLoop(id, g.DirectedGraph.To(id))
+}
+
+func (g *DotGraph) withSelfLoop(id int64, nodes graph.Nodes) graph.Nodes {
+	loop, exists := g.selfLoops[id]
+	if !exists {
+		return nodes
+	}
+	return iterator.NewOrderedNodes(append(graph.NodesOf(nodes), loop.From()))
+}
+
+// dotCluster は同じパッケージに属するNodeをまとめるsubgraph
+type dotCluster struct {
+	*simple.DirectedGraph
+	packagePath string
+}
+
+// DOTID はGraphvizがクラスタとして描画するよう"cluster"で始める
+func (c *dotCluster) DOTID() string {
+	return "cluster_" + c.packagePath
+}
+
+func (c *dotCluster) DOTAttributers() (graph, node, edge encoding.Attributer) {
+	return &encoding.Attributes{{Key: "label", Value: c.packagePath}}, &encoding.Attributes{}, &encoding.Attributes{}
+}
+
+// dotEdge はDOT属性を持つ辺
+type dotEdge struct {
+	graph.Edge
+	attributes map[string]string
+}
+
+func newDotEdge(from, to graph.Node) *dotEdge {
+	return &dotEdge{
+		Edge:       simple.Edge{F: from, T: to},
+		attributes: make(map[string]string),
+	}
+}
+
+func (e *dotEdge) Attributes() []encoding.Attribute {
+	return sortedAttributes(e.attributes)
+}
+
+func sortedAttributes(attributes map[string]string) []encoding.Attribute {
+	result := make([]encoding.Attribute, 0, len(attributes))
+	for key, value := range attributes {
+		result = append(result, encoding.Attribute{Key: key, Value: value})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
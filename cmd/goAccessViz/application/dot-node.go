@@ -1,64 +1,312 @@
 package application
 
 import (
+	"fmt"
+	"html"
+	"strings"
+
 	"goAccessViz/cmd/goAccessViz/domain/node"
 
 	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/encoding"
 	"gonum.org/v1/gonum/graph/encoding/dot"
-	"gonum.org/v1/gonum/graph/simple"
+	"gonum.org/v1/gonum/graph/topo"
 )
 
+const recursiveColor = "red"
+
+// dynamicTableColor は名前の一部が実行時に決まるテーブルの色
+const dynamicTableColor = "gray40"
+
+// accessModeColors はテーブルへの辺を操作ごとに色分けする
+var accessModeColors = map[node.AccessMode]string{
+	node.AccessSelect:   "blue",
+	node.AccessInsert:   "darkgreen",
+	node.AccessUpdate:   "orange",
+	node.AccessDelete:   "crimson",
+	node.AccessUpsert:   "purple",
+	node.AccessTruncate: "black",
+}
+
 type dotNode struct {
 	graph.Node
-	label string
+	id         string
+	label      string
+	attributes map[string]string
 }
 
+// DOTID はドメインNodeのIDを返す(表示名はlabel属性で出力する)
 func (d *dotNode) DOTID() string {
-	return d.label
+	return d.id
 }
 
 func (d *dotNode) Getlabel() string {
-	return d.DOTID()
+	return d.label
+}
+
+func (d *dotNode) Attributes() []encoding.Attribute {
+	attributes := sortedAttributes(d.attributes)
+	if d.label != "" && d.label != d.id {
+		attributes = append([]encoding.Attribute{{Key: "label", Value: d.label}}, attributes...)
+	}
+	return attributes
+}
+
+func newDotNode(domainNode node.TrackedEntity, goNumDotNode graph.Node) *dotNode {
+	d := &dotNode{
+		Node:       goNumDotNode,
+		id:         domainNode.GetID(),
+		label:      domainNode.GetLabel(),
+		attributes: make(map[string]string),
+	}
+	switch n := domainNode.(type) {
+	case *node.FunctionTrackedEntity:
+		setPositionAttributes(d.attributes, n.GetPosition())
+		// エラーのあるパッケージの関数は辺が欠けている可能性がある
+		if n.IsIncomplete() {
+			addStyle(d.attributes, "dashed")
+		}
+	case *node.DatabaseTableTrackedEntity:
+		// 名前が"audit_%s"のようなパターンのテーブルは実在のテーブルと見分けられるようにする
+		if n.IsDynamic() {
+			addStyle(d.attributes, "dashed")
+			d.attributes["color"] = dynamicTableColor
+			d.attributes["fontcolor"] = dynamicTableColor
+		}
+	case *node.PackageTrackedEntity:
+		// 解析対象外のパッケージをまとめたNodeは関数と見分けられるようにする
+		d.attributes["shape"] = "folder"
+		addStyle(d.attributes, "dashed")
+	}
+	return d
+}
+
+// setPositionAttributes はソースコード上の位置をtooltipとURLとして出力する
+func setPositionAttributes(attributes map[string]string, positions ...node.Position) {
+	var tooltips []string
+	var first *node.Position
+	for i, position := range positions {
+		if !position.IsValid() {
+			continue
+		}
+		if first == nil {
+			first = &positions[i]
+		}
+		tooltips = append(tooltips, position.String())
+	}
+	if first == nil {
+		return
+	}
+	attributes["tooltip"] = strings.Join(tooltips, "\n")
+	attributes["URL"] = positionURL(*first)
+}
+
+// setCallAttributes は呼び出し回数やgo/deferをラベルに、動的な呼び出しやgoroutineの起動をスタイルに出力する
+func setCallAttributes(attributes map[string]string, edge *node.Edge) {
+	var labels []string
+	if edge.GetCallSites() > 1 {
+		labels = append(labels, fmt.Sprintf("%d calls", edge.GetCallSites()))
+	}
+	if edge.IsGoroutine() {
+		labels = append(labels, "go")
+		addStyle(attributes, "bold")
+	}
+	if edge.IsDeferred() {
+		labels = append(labels, "defer")
+	}
+	if edge.IsDynamic() {
+		addStyle(attributes, "dashed")
+	}
+	if len(labels) > 0 {
+		attributes["label"] = strings.Join(labels, ", ")
+	}
+}
+
+// setAccessAttributes はテーブルへの操作をラベルに、操作ごとの色を並べてcolorに出力する
+// 複数の操作を含む辺はGraphvizの"a:b"指定で平行な色付きの線として描かれる
+func setAccessAttributes(attributes map[string]string, edge *node.Edge) {
+	modes := edge.GetAccessModes()
+	if len(modes) == 0 {
+		return
+	}
+	labels := make([]string, 0, len(modes))
+	colors := make([]string, 0, len(modes))
+	for _, mode := range modes {
+		labels = append(labels, string(mode))
+		colors = append(colors, accessModeColors[mode])
+	}
+	attributes["label"] = strings.Join(labels, ", ")
+	attributes["color"] = strings.Join(colors, ":")
+	attributes["fontcolor"] = colors[0]
+	if edge.IsWrite() {
+		addStyle(attributes, "bold")
+	}
+}
+
+// setPreparationAttributes はプリペアドステートメントを準備した関数と箇所をtooltipに書き足す
+func setPreparationAttributes(attributes map[string]string, edge *node.Edge) {
+	var lines []string
+	if tooltip, ok := attributes["tooltip"]; ok {
+		lines = append(lines, tooltip)
+	}
+	for _, preparation := range edge.GetPreparations() {
+		line := "prepared in " + preparation.Function
+		if preparation.Position.IsValid() {
+			line += " at " + preparation.Position.String()
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) > 0 {
+		attributes["tooltip"] = strings.Join(lines, "\n")
+	}
+}
+
+// setTableRecordAttributes はテーブル名の下に列名を並べた表をラベルに設定する
+// shape=recordのラベルはバックスラッシュでのエスケープがDOTの出力で壊れるため、HTML形式の表で描く
+func setTableRecordAttributes(d *dotNode, table *node.DatabaseTableTrackedEntity) {
+	var rows strings.Builder
+	rows.WriteString(`<TR><TD><B>` + html.EscapeString(table.GetLabel()) + `</B></TD></TR>`)
+	for _, child := range table.GetChildren() {
+		if column, ok := child.(*node.ColumnTrackedEntity); ok {
+			rows.WriteString(`<TR><TD ALIGN="LEFT">` + html.EscapeString(column.GetColumnName()) + `</TD></TR>`)
+		}
+	}
+	d.label = `<<TABLE BORDER="0" CELLBORDER="1" CELLSPACING="0">` + rows.String() + `</TABLE>>`
+	d.attributes["shape"] = "plain"
 }
 
-func newDotNode(node node.TrackedEntity, goNumDotNode graph.Node) *dotNode {
-	return &dotNode{
-		Node:  goNumDotNode,
-		label: node.GetLabel(),
+// addStyle はGraphvizのstyle属性(カンマ区切り)に重複なく追加する
+func addStyle(attributes map[string]string, style string) {
+	current := attributes["style"]
+	if current == "" {
+		attributes["style"] = style
+		return
 	}
+	for _, existing := range strings.Split(current, ",") {
+		if existing == style {
+			return
+		}
+	}
+	attributes["style"] = current + "," + style
 }
 
-// ToDo2度同じNodeに達した場合の対応を考える
-func addDomainNodeChildrenToDotGraph(rootNode node.TrackedEntity, rootDotNode *dotNode, g *simple.DirectedGraph, dotIdToIDMap map[string]*dotNode) {
-	for _, child := range rootNode.GetChildren() {
-		label := child.GetLabel()
+func positionURL(position node.Position) string {
+	return fmt.Sprintf("file://%s#L%d", position.File, position.Line)
+}
 
-		childDotNode, exists := dotIdToIDMap[label]
-		if !exists {
-			childDotNode = newDotNode(child, g.NewNode())
-			dotIdToIDMap[label] = childDotNode
+// DotGraphOption はDOTグラフへの変換方法を変更する
+type DotGraphOption func(*dotGraphBuilder)
+
+// WithGroupByPackage は関数Nodeを所属パッケージごとのクラスタにまとめる
+func WithGroupByPackage() DotGraphOption {
+	return func(b *dotGraphBuilder) {
+		b.groupByPackage = true
+	}
+}
+
+// WithTableRecords はテーブルNodeをアクセスされた列を並べた表として描き、列Nodeは出力しない
+func WithTableRecords() DotGraphOption {
+	return func(b *dotGraphBuilder) {
+		b.tableRecords = true
+	}
+}
+
+// dotGraphBuilder はドメインNodeを一度だけ訪問してDotGraphに変換する
+type dotGraphBuilder struct {
+	g                 *DotGraph
+	dotIdToDotNodeMap map[string]*dotNode
+	visited           map[node.TrackedEntity]bool
+	groupByPackage    bool
+	tableRecords      bool
+}
+
+func (b *dotGraphBuilder) dotNodeOf(domainNode node.TrackedEntity) *dotNode {
+	id := domainNode.GetID()
+	dn, exists := b.dotIdToDotNodeMap[id]
+	if !exists {
+		dn = newDotNode(domainNode, b.g.NewNode())
+		b.g.AddNode(dn)
+		b.dotIdToDotNodeMap[id] = dn
+		if fnNode, ok := domainNode.(*node.FunctionTrackedEntity); ok && b.groupByPackage && fnNode.GetPackagePath() != "" {
+			b.g.addToCluster(fnNode.GetPackagePath(), dn)
 		}
+		if table, ok := domainNode.(*node.DatabaseTableTrackedEntity); ok && b.tableRecords {
+			setTableRecordAttributes(dn, table)
+		}
+	}
+	return dn
+}
+
+// addDomainNode は訪問済みのNodeを再帰しないため、再帰関数でも停止する
+// 訪問済みNodeへの辺(後退辺)も辺として追加する
+func (b *dotGraphBuilder) addDomainNode(domainNode node.TrackedEntity) *dotNode {
+	dn := b.dotNodeOf(domainNode)
+	if b.visited[domainNode] {
+		return dn
+	}
+	b.visited[domainNode] = true
 
-		g.SetEdge(g.NewEdge(rootDotNode, childDotNode))
-		addDomainNodeChildrenToDotGraph(child, childDotNode, g, dotIdToIDMap)
+	for _, edge := range domainNode.GetEdges() {
+		// 表の形式ではテーブルNodeの中に列を描くため、列への辺は出力しない
+		if _, ok := edge.GetChild().(*node.ColumnTrackedEntity); ok && b.tableRecords {
+			continue
+		}
+		childDotNode := b.addDomainNode(edge.GetChild())
+		de := newDotEdge(dn, childDotNode)
+		setPositionAttributes(de.attributes, edge.GetPositions()...)
+		setCallAttributes(de.attributes, edge)
+		setAccessAttributes(de.attributes, edge)
+		setPreparationAttributes(de.attributes, edge)
+		b.g.SetEdge(de)
 	}
+	return dn
 }
 
-func NewDotGraph(rootNodes []node.TrackedEntity) *simple.DirectedGraph {
-	g := simple.NewDirectedGraph()
+// markRecursiveCycles は自己ループと強連結成分に含まれるNodeと辺に色を付ける
+func markRecursiveCycles(g *DotGraph) {
+	for _, component := range topo.TarjanSCC(g) {
+		if len(component) == 1 && !g.HasEdgeFromTo(component[0].ID(), component[0].ID()) {
+			continue
+		}
+
+		inComponent := make(map[int64]bool)
+		for _, n := range component {
+			inComponent[n.ID()] = true
+		}
+		for _, n := range component {
+			n.(*dotNode).attributes["color"] = recursiveColor
+			to := g.From(n.ID())
+			for to.Next() {
+				if !inComponent[to.Node().ID()] {
+					continue
+				}
+				if e, ok := g.Edge(n.ID(), to.Node().ID()).(*dotEdge); ok {
+					e.attributes["color"] = recursiveColor
+					addStyle(e.attributes, "bold")
+				}
+			}
+		}
+	}
+}
 
-	dotIdToDotNodeMap := make(map[string]*dotNode)
+func NewDotGraph(rootNodes []node.TrackedEntity, opts ...DotGraphOption) *DotGraph {
+	builder := &dotGraphBuilder{
+		g:                 newDotGraph(),
+		dotIdToDotNodeMap: make(map[string]*dotNode),
+		visited:           make(map[node.TrackedEntity]bool),
+	}
+	for _, opt := range opts {
+		opt(builder)
+	}
 	for _, rootNode := range rootNodes {
-		rootDotNode := newDotNode(rootNode, g.NewNode())
-		g.AddNode(rootDotNode)
-		dotIdToDotNodeMap[rootNode.GetLabel()] = rootDotNode
-		addDomainNodeChildrenToDotGraph(rootNode, rootDotNode, g, dotIdToDotNodeMap)
+		builder.addDomainNode(rootNode)
 	}
 
-	return g
+	markRecursiveCycles(builder.g)
+	return builder.g
 }
 
-func ConvertDotGraphToString(dotGraph *simple.DirectedGraph) (string, error) {
+func ConvertDotGraphToString(dotGraph *DotGraph) (string, error) {
 	b, err := dot.Marshal(dotGraph, "Graph", "", " ")
 	if err != nil {
 		return "", err
@@ -2,6 +2,7 @@ package application
 
 import (
 	"goAccessViz/cmd/goAccessViz/domain/node"
+	"strings"
 	"testing"
 
 	"gonum.org/v1/gonum/graph"
@@ -13,10 +14,11 @@ func TestNew(t *testing.T) {}
 
 // DotNodeのDOTIDメソッドのテスト
 func TestDOTID(t *testing.T) {
-	expected := "test.dot.node"
+	expected := "func:test.dot.node"
 
 	dotNode := &dotNode{
-		label: expected,
+		id:    expected,
+		label: "test.dot.node",
 		Node:  nil,
 	}
 
@@ -52,8 +54,11 @@ func TestNewDotNode(t *testing.T) {
 
 	dotNode := newDotNode(testNode, tmpGoNumDotNode)
 
-	if dotNode.DOTID() != testNodeName {
-		t.Errorf("Expected DOTID to be '%s', but got '%s'", testNodeName, dotNode.DOTID())
+	if dotNode.DOTID() != testNode.GetID() {
+		t.Errorf("Expected DOTID to be '%s', but got '%s'", testNode.GetID(), dotNode.DOTID())
+	}
+	if dotNode.Getlabel() != testNodeName {
+		t.Errorf("Expected label to be '%s', but got '%s'", testNodeName, dotNode.Getlabel())
 	}
 }
 
@@ -203,3 +208,313 @@ func TestNewDotGraphWithSomeRootNodes(t *testing.T) {
 		}
 	}
 }
+
+func findDotNodeByLabel(g *DotGraph, label string) *dotNode {
+	nodes := g.Nodes()
+	for nodes.Next() {
+		if dn := nodes.Node().(*dotNode); dn.Getlabel() == label {
+			return dn
+		}
+	}
+	return nil
+}
+
+// 自己再帰する関数 例:A -> A
+func TestNewDotGraphWithSelfRecursion(t *testing.T) {
+	recursiveNode := node.NewFunctionTrackedEntity("recursiveFunction", nil)
+	*recursiveNode = *node.NewFunctionTrackedEntity("recursiveFunction", []node.TrackedEntity{recursiveNode})
+
+	dotGraph := NewDotGraph([]node.TrackedEntity{recursiveNode})
+
+	dn := findDotNodeByLabel(dotGraph, "recursiveFunction")
+	if dn == nil {
+		t.Fatal("Expected recursive node in dot graph")
+	}
+	if !dotGraph.HasEdgeFromTo(dn.ID(), dn.ID()) {
+		t.Error("Expected self loop to be kept as an edge")
+	}
+	if dn.attributes["color"] != recursiveColor {
+		t.Errorf("Expected recursive node to be colored '%s', got '%s'", recursiveColor, dn.attributes["color"])
+	}
+
+	if _, err := ConvertDotGraphToString(dotGraph); err != nil {
+		t.Errorf("Failed to marshal graph: %v", err)
+	}
+}
+
+// 相互再帰する関数 例:A -> B -> A, B -> C
+func TestNewDotGraphWithMutualRecursion(t *testing.T) {
+	leafNode := node.NewFunctionTrackedEntity("leafFunction", nil)
+	nodeA := node.NewFunctionTrackedEntity("functionA", nil)
+	nodeB := node.NewFunctionTrackedEntity("functionB", []node.TrackedEntity{nodeA, leafNode})
+	*nodeA = *node.NewFunctionTrackedEntity("functionA", []node.TrackedEntity{nodeB})
+
+	dotGraph := NewDotGraph([]node.TrackedEntity{nodeA, nodeB, leafNode})
+
+	if dotGraph.Nodes().Len() != 3 {
+		t.Errorf("Expected 3 nodes, but got %d", dotGraph.Nodes().Len())
+	}
+
+	dotA := findDotNodeByLabel(dotGraph, "functionA")
+	dotB := findDotNodeByLabel(dotGraph, "functionB")
+	dotLeaf := findDotNodeByLabel(dotGraph, "leafFunction")
+	if !dotGraph.HasEdgeFromTo(dotA.ID(), dotB.ID()) || !dotGraph.HasEdgeFromTo(dotB.ID(), dotA.ID()) {
+		t.Error("Expected both edges of the cycle to be kept")
+	}
+	if dotA.attributes["color"] != recursiveColor || dotB.attributes["color"] != recursiveColor {
+		t.Error("Expected nodes in the cycle to be marked as recursive")
+	}
+	if _, ok := dotLeaf.attributes["color"]; ok {
+		t.Error("Expected node outside the cycle not to be marked as recursive")
+	}
+	if e := dotGraph.Edge(dotB.ID(), dotLeaf.ID()).(*dotEdge); e.attributes["color"] == recursiveColor {
+		t.Error("Expected edge leaving the cycle not to be marked as recursive")
+	}
+}
+
+// 同じ名前の関数とテーブルが別Nodeになることのテスト
+func TestNewDotGraphWithSameLabelDifferentKind(t *testing.T) {
+	tableNode := node.NewDatabaseTableTrackedEntity("users", nil)
+	functionNode := node.NewFunctionTrackedEntity("users", []node.TrackedEntity{tableNode})
+
+	dotGraph := NewDotGraph([]node.TrackedEntity{functionNode})
+
+	if dotGraph.Nodes().Len() != 2 {
+		t.Fatalf("Expected 2 nodes, but got %d", dotGraph.Nodes().Len())
+	}
+
+	converted, err := ConvertDotGraphToString(dotGraph)
+	if err != nil {
+		t.Fatalf("Failed to marshal graph: %v", err)
+	}
+	if !strings.Contains(converted, `"func:users" -> "table:users"`) {
+		t.Errorf("Expected edge keyed by kind-qualified IDs, got:\n%s", converted)
+	}
+}
+
+func TestNewDotNodeWithPackageBoundary(t *testing.T) {
+	tmpGraph := simple.NewDirectedGraph()
+	dotNode := newDotNode(node.NewPackageTrackedEntity("fmt"), tmpGraph.NewNode())
+
+	if dotNode.DOTID() != "pkg:fmt" {
+		t.Errorf("Expected DOTID to be 'pkg:fmt', but got '%s'", dotNode.DOTID())
+	}
+	if dotNode.attributes["shape"] != "folder" {
+		t.Errorf("Expected boundary node to be drawn as a folder, got '%s'", dotNode.attributes["shape"])
+	}
+}
+
+func TestNewDotGraphWithGroupByPackage(t *testing.T) {
+	callee := node.NewFunctionTrackedEntity("other.Callee", nil, node.WithPackagePath("example.com/other"))
+	caller := node.NewFunctionTrackedEntity("app.Caller", []node.TrackedEntity{callee}, node.WithPackagePath("example.com/app"))
+
+	dotGraph := NewDotGraph([]node.TrackedEntity{caller}, WithGroupByPackage())
+
+	clusters := dotGraph.Structure()
+	if len(clusters) != 2 {
+		t.Fatalf("Expected 2 package clusters, but got %d", len(clusters))
+	}
+
+	converted, err := ConvertDotGraphToString(dotGraph)
+	if err != nil {
+		t.Fatalf("Failed to marshal graph: %v", err)
+	}
+	if !strings.Contains(converted, `subgraph "cluster_example.com/app"`) {
+		t.Errorf("Expected a cluster for example.com/app, got:\n%s", converted)
+	}
+}
+
+func TestNewDotGraphWithPositions(t *testing.T) {
+	tableNode := node.NewDatabaseTableTrackedEntity("users", nil)
+	functionNode := node.NewFunctionTrackedEntity("app.GetUser", nil,
+		node.WithPosition(node.Position{File: "/src/app/user.go", Line: 10, Column: 6}),
+		node.WithEdges(node.NewEdge(tableNode, node.WithEdgePositions(
+			node.Position{File: "/src/app/user.go", Line: 12, Column: 9},
+			node.Position{File: "/src/app/user.go", Line: 15, Column: 9},
+		))),
+	)
+
+	dotGraph := NewDotGraph([]node.TrackedEntity{functionNode})
+
+	fnDotNode := findDotNodeByLabel(dotGraph, "app.GetUser")
+	if fnDotNode.attributes["tooltip"] != "/src/app/user.go:10:6" {
+		t.Errorf("Unexpected function tooltip '%s'", fnDotNode.attributes["tooltip"])
+	}
+	if fnDotNode.attributes["URL"] != "file:///src/app/user.go#L10" {
+		t.Errorf("Unexpected function URL '%s'", fnDotNode.attributes["URL"])
+	}
+
+	tableDotNode := findDotNodeByLabel(dotGraph, "users")
+	e := dotGraph.Edge(fnDotNode.ID(), tableDotNode.ID()).(*dotEdge)
+	if e.attributes["tooltip"] != "/src/app/user.go:12:9\n/src/app/user.go:15:9" {
+		t.Errorf("Unexpected edge tooltip '%s'", e.attributes["tooltip"])
+	}
+	if e.attributes["URL"] != "file:///src/app/user.go#L12" {
+		t.Errorf("Unexpected edge URL '%s'", e.attributes["URL"])
+	}
+}
+
+func TestNewDotGraphWithCallEdgeStyles(t *testing.T) {
+	worker := node.NewFunctionTrackedEntity("app.worker", nil)
+	handler := node.NewFunctionTrackedEntity("app.handler", nil)
+	helper := node.NewFunctionTrackedEntity("app.helper", nil)
+	hook := node.NewFunctionTrackedEntity("app.hook", nil)
+	caller := node.NewFunctionTrackedEntity("app.Run", nil, node.WithEdges(
+		node.NewEdge(worker, node.WithCallSites(1), node.WithDispatchKinds(node.DispatchStatic), node.WithGoroutine(true)),
+		node.NewEdge(handler, node.WithCallSites(1), node.WithDispatchKinds(node.DispatchInterface), node.WithDeferred(true)),
+		node.NewEdge(helper, node.WithCallSites(3), node.WithDispatchKinds(node.DispatchStatic)),
+		node.NewEdge(hook, node.WithCallSites(1), node.WithDispatchKinds(node.DispatchFunctionValue)),
+	))
+
+	dotGraph := NewDotGraph([]node.TrackedEntity{caller})
+	callerDotNode := findDotNodeByLabel(dotGraph, "app.Run")
+
+	tests := []struct {
+		callee string
+		style  string
+		label  string
+	}{
+		{"app.worker", "bold", "go"},
+		{"app.handler", "dashed", "defer"},
+		{"app.helper", "", "3 calls"},
+		{"app.hook", "dashed", ""},
+	}
+	for _, tt := range tests {
+		calleeDotNode := findDotNodeByLabel(dotGraph, tt.callee)
+		e := dotGraph.Edge(callerDotNode.ID(), calleeDotNode.ID()).(*dotEdge)
+		if e.attributes["style"] != tt.style {
+			t.Errorf("Expected edge to %s to have style '%s', got '%s'", tt.callee, tt.style, e.attributes["style"])
+		}
+		if e.attributes["label"] != tt.label {
+			t.Errorf("Expected edge to %s to have label '%s', got '%s'", tt.callee, tt.label, e.attributes["label"])
+		}
+	}
+}
+
+func TestNewDotGraphWithAccessModes(t *testing.T) {
+	users := node.NewDatabaseTableTrackedEntity("users", nil)
+	orders := node.NewDatabaseTableTrackedEntity("orders", nil)
+	caller := node.NewFunctionTrackedEntity("app.Sync", nil, node.WithEdges(
+		node.NewEdge(users, node.WithAccessModes(node.AccessSelect)),
+		node.NewEdge(orders, node.WithAccessModes(node.AccessDelete, node.AccessSelect)),
+	))
+
+	dotGraph := NewDotGraph([]node.TrackedEntity{caller})
+	callerDotNode := findDotNodeByLabel(dotGraph, "app.Sync")
+
+	tests := []struct {
+		table string
+		label string
+		color string
+		style string
+	}{
+		{"users", "SELECT", "blue", ""},
+		{"orders", "SELECT, DELETE", "blue:crimson", "bold"},
+	}
+	for _, tt := range tests {
+		tableDotNode := findDotNodeByLabel(dotGraph, tt.table)
+		e := dotGraph.Edge(callerDotNode.ID(), tableDotNode.ID()).(*dotEdge)
+		if e.attributes["label"] != tt.label {
+			t.Errorf("Expected edge to %s to have label '%s', got '%s'", tt.table, tt.label, e.attributes["label"])
+		}
+		if e.attributes["color"] != tt.color {
+			t.Errorf("Expected edge to %s to have color '%s', got '%s'", tt.table, tt.color, e.attributes["color"])
+		}
+		if e.attributes["style"] != tt.style {
+			t.Errorf("Expected edge to %s to have style '%s', got '%s'", tt.table, tt.style, e.attributes["style"])
+		}
+	}
+}
+
+func TestNewDotGraphWithPreparedStatement(t *testing.T) {
+	shipments := node.NewDatabaseTableTrackedEntity("shipments", nil)
+	executor := node.NewFunctionTrackedEntity("app.(*Store).Find", nil, node.WithEdges(
+		node.NewEdge(shipments,
+			node.WithEdgePositions(node.Position{File: "/src/app/store.go", Line: 20, Column: 9}),
+			node.WithAccessModes(node.AccessSelect),
+			node.WithPreparations(node.Preparation{Function: "app.NewStore", Position: node.Position{File: "/src/app/store.go", Line: 11, Column: 15}}),
+		),
+	))
+
+	dotGraph := NewDotGraph([]node.TrackedEntity{executor})
+	e := dotGraph.Edge(findDotNodeByLabel(dotGraph, "app.(*Store).Find").ID(), findDotNodeByLabel(dotGraph, "shipments").ID()).(*dotEdge)
+
+	expected := "/src/app/store.go:20:9\nprepared in app.NewStore at /src/app/store.go:11:15"
+	if e.attributes["tooltip"] != expected {
+		t.Errorf("Expected tooltip '%s', got '%s'", expected, e.attributes["tooltip"])
+	}
+	if e.attributes["URL"] != "file:///src/app/store.go#L20" {
+		t.Errorf("Expected URL to point at the execution, got '%s'", e.attributes["URL"])
+	}
+}
+
+func TestNewDotGraphWithColumns(t *testing.T) {
+	orders := node.NewDatabaseTableTrackedEntity("orders", nil)
+	status := node.NewColumnTrackedEntity("status", orders)
+	all := node.NewColumnTrackedEntity(node.AllColumns, orders)
+	*orders = *node.NewDatabaseTableTrackedEntity("orders", []node.TrackedEntity{all, status})
+	caller := node.NewFunctionTrackedEntity("app.Close", nil, node.WithEdges(
+		node.NewEdge(orders, node.WithAccessModes(node.AccessUpdate)),
+		node.NewEdge(status, node.WithAccessModes(node.AccessUpdate)),
+	))
+
+	dotGraph := NewDotGraph([]node.TrackedEntity{caller})
+	callerDotNode := findDotNodeByLabel(dotGraph, "app.Close")
+	statusDotNode := findDotNodeByLabel(dotGraph, "orders.status")
+	if statusDotNode == nil {
+		t.Fatal("Expected a column node for orders.status")
+	}
+	if !dotGraph.HasEdgeFromTo(callerDotNode.ID(), statusDotNode.ID()) {
+		t.Error("Expected an edge from app.Close to orders.status")
+	}
+	if !dotGraph.HasEdgeFromTo(findDotNodeByLabel(dotGraph, "orders").ID(), statusDotNode.ID()) {
+		t.Error("Expected an edge from orders to orders.status")
+	}
+
+	recordGraph := NewDotGraph([]node.TrackedEntity{caller}, WithTableRecords())
+	if findDotNodeByLabel(recordGraph, "orders.status") != nil {
+		t.Error("Expected no column nodes when tables are drawn as records")
+	}
+	tableDotNode := findDotNodeByLabel(recordGraph, `<<TABLE BORDER="0" CELLBORDER="1" CELLSPACING="0"><TR><TD><B>orders</B></TD></TR><TR><TD ALIGN="LEFT">*</TD></TR><TR><TD ALIGN="LEFT">status</TD></TR></TABLE>>`)
+	if tableDotNode == nil {
+		t.Fatal("Expected orders to be a table listing its columns")
+	}
+	if tableDotNode.attributes["shape"] != "plain" {
+		t.Errorf("Expected shape 'plain', got '%s'", tableDotNode.attributes["shape"])
+	}
+	if recordGraph.Nodes().Len() != 2 {
+		t.Errorf("Expected 2 nodes, got %d", recordGraph.Nodes().Len())
+	}
+}
+
+func TestTableRecordLabelEscaping(t *testing.T) {
+	table := node.NewDatabaseTableTrackedEntity("a<b", nil)
+	*table = *node.NewDatabaseTableTrackedEntity("a<b", []node.TrackedEntity{node.NewColumnTrackedEntity("x&y", table)})
+
+	out, err := ConvertDotGraphToString(NewDotGraph([]node.TrackedEntity{table}, WithTableRecords()))
+	if err != nil {
+		t.Fatalf("Failed to convert graph: %v", err)
+	}
+	if !strings.Contains(out, "<B>a&lt;b</B>") || !strings.Contains(out, ">x&amp;y<") {
+		t.Errorf("Expected escaped table and column names, got %s", out)
+	}
+	if strings.Contains(out, `label="<`) {
+		t.Errorf("Expected the label to be written as an HTML label, got %s", out)
+	}
+}
+
+func TestNewDotNodeWithDynamicTable(t *testing.T) {
+	tmpGraph := simple.NewDirectedGraph()
+	dynamic := newDotNode(node.NewDatabaseTableTrackedEntity("audit_%s", nil, node.WithDynamic()), tmpGraph.NewNode())
+	static := newDotNode(node.NewDatabaseTableTrackedEntity("audit", nil), tmpGraph.NewNode())
+
+	if dynamic.Getlabel() != "audit_%s" {
+		t.Errorf("Expected label 'audit_%%s', but got '%s'", dynamic.Getlabel())
+	}
+	if dynamic.attributes["style"] != "dashed" || dynamic.attributes["color"] != dynamicTableColor {
+		t.Errorf("Expected dynamic table to be dashed and %s, got %v", dynamicTableColor, dynamic.attributes)
+	}
+	if len(static.attributes) != 0 {
+		t.Errorf("Expected no attributes on a static table, got %v", static.attributes)
+	}
+}
@@ -0,0 +1,47 @@
+package node
+
+// AllColumns はSELECT *や列リストのないINSERTのように、すべての列への操作を表す列名
+const AllColumns = "*"
+
+// ColumnTrackedEntity はSQLテーブルの列に相当するNodeで、DatabaseTableTrackedEntityの子になる
+type ColumnTrackedEntity struct {
+	columnName string
+	table      *DatabaseTableTrackedEntity
+}
+
+func NewColumnTrackedEntity(columnName string, table *DatabaseTableTrackedEntity) *ColumnTrackedEntity {
+	return &ColumnTrackedEntity{
+		columnName: columnName,
+		table:      table,
+	}
+}
+
+func (col *ColumnTrackedEntity) GetChildren() []TrackedEntity {
+	return nil
+}
+
+func (col *ColumnTrackedEntity) GetEdges() []*Edge {
+	return nil
+}
+
+// GetID はテーブルの修飾名を含むため、別テーブルの同名の列は別のNodeになる
+func (col *ColumnTrackedEntity) GetID() string {
+	return columnIDPrefix + col.table.qualifiedID() + "." + quoteIDPart(col.columnName)
+}
+
+func (col *ColumnTrackedEntity) GetLabel() string {
+	return col.table.GetTableName() + "." + col.columnName
+}
+
+func (col *ColumnTrackedEntity) GetColumnName() string {
+	return col.columnName
+}
+
+func (col *ColumnTrackedEntity) GetTable() *DatabaseTableTrackedEntity {
+	return col.table
+}
+
+// IsAllColumns はSELECT *のようにすべての列への操作を表すNodeかを判定する
+func (col *ColumnTrackedEntity) IsAllColumns() bool {
+	return col.columnName == AllColumns
+}
@@ -1,22 +1,103 @@
 package node
 
+import "strings"
+
 // DatabaseTableTrackedEntity はSQLテーブルに相当するNode
 type DatabaseTableTrackedEntity struct {
 	tableName string
+	schema    string
+	dynamic   bool
 	children  []TrackedEntity
 }
 
-func NewDatabaseTableTrackedEntity(tableName string, children []TrackedEntity) *DatabaseTableTrackedEntity {
-	return &DatabaseTableTrackedEntity{
+type TableOption func(*DatabaseTableTrackedEntity)
+
+// WithSchema はテーブルが属するスキーマ(MySQLではデータベース)を記録する
+func WithSchema(schema string) TableOption {
+	return func(dbtb *DatabaseTableTrackedEntity) {
+		dbtb.schema = schema
+	}
+}
+
+// WithDynamic はテーブル名が実行時に組み立てられ、名前が"audit_%s"のようなパターンであることを記録する
+func WithDynamic() TableOption {
+	return func(dbtb *DatabaseTableTrackedEntity) {
+		dbtb.dynamic = true
+	}
+}
+
+func NewDatabaseTableTrackedEntity(tableName string, children []TrackedEntity, opts ...TableOption) *DatabaseTableTrackedEntity {
+	dbtb := &DatabaseTableTrackedEntity{
 		tableName: tableName,
 		children:  children,
 	}
+	for _, opt := range opts {
+		opt(dbtb)
+	}
+	return dbtb
 }
 
 func (dbtb *DatabaseTableTrackedEntity) GetChildren() []TrackedEntity {
 	return dbtb.children
 }
 
+// SetColumns はテーブルの子を、アクセスされた列のNodeで置き換える
+// 列のNodeはテーブルを参照するため、テーブルを作った後で設定する
+func (dbtb *DatabaseTableTrackedEntity) SetColumns(columns []*ColumnTrackedEntity) {
+	children := make([]TrackedEntity, 0, len(columns))
+	for _, column := range columns {
+		children = append(children, column)
+	}
+	dbtb.children = children
+}
+
+func (dbtb *DatabaseTableTrackedEntity) GetEdges() []*Edge {
+	return newEdgesFromChildren(dbtb.children)
+}
+
+// GetID はスキーマで修飾した名前から作るため、別スキーマの同名テーブルは別のNodeになる
+func (dbtb *DatabaseTableTrackedEntity) GetID() string {
+	return tableIDPrefix + dbtb.qualifiedID()
+}
+
+// qualifiedID は"."や"を含む名前をクォートするため、テーブル"a.b"とスキーマaのテーブルbは別のIDになる
+func (dbtb *DatabaseTableTrackedEntity) qualifiedID() string {
+	if dbtb.schema == "" {
+		return quoteIDPart(dbtb.tableName)
+	}
+	return quoteIDPart(dbtb.schema) + "." + quoteIDPart(dbtb.tableName)
+}
+
+func quoteIDPart(name string) string {
+	if !strings.ContainsAny(name, `."`) {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
 func (dbtb *DatabaseTableTrackedEntity) GetLabel() string {
+	return dbtb.GetQualifiedName()
+}
+
+// GetTableName はスキーマを含まないテーブル名を返す
+func (dbtb *DatabaseTableTrackedEntity) GetTableName() string {
 	return dbtb.tableName
 }
+
+// GetSchema はスキーマが分からない(SQLで修飾されておらず、デフォルトも指定されていない)場合は空文字を返す
+func (dbtb *DatabaseTableTrackedEntity) GetSchema() string {
+	return dbtb.schema
+}
+
+// GetQualifiedName はスキーマがあれば"schema.table"、なければテーブル名を返す
+func (dbtb *DatabaseTableTrackedEntity) GetQualifiedName() string {
+	if dbtb.schema == "" {
+		return dbtb.tableName
+	}
+	return dbtb.schema + "." + dbtb.tableName
+}
+
+// IsDynamic はテーブル名の一部が実行時まで分からないパターンかを判定する
+func (dbtb *DatabaseTableTrackedEntity) IsDynamic() bool {
+	return dbtb.dynamic
+}
@@ -0,0 +1,219 @@
+package node
+
+// DispatchKind は関数呼び出しの呼び出し方
+type DispatchKind string
+
+const (
+	// DispatchStatic は呼び出し先が静的に決まる呼び出し
+	DispatchStatic DispatchKind = "static"
+	// DispatchInterface はインターフェースのメソッド呼び出し
+	DispatchInterface DispatchKind = "interface"
+	// DispatchFunctionValue は関数値(変数や引数に入った関数)の呼び出し
+	DispatchFunctionValue DispatchKind = "function-value"
+)
+
+// AccessMode は関数からテーブルへのアクセスで行われる操作
+type AccessMode string
+
+const (
+	AccessSelect   AccessMode = "SELECT"
+	AccessInsert   AccessMode = "INSERT"
+	AccessUpdate   AccessMode = "UPDATE"
+	AccessDelete   AccessMode = "DELETE"
+	AccessUpsert   AccessMode = "UPSERT"
+	AccessTruncate AccessMode = "TRUNCATE"
+)
+
+// AccessModes は表示や比較で使う操作の順序
+var AccessModes = []AccessMode{AccessSelect, AccessInsert, AccessUpdate, AccessDelete, AccessUpsert, AccessTruncate}
+
+// IsWrite はテーブルの内容を変更する操作かを返す
+func (m AccessMode) IsWrite() bool {
+	return m != AccessSelect
+}
+
+// Preparation はプリペアドステートメントを準備した関数とその箇所
+// 実行した関数からテーブルへの辺に、SQLが書かれた場所の根拠として付く
+type Preparation struct {
+	Function string
+	Position Position
+}
+
+// Edge は親Nodeから子Nodeへの辺に相当する
+type Edge struct {
+	child      TrackedEntity
+	algorithm  string
+	positions  []Position
+	callSites  int
+	dispatches []DispatchKind
+	goroutine  bool
+	deferred   bool
+	modes      []AccessMode
+	prepared   []Preparation
+}
+
+type EdgeOption func(*Edge)
+
+// WithEdgeAlgorithm は辺を生成したコールグラフのアルゴリズムを記録する
+func WithEdgeAlgorithm(algorithm string) EdgeOption {
+	return func(e *Edge) {
+		e.algorithm = algorithm
+	}
+}
+
+// WithEdgePositions は辺の根拠となった呼び出し箇所(SQLの実行箇所など)を記録する
+func WithEdgePositions(positions ...Position) EdgeOption {
+	return func(e *Edge) {
+		e.positions = append(e.positions, positions...)
+	}
+}
+
+// WithCallSites は同じ呼び出し先への呼び出し箇所の数を記録する
+func WithCallSites(callSites int) EdgeOption {
+	return func(e *Edge) {
+		e.callSites = callSites
+	}
+}
+
+// WithDispatchKinds は呼び出し箇所の呼び出し方を記録する
+func WithDispatchKinds(dispatches ...DispatchKind) EdgeOption {
+	return func(e *Edge) {
+		e.dispatches = append(e.dispatches, dispatches...)
+	}
+}
+
+// WithGoroutine はgo文による呼び出しを含むことを記録する
+func WithGoroutine(goroutine bool) EdgeOption {
+	return func(e *Edge) {
+		e.goroutine = goroutine
+	}
+}
+
+// WithDeferred はdefer文による呼び出しを含むことを記録する
+func WithDeferred(deferred bool) EdgeOption {
+	return func(e *Edge) {
+		e.deferred = deferred
+	}
+}
+
+// WithAccessModes はテーブルへの辺で行われる操作を記録する(重複を除きAccessModesの順に並べる)
+func WithAccessModes(modes ...AccessMode) EdgeOption {
+	return func(e *Edge) {
+		seen := make(map[AccessMode]bool)
+		for _, mode := range append(e.modes, modes...) {
+			seen[mode] = true
+		}
+		e.modes = nil
+		for _, mode := range AccessModes {
+			if seen[mode] {
+				e.modes = append(e.modes, mode)
+			}
+		}
+	}
+}
+
+// WithPreparations はプリペアドステートメントを準備した関数と箇所を記録する
+func WithPreparations(preparations ...Preparation) EdgeOption {
+	return func(e *Edge) {
+		for _, preparation := range preparations {
+			if !containsPreparation(e.prepared, preparation) {
+				e.prepared = append(e.prepared, preparation)
+			}
+		}
+	}
+}
+
+func containsPreparation(preparations []Preparation, preparation Preparation) bool {
+	for _, existing := range preparations {
+		if existing == preparation {
+			return true
+		}
+	}
+	return false
+}
+
+func NewEdge(child TrackedEntity, opts ...EdgeOption) *Edge {
+	e := &Edge{
+		child: child,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+func (e *Edge) GetChild() TrackedEntity {
+	return e.child
+}
+
+// GetAlgorithm はコールグラフ由来でない辺(SQLによるテーブル参照など)では空文字を返す
+func (e *Edge) GetAlgorithm() string {
+	return e.algorithm
+}
+
+func (e *Edge) GetPositions() []Position {
+	return e.positions
+}
+
+// GetCallSites は呼び出し箇所の数を返す(呼び出し以外の辺では0)
+func (e *Edge) GetCallSites() int {
+	return e.callSites
+}
+
+func (e *Edge) GetDispatchKinds() []DispatchKind {
+	return e.dispatches
+}
+
+// IsDynamic はインターフェースや関数値を経由した呼び出しを含むかを返す
+func (e *Edge) IsDynamic() bool {
+	for _, dispatch := range e.dispatches {
+		if dispatch != DispatchStatic {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Edge) IsGoroutine() bool {
+	return e.goroutine
+}
+
+func (e *Edge) IsDeferred() bool {
+	return e.deferred
+}
+
+// GetAccessModes はテーブルへの辺で行われる操作を返す(呼び出しの辺では空)
+func (e *Edge) GetAccessModes() []AccessMode {
+	return e.modes
+}
+
+// IsWrite はテーブルの内容を変更する操作を含むかを返す
+func (e *Edge) IsWrite() bool {
+	for _, mode := range e.modes {
+		if mode.IsWrite() {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPreparations はプリペアドステートメントを準備した関数と箇所を返す(直接実行したSQLの辺では空)
+func (e *Edge) GetPreparations() []Preparation {
+	return e.prepared
+}
+
+func newEdgesFromChildren(children []TrackedEntity) []*Edge {
+	edges := make([]*Edge, 0, len(children))
+	for _, child := range children {
+		edges = append(edges, NewEdge(child))
+	}
+	return edges
+}
+
+func childrenFromEdges(edges []*Edge) []TrackedEntity {
+	children := make([]TrackedEntity, 0, len(edges))
+	for _, edge := range edges {
+		children = append(children, edge.child)
+	}
+	return children
+}
@@ -3,20 +3,89 @@ package node
 // 関数に相当するNode
 type FunctionTrackedEntity struct {
 	funtionName string
-	children    []TrackedEntity
+	edges       []*Edge
+	algorithm   string
+	packagePath string
+	position    Position
+	incomplete  bool
 }
 
-func NewFunctionTrackedEntity(functionName string, children []TrackedEntity) *FunctionTrackedEntity {
-	return &FunctionTrackedEntity{
+type FunctionOption func(*FunctionTrackedEntity)
+
+// WithEdges はメタデータ付きの辺を子Nodeとして追加する
+func WithEdges(edges ...*Edge) FunctionOption {
+	return func(fn *FunctionTrackedEntity) {
+		fn.edges = append(fn.edges, edges...)
+	}
+}
+
+// WithAlgorithm はNodeを生成したコールグラフのアルゴリズムを記録する
+func WithAlgorithm(algorithm string) FunctionOption {
+	return func(fn *FunctionTrackedEntity) {
+		fn.algorithm = algorithm
+	}
+}
+
+// WithPackagePath は関数が所属するパッケージを記録する
+func WithPackagePath(packagePath string) FunctionOption {
+	return func(fn *FunctionTrackedEntity) {
+		fn.packagePath = packagePath
+	}
+}
+
+// WithPosition は関数が定義されている位置を記録する
+func WithPosition(position Position) FunctionOption {
+	return func(fn *FunctionTrackedEntity) {
+		fn.position = position
+	}
+}
+
+// WithIncomplete は所属パッケージに読み込みや型のエラーがあり、辺が欠けている可能性を記録する
+func WithIncomplete(incomplete bool) FunctionOption {
+	return func(fn *FunctionTrackedEntity) {
+		fn.incomplete = incomplete
+	}
+}
+
+func NewFunctionTrackedEntity(functionName string, children []TrackedEntity, opts ...FunctionOption) *FunctionTrackedEntity {
+	fn := &FunctionTrackedEntity{
 		funtionName: functionName,
-		children:    children,
+		edges:       newEdgesFromChildren(children),
+	}
+	for _, opt := range opts {
+		opt(fn)
 	}
+	return fn
 }
 
 func (fn *FunctionTrackedEntity) GetChildren() []TrackedEntity {
-	return fn.children
+	return childrenFromEdges(fn.edges)
+}
+
+func (fn *FunctionTrackedEntity) GetEdges() []*Edge {
+	return fn.edges
+}
+
+func (fn *FunctionTrackedEntity) GetID() string {
+	return functionIDPrefix + fn.funtionName
 }
 
 func (fn *FunctionTrackedEntity) GetLabel() string {
 	return fn.funtionName
 }
+
+func (fn *FunctionTrackedEntity) GetAlgorithm() string {
+	return fn.algorithm
+}
+
+func (fn *FunctionTrackedEntity) GetPackagePath() string {
+	return fn.packagePath
+}
+
+func (fn *FunctionTrackedEntity) GetPosition() Position {
+	return fn.position
+}
+
+func (fn *FunctionTrackedEntity) IsIncomplete() bool {
+	return fn.incomplete
+}
@@ -1,6 +1,18 @@
 package node
 
+// IDの種類を表す接頭辞
+const (
+	functionIDPrefix = "func:"
+	tableIDPrefix    = "table:"
+	packageIDPrefix  = "pkg:"
+	columnIDPrefix   = "column:"
+)
+
 type TrackedEntity interface {
 	GetChildren() []TrackedEntity
+	GetEdges() []*Edge
+	// GetID は種類で修飾された一意なIDを返す(例: "func:pkg.F", "table:users")
+	// 表示用のGetLabelとは異なり、種類の違うNodeが同じ名前でも衝突しない
+	GetID() string
 	GetLabel() string
 }
@@ -69,3 +69,166 @@ func TestDBTableNodeGetChildren(t *testing.T) {
 	}
 
 }
+
+func TestFunctionNodeWithEdges(t *testing.T) {
+	child := NewFunctionTrackedEntity("callee", nil)
+	functionNode := NewFunctionTrackedEntity("caller", nil,
+		WithEdges(NewEdge(child, WithEdgeAlgorithm("rta"))),
+		WithAlgorithm("rta"),
+	)
+
+	if functionNode.GetAlgorithm() != "rta" {
+		t.Errorf("Expected algorithm 'rta', but got '%s'", functionNode.GetAlgorithm())
+	}
+
+	edges := functionNode.GetEdges()
+	if len(edges) != 1 {
+		t.Fatalf("Expected 1 edge, but got %d", len(edges))
+	}
+	if edges[0].GetChild() != child || edges[0].GetAlgorithm() != "rta" {
+		t.Errorf("Unexpected edge %v", edges[0])
+	}
+	if functionNode.GetChildren()[0] != child {
+		t.Errorf("Expected child %v, but got %v", child, functionNode.GetChildren()[0])
+	}
+}
+
+func TestTrackedEntityID(t *testing.T) {
+	functionNode := NewFunctionTrackedEntity("users", nil)
+	dbTableNode := NewDatabaseTableTrackedEntity("users", nil)
+
+	if functionNode.GetID() != "func:users" {
+		t.Errorf("Expected function ID 'func:users', but got '%s'", functionNode.GetID())
+	}
+	if dbTableNode.GetID() != "table:users" {
+		t.Errorf("Expected table ID 'table:users', but got '%s'", dbTableNode.GetID())
+	}
+	if functionNode.GetLabel() != dbTableNode.GetLabel() {
+		t.Error("Expected labels to stay the display name")
+	}
+}
+
+func TestFunctionNodeMetadata(t *testing.T) {
+	position := Position{File: "/src/app/main.go", Line: 3, Column: 6}
+	functionNode := NewFunctionTrackedEntity("app.Run", nil,
+		WithPackagePath("example.com/app"),
+		WithPosition(position),
+		WithIncomplete(true),
+	)
+
+	if functionNode.GetPackagePath() != "example.com/app" {
+		t.Errorf("Expected package 'example.com/app', but got '%s'", functionNode.GetPackagePath())
+	}
+	if functionNode.GetPosition() != position || position.String() != "/src/app/main.go:3:6" {
+		t.Errorf("Unexpected position %v", functionNode.GetPosition())
+	}
+	if !functionNode.IsIncomplete() {
+		t.Error("Expected function node to be incomplete")
+	}
+	if (Position{}).IsValid() {
+		t.Error("Expected zero position to be invalid")
+	}
+}
+
+func TestEdgeAccessModes(t *testing.T) {
+	table := NewDatabaseTableTrackedEntity("users", nil)
+	edge := NewEdge(table,
+		WithAccessModes(AccessUpdate, AccessSelect),
+		WithAccessModes(AccessUpdate),
+	)
+
+	modes := edge.GetAccessModes()
+	if len(modes) != 2 || modes[0] != AccessSelect || modes[1] != AccessUpdate {
+		t.Errorf("Expected [SELECT UPDATE], got %v", modes)
+	}
+	if !edge.IsWrite() {
+		t.Error("Expected edge with UPDATE to be a write")
+	}
+	if NewEdge(table, WithAccessModes(AccessSelect)).IsWrite() {
+		t.Error("Expected read-only edge not to be a write")
+	}
+}
+
+func TestEdgePreparations(t *testing.T) {
+	table := NewDatabaseTableTrackedEntity("users", nil)
+	preparation := Preparation{Function: "NewStore", Position: Position{File: "store.go", Line: 12, Column: 2}}
+	edge := NewEdge(table,
+		WithPreparations(preparation),
+		WithPreparations(preparation),
+	)
+
+	preparations := edge.GetPreparations()
+	if len(preparations) != 1 || preparations[0] != preparation {
+		t.Errorf("Expected one preparation in NewStore, got %v", preparations)
+	}
+	if len(NewEdge(table).GetPreparations()) != 0 {
+		t.Error("Expected an edge without a prepared statement to have no preparations")
+	}
+}
+
+func TestDatabaseTableNodeWithSchema(t *testing.T) {
+	publicUsers := NewDatabaseTableTrackedEntity("users", nil, WithSchema("public"))
+	authUsers := NewDatabaseTableTrackedEntity("Users", nil, WithSchema("auth"))
+
+	if publicUsers.GetSchema() != "public" || publicUsers.GetTableName() != "users" {
+		t.Errorf("Expected schema 'public' and table 'users', got '%s' and '%s'", publicUsers.GetSchema(), publicUsers.GetTableName())
+	}
+	if publicUsers.GetLabel() != "public.users" {
+		t.Errorf("Expected label 'public.users', but got '%s'", publicUsers.GetLabel())
+	}
+	if authUsers.GetID() != "table:auth.Users" {
+		t.Errorf("Expected ID 'table:auth.Users', but got '%s'", authUsers.GetID())
+	}
+	if publicUsers.GetID() == authUsers.GetID() {
+		t.Error("Expected tables in different schemas to have different IDs")
+	}
+	dotted := NewDatabaseTableTrackedEntity("a.b", nil)
+	qualified := NewDatabaseTableTrackedEntity("b", nil, WithSchema("a"))
+	if dotted.GetID() == qualified.GetID() {
+		t.Errorf("Expected the table \"a.b\" and the table a.b to have different IDs, both got '%s'", dotted.GetID())
+	}
+}
+
+func TestColumnNode(t *testing.T) {
+	table := NewDatabaseTableTrackedEntity("users", nil, WithSchema("public"))
+	email := NewColumnTrackedEntity("email", table)
+	all := NewColumnTrackedEntity(AllColumns, table)
+
+	if email.GetID() != "column:public.users.email" {
+		t.Errorf("Expected ID 'column:public.users.email', but got '%s'", email.GetID())
+	}
+	if email.GetLabel() != "users.email" {
+		t.Errorf("Expected label 'users.email', but got '%s'", email.GetLabel())
+	}
+	if email.GetTable() != table || email.IsAllColumns() {
+		t.Error("Expected 'email' to be a regular column of 'users'")
+	}
+	if !all.IsAllColumns() || all.GetID() == email.GetID() {
+		t.Error("Expected '*' to be a distinct all-columns marker")
+	}
+}
+
+func TestDynamicDatabaseTableNode(t *testing.T) {
+	audit := NewDatabaseTableTrackedEntity("audit_%s", nil, WithDynamic())
+	users := NewDatabaseTableTrackedEntity("users", nil)
+
+	if !audit.IsDynamic() || users.IsDynamic() {
+		t.Error("Expected only the audit pattern to be dynamic")
+	}
+	if audit.GetLabel() != "audit_%s" {
+		t.Errorf("Expected label 'audit_%%s', but got '%s'", audit.GetLabel())
+	}
+}
+
+func TestDatabaseTableSetColumns(t *testing.T) {
+	audit := NewDatabaseTableTrackedEntity("audit_%s", nil, WithSchema("logs"), WithDynamic())
+	entry := NewColumnTrackedEntity("entry", audit)
+	audit.SetColumns([]*ColumnTrackedEntity{entry})
+
+	if children := audit.GetChildren(); len(children) != 1 || children[0] != entry {
+		t.Errorf("Expected the entry column as the only child, got %v", children)
+	}
+	if !audit.IsDynamic() || audit.GetSchema() != "logs" || audit.GetTableName() != "audit_%s" {
+		t.Error("Expected setting the columns to keep the other table attributes")
+	}
+}
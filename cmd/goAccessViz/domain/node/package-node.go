@@ -0,0 +1,28 @@
+package node
+
+// PackageTrackedEntity は解析対象外のパッケージへの呼び出しをまとめた境界Node
+type PackageTrackedEntity struct {
+	packagePath string
+}
+
+func NewPackageTrackedEntity(packagePath string) *PackageTrackedEntity {
+	return &PackageTrackedEntity{
+		packagePath: packagePath,
+	}
+}
+
+func (pkg *PackageTrackedEntity) GetChildren() []TrackedEntity {
+	return nil
+}
+
+func (pkg *PackageTrackedEntity) GetEdges() []*Edge {
+	return nil
+}
+
+func (pkg *PackageTrackedEntity) GetID() string {
+	return packageIDPrefix + pkg.packagePath
+}
+
+func (pkg *PackageTrackedEntity) GetLabel() string {
+	return pkg.packagePath
+}
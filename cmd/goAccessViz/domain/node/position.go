@@ -0,0 +1,21 @@
+package node
+
+import "fmt"
+
+// Position はNodeや辺の元になったソースコード上の位置
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (p Position) IsValid() bool {
+	return p.File != "" && p.Line > 0
+}
+
+func (p Position) String() string {
+	if !p.IsValid() {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
@@ -1,31 +1,105 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	"goAccessViz/cmd/goAccessViz/application"
 	"goAccessViz/cmd/goAccessViz/repository"
 )
 
 func main() {
-	// Get package path from command line arguments
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: goAccessViz <package-path>")
+	callGraphFlag := flag.String("callgraph", string(repository.CHA), "call graph algorithm: cha, rta, vta or static")
+	entryFlag := flag.String("entry", "", "comma separated root functions for rta (e.g. example.com/pkg.Run)")
+	foldClosuresFlag := flag.Bool("fold-closures", false, "attribute closures to the function that defines them")
+	includeFlag := flag.String("include", "", "comma separated package patterns to analyze (defaults to the main module)")
+	excludeFlag := flag.String("exclude", "", "comma separated package patterns to leave out")
+	strictFlag := flag.Bool("strict", false, "fail on package load, parse or type errors instead of rendering a partial graph")
+	groupByPackageFlag := flag.Bool("group-by-package", false, "draw functions of each package in their own cluster")
+	boundaryFlag := flag.String("boundary", string(repository.BoundaryDrop), "out-of-scope callees: drop or collapse (one node per package)")
+	modeFlag := flag.String("mode", "all", "table accesses to draw: all, read, write or a comma separated list of select, insert, update, delete, upsert, truncate")
+	defaultSchemaFlag := flag.String("default-schema", "", "schema of tables that are not schema-qualified in SQL (e.g. public)")
+	columnsFlag := flag.Bool("columns", false, "track accessed columns as nodes under their tables (SELECT * becomes a \"*\" column)")
+	tableRecordsFlag := flag.Bool("table-records", false, "draw each table as one node listing its accessed columns (use with --columns)")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: goAccessViz [flags] <package-pattern>...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// Get package patterns from command line arguments
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	patterns := flag.Args()
+
+	algorithm, err := repository.ParseCallGraphAlgorithm(*callGraphFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	boundary, err := repository.ParseBoundaryMode(*boundaryFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	accessModes, err := repository.ParseAccessModes(*modeFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	packagePath := os.Args[1]
+	var diagnostics repository.Diagnostics
+	opts := []repository.Option{
+		repository.WithCallGraphAlgorithm(algorithm),
+		repository.WithStrict(*strictFlag),
+		repository.WithDiagnostics(&diagnostics),
+		repository.WithFoldClosures(*foldClosuresFlag),
+		repository.WithBoundaryMode(boundary),
+		repository.WithAccessModes(accessModes...),
+		repository.WithDefaultSchema(*defaultSchemaFlag),
+		repository.WithColumns(*columnsFlag),
+	}
+	if *entryFlag != "" {
+		opts = append(opts, repository.WithEntryPoints(strings.Split(*entryFlag, ",")...))
+	}
+	if *includeFlag != "" {
+		opts = append(opts, repository.WithIncludePackages(strings.Split(*includeFlag, ",")...))
+	}
+	if *excludeFlag != "" {
+		opts = append(opts, repository.WithExcludePackages(strings.Split(*excludeFlag, ",")...))
+	}
 
 	// Read the graph with SQL analysis
-	nodes, err := repository.ReadGraph(packagePath)
+	nodes, err := repository.ReadGraph(patterns, opts...)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error reading graph: %v\n", err)
 		os.Exit(1)
 	}
 
+	// Report package errors but still render what could be analyzed
+	for _, diagnostic := range diagnostics.Diagnostics {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", diagnostic)
+	}
+	for _, pkgPath := range diagnostics.IncompletePackages {
+		fmt.Fprintf(os.Stderr, "Warning: graph for %s may be incomplete\n", pkgPath)
+	}
+
 	// Convert to DOT graph
-	dotGraph := application.NewDotGraph(nodes)
+	var dotOpts []application.DotGraphOption
+	if *groupByPackageFlag {
+		dotOpts = append(dotOpts, application.WithGroupByPackage())
+	}
+	if *tableRecordsFlag {
+		dotOpts = append(dotOpts, application.WithTableRecords())
+	}
+	dotGraph := application.NewDotGraph(nodes, dotOpts...)
 
 	// Convert to string and print
 	convertedDotGraph, err := application.ConvertDotGraphToString(dotGraph)
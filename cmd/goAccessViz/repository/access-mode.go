@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"goAccessViz/cmd/goAccessViz/domain/node"
+	"goAccessViz/cmd/goAccessViz/repository/sqlparser"
+)
+
+// accessModeOfOperation はSQLの構文解析で求めた操作をドメインの操作に対応付ける
+var accessModeOfOperation = map[sqlparser.Operation]node.AccessMode{
+	sqlparser.OperationSelect:   node.AccessSelect,
+	sqlparser.OperationInsert:   node.AccessInsert,
+	sqlparser.OperationUpdate:   node.AccessUpdate,
+	sqlparser.OperationDelete:   node.AccessDelete,
+	sqlparser.OperationUpsert:   node.AccessUpsert,
+	sqlparser.OperationTruncate: node.AccessTruncate,
+}
+
+// ParseAccessModes はCLIの引数などから残すテーブルアクセスの操作を解決する
+// "all"(または空)はすべて、"read"は参照のみ、"write"は変更を伴う操作、
+// それ以外はカンマ区切りの操作名(例: "insert,delete")として扱う
+func ParseAccessModes(value string) ([]node.AccessMode, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "all":
+		return nil, nil
+	case "read":
+		return []node.AccessMode{node.AccessSelect}, nil
+	case "write":
+		var modes []node.AccessMode
+		for _, mode := range node.AccessModes {
+			if mode.IsWrite() {
+				modes = append(modes, mode)
+			}
+		}
+		return modes, nil
+	}
+
+	var modes []node.AccessMode
+	for _, name := range strings.Split(value, ",") {
+		mode, ok := parseAccessMode(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("unknown access mode %q (expected all, read, write or a list of select, insert, update, delete, upsert, truncate)", name)
+		}
+		modes = append(modes, mode)
+	}
+	return modes, nil
+}
+
+func parseAccessMode(name string) (node.AccessMode, bool) {
+	for _, mode := range node.AccessModes {
+		if strings.EqualFold(string(mode), name) {
+			return mode, true
+		}
+	}
+	return "", false
+}
+
+// acceptsAccessMode は操作がフィルタに含まれるかを判定する(フィルタが空ならすべて含む)
+func (config *readGraphConfig) acceptsAccessMode(mode node.AccessMode) bool {
+	if len(config.accessModes) == 0 {
+		return true
+	}
+	for _, accepted := range config.accessModes {
+		if accepted == mode {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"goAccessViz/cmd/goAccessViz/domain/node"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// callEdge は同じ呼び出し元から同じ呼び出し先への呼び出しをまとめたもの
+type callEdge struct {
+	child node.TrackedEntity
+	sites []ssa.CallInstruction
+}
+
+// callEdgeMap は呼び出し元ごとのcallEdgeを見つけた順に保持する
+type callEdgeMap struct {
+	callers []*ssa.Function
+	edges   map[*ssa.Function][]*callEdge
+}
+
+func newCallEdgeMap() *callEdgeMap {
+	return &callEdgeMap{
+		edges: make(map[*ssa.Function][]*callEdge),
+	}
+}
+
+func (m *callEdgeMap) add(caller *ssa.Function, child node.TrackedEntity, site ssa.CallInstruction) {
+	if _, exists := m.edges[caller]; !exists {
+		m.callers = append(m.callers, caller)
+	}
+	for _, edge := range m.edges[caller] {
+		if edge.child == child {
+			edge.addSite(site)
+			return
+		}
+	}
+	edge := &callEdge{child: child}
+	edge.addSite(site)
+	m.edges[caller] = append(m.edges[caller], edge)
+}
+
+// addSite はCHAなどが同じ呼び出し箇所から同じ先へ複数の辺を作る場合に重複を数えない
+func (e *callEdge) addSite(site ssa.CallInstruction) {
+	if site != nil {
+		for _, existing := range e.sites {
+			if existing == site {
+				return
+			}
+		}
+	}
+	e.sites = append(e.sites, site)
+}
+
+func (e *callEdge) toEdge(algorithm CallGraphAlgorithm) *node.Edge {
+	var positions []node.Position
+	var dispatches []node.DispatchKind
+	goroutine, deferred := false, false
+	for _, site := range e.sites {
+		if site == nil {
+			continue
+		}
+		if site.Parent() != nil {
+			positions = append(positions, toPosition(site.Parent().Prog.Fset, site.Pos()))
+		}
+		dispatches = appendDispatchKind(dispatches, dispatchKindOf(site))
+		switch site.(type) {
+		case *ssa.Go:
+			goroutine = true
+		case *ssa.Defer:
+			deferred = true
+		}
+	}
+
+	return node.NewEdge(e.child,
+		node.WithEdgeAlgorithm(string(algorithm)),
+		node.WithEdgePositions(positions...),
+		node.WithCallSites(len(e.sites)),
+		node.WithDispatchKinds(dispatches...),
+		node.WithGoroutine(goroutine),
+		node.WithDeferred(deferred),
+	)
+}
+
+// dispatchKindOf は呼び出しが静的呼び出し、インターフェース経由、関数値経由のいずれかを返す
+func dispatchKindOf(site ssa.CallInstruction) node.DispatchKind {
+	common := site.Common()
+	if common.IsInvoke() {
+		return node.DispatchInterface
+	}
+	if common.StaticCallee() != nil {
+		return node.DispatchStatic
+	}
+	return node.DispatchFunctionValue
+}
+
+func appendDispatchKind(dispatches []node.DispatchKind, kind node.DispatchKind) []node.DispatchKind {
+	for _, existing := range dispatches {
+		if existing == kind {
+			return dispatches
+		}
+	}
+	return append(dispatches, kind)
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"fmt"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/callgraph/rta"
+	"golang.org/x/tools/go/callgraph/static"
+	"golang.org/x/tools/go/callgraph/vta"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// CallGraphAlgorithm はコールグラフの構築に使うアルゴリズム
+type CallGraphAlgorithm string
+
+const (
+	CHA    CallGraphAlgorithm = "cha"
+	RTA    CallGraphAlgorithm = "rta"
+	VTA    CallGraphAlgorithm = "vta"
+	Static CallGraphAlgorithm = "static"
+)
+
+// ParseCallGraphAlgorithm はCLIの引数などからアルゴリズムを解決する
+func ParseCallGraphAlgorithm(name string) (CallGraphAlgorithm, error) {
+	switch algorithm := CallGraphAlgorithm(name); algorithm {
+	case CHA, RTA, VTA, Static:
+		return algorithm, nil
+	}
+	return "", fmt.Errorf("unknown call graph algorithm %q (expected cha, rta, vta or static)", name)
+}
+
+func buildCallGraph(prog *ssa.Program, pkgs []*packages.Package, config *readGraphConfig) (*callgraph.Graph, error) {
+	switch config.algorithm {
+	case CHA:
+		return cha.CallGraph(prog), nil
+	case RTA:
+		roots, err := findRTARoots(prog, pkgs, config.entryPoints)
+		if err != nil {
+			return nil, err
+		}
+		return rta.Analyze(roots, true).CallGraph, nil
+	case VTA:
+		return vta.CallGraph(ssautil.AllFunctions(prog), cha.CallGraph(prog)), nil
+	case Static:
+		return static.CallGraph(prog), nil
+	}
+	return nil, fmt.Errorf("unknown call graph algorithm %q", config.algorithm)
+}
+
+// findRTARoots はRTAの起点となる関数を返す
+// エントリポイントが指定されていればそれを使い、なければmain/initを起点にする
+// mainパッケージを含まない場合はパッケージの全関数を起点にする
+func findRTARoots(prog *ssa.Program, pkgs []*packages.Package, entryPoints []string) ([]*ssa.Function, error) {
+	var roots []*ssa.Function
+	if len(entryPoints) > 0 {
+		functionsByName := make(map[string]*ssa.Function)
+		for fn := range ssautil.AllFunctions(prog) {
+			functionsByName[fn.String()] = fn
+		}
+		for _, name := range entryPoints {
+			fn, exists := functionsByName[name]
+			if !exists {
+				return nil, fmt.Errorf("entry point %q not found", name)
+			}
+			roots = append(roots, fn)
+		}
+		return roots, nil
+	}
+
+	targetPkgs := targetSSAPackages(prog, pkgs)
+	for _, mainPkg := range ssautil.MainPackages(targetPkgs) {
+		roots = append(roots, mainPkg.Func("init"), mainPkg.Func("main"))
+	}
+	if len(roots) > 0 {
+		return roots, nil
+	}
+
+	for _, ssaPkg := range targetPkgs {
+		for _, member := range ssaPkg.Members {
+			if fn, ok := member.(*ssa.Function); ok {
+				roots = append(roots, fn)
+			}
+		}
+	}
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no root functions found for rta")
+	}
+	return roots, nil
+}
+
+func targetSSAPackages(prog *ssa.Program, pkgs []*packages.Package) []*ssa.Package {
+	var ssaPkgs []*ssa.Package
+	for _, pkg := range pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		if ssaPkg := prog.Package(pkg.Types); ssaPkg != nil {
+			ssaPkgs = append(ssaPkgs, ssaPkg)
+		}
+	}
+	return ssaPkgs
+}
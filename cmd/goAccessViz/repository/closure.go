@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"go/ast"
+	"goAccessViz/cmd/goAccessViz/domain/node"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// outermostFunction は無名関数を定義している最も外側の関数を返す
+func outermostFunction(fn *ssa.Function) *ssa.Function {
+	for fn.Parent() != nil {
+		fn = fn.Parent()
+	}
+	return fn
+}
+
+// collectAnonFunctions はFuncLitから対応するSSAの無名関数を引けるようにする
+func collectAnonFunctions(fn *ssa.Function, anonFuncs map[*ast.FuncLit]*ssa.Function) {
+	for _, anonFunc := range fn.AnonFuncs {
+		if lit, ok := anonFunc.Syntax().(*ast.FuncLit); ok {
+			anonFuncs[lit] = anonFunc
+		}
+		collectAnonFunctions(anonFunc, anonFuncs)
+	}
+}
+
+// functionBodies はFuncDeclとその中のFuncLitそれぞれの本体を返す
+func functionBodies(funcDecl *ast.FuncDecl) map[ast.Node]*ast.BlockStmt {
+	bodies := make(map[ast.Node]*ast.BlockStmt)
+	if funcDecl.Body == nil {
+		return bodies
+	}
+	bodies[funcDecl] = funcDecl.Body
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.FuncLit); ok {
+			bodies[lit] = lit.Body
+		}
+		return true
+	})
+	return bodies
+}
+
+// linkClosuresToParents は無名関数を定義元の関数の子にする
+// errgroupやsync.Onceに渡された無名関数はコールグラフ上で定義元から直接呼ばれないため
+func linkClosuresToParents(nodeMap map[*ssa.Function]*node.FunctionTrackedEntity, childrenMap map[*ssa.Function][]*node.Edge) {
+	var closures []*ssa.Function
+	for fn := range nodeMap {
+		if fn.Parent() != nil {
+			closures = append(closures, fn)
+		}
+	}
+
+	for _, closure := range closures {
+		// ネストした無名関数は定義元を順に辿ってつなぐ
+		for fn := closure; fn.Parent() != nil; fn = fn.Parent() {
+			parent := fn.Parent()
+			ensureNodeExists(nodeMap, parent)
+			if hasChild(childrenMap[parent], nodeMap[fn]) {
+				continue
+			}
+			childrenMap[parent] = append(childrenMap[parent], node.NewEdge(nodeMap[fn]))
+		}
+	}
+}
+
+func hasChild(edges []*node.Edge, child node.TrackedEntity) bool {
+	for _, edge := range edges {
+		if edge.GetChild() == child {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"sort"
+	"strings"
+
+	"goAccessViz/cmd/goAccessViz/domain/node"
+	"goAccessViz/cmd/goAccessViz/repository/sqlparser"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// sqlColumnAccess はSQL文中の列参照とその列に対する操作
+// SELECT *のようにすべての列を指す場合、columnはnode.AllColumnsになる
+type sqlColumnAccess struct {
+	table  tableIdentifier
+	column string
+	mode   node.AccessMode
+}
+
+func extractColumnAccessesFromSQL(sql string, defaultSchema string) []sqlColumnAccess {
+	stmts, _ := sqlparser.Parse(sql)
+
+	var accesses []sqlColumnAccess
+	for _, ref := range sqlparser.Columns(stmts...) {
+		column := node.AllColumns
+		if !ref.Star {
+			column = ref.Column.Normalized()
+		}
+		// 実行時に組み立てられる列や条件(WHERE %sなど)は列として扱わない
+		if strings.Contains(column, sqlparser.Placeholder) {
+			continue
+		}
+		accesses = append(accesses, sqlColumnAccess{
+			table:  resolveTableIdentifier(ref.Table, defaultSchema),
+			column: column,
+			mode:   accessModeOfOperation[ref.Operation],
+		})
+	}
+	return accesses
+}
+
+// columnKey はテーブル(tableIdentifier.key)と列名の組
+type columnKey struct {
+	table  string
+	column string
+}
+
+// columnAccess は関数から列へのアクセスとその根拠となった箇所、行われる操作
+type columnAccess struct {
+	column    columnKey
+	positions []node.Position
+	modes     []node.AccessMode
+}
+
+// columnAccesses は関数ごとの列へのアクセスを集め、最後に列Nodeと辺を作る
+type columnAccesses struct {
+	byFunc map[*ssa.Function][]*columnAccess
+	funcs  []*ssa.Function
+}
+
+func newColumnAccesses() *columnAccesses {
+	return &columnAccesses{byFunc: make(map[*ssa.Function][]*columnAccess)}
+}
+
+func (c *columnAccesses) record(fn *ssa.Function, sqlAccess sqlColumnAccess, position node.Position) {
+	key := columnKey{table: sqlAccess.table.key(), column: sqlAccess.column}
+	if _, exists := c.byFunc[fn]; !exists {
+		c.funcs = append(c.funcs, fn)
+	}
+	var access *columnAccess
+	for _, existing := range c.byFunc[fn] {
+		if existing.column == key {
+			access = existing
+			break
+		}
+	}
+	if access == nil {
+		access = &columnAccess{column: key}
+		c.byFunc[fn] = append(c.byFunc[fn], access)
+	}
+	access.modes = append(access.modes, sqlAccess.mode)
+	if !containsPosition(access.positions, position) {
+		access.positions = append(access.positions, position)
+	}
+}
+
+// link は列Nodeを作って関数から辺を張り、テーブルNodeの子として列を並べる
+// テーブルNodeは関数の辺から参照されているため、同じポインタのまま作り直す
+func (c *columnAccesses) link(childrenMap map[*ssa.Function][]*node.Edge, dbTableMap map[string]*node.DatabaseTableTrackedEntity) {
+	columnNodes := make(map[columnKey]*node.ColumnTrackedEntity)
+	for _, fn := range c.funcs {
+		for _, access := range c.byFunc[fn] {
+			columnNode, exists := columnNodes[access.column]
+			if !exists {
+				columnNode = node.NewColumnTrackedEntity(access.column.column, dbTableMap[access.column.table])
+				columnNodes[access.column] = columnNode
+			}
+			edge := node.NewEdge(columnNode, node.WithEdgePositions(access.positions...), node.WithAccessModes(access.modes...))
+			childrenMap[fn] = append(childrenMap[fn], edge)
+		}
+	}
+
+	columnsByTable := make(map[string][]*node.ColumnTrackedEntity)
+	for key, columnNode := range columnNodes {
+		columnsByTable[key.table] = append(columnsByTable[key.table], columnNode)
+	}
+	for tableKey, columns := range columnsByTable {
+		sortColumns(columns)
+		dbTableMap[tableKey].SetColumns(columns)
+	}
+}
+
+// sortColumns はすべての列を表す"*"を先頭に、残りを列名順に並べる
+func sortColumns(columns []*node.ColumnTrackedEntity) {
+	sort.Slice(columns, func(i, j int) bool {
+		if columns[i].IsAllColumns() != columns[j].IsAllColumns() {
+			return columns[i].IsAllColumns()
+		}
+		return columns[i].GetColumnName() < columns[j].GetColumnName()
+	})
+}
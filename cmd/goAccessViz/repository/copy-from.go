@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"strings"
+
+	"goAccessViz/cmd/goAccessViz/repository/sqlparser"
+)
+
+// isCopyFrom はpgxのCopyFromかを判定する(SQLの代わりにpgx.Identifierでテーブルを受け取る)
+func isCopyFrom(fn *types.Func) bool {
+	return fn != nil && fn.Name() == "CopyFrom" && identifierParameterIndex(fn.Type().(*types.Signature)) >= 0
+}
+
+// copyFromSites はCopyFromで書き込むテーブルと列をINSERT文として表す
+// pgx.Identifierの要素はpgxがクォートするため、大文字小文字を保つクォートされた名前として扱う
+// 定数でない要素は実行時まで分からない部分になり、テーブルがリテラルで渡されていなければ何も返さない
+func copyFromSites(callExpr *ast.CallExpr, method *types.Func, info *types.Info) []sqlSite {
+	sig := method.Type().(*types.Signature)
+	index := identifierParameterIndex(sig)
+	if index >= len(callExpr.Args) {
+		return []sqlSite{}
+	}
+	parts, ok := constantStrings(callExpr.Args[index], info)
+	if !ok || len(parts) == 0 {
+		return []sqlSite{}
+	}
+	table := quoteIdentifiers(parts)
+
+	columns := "VALUES (NULL)"
+	if index+1 < len(callExpr.Args) {
+		if names, ok := constantStrings(callExpr.Args[index+1], info); ok && len(names) > 0 {
+			columns = "(" + strings.Join(quoteIdentifiers(names), ", ") + ") " + columns
+		}
+	}
+	query := "INSERT INTO " + strings.Join(table, ".") + " " + columns
+	return []sqlSite{{query: query, pos: callExpr.Pos(), litPos: callExpr.Args[index].Pos()}}
+}
+
+// identifierParameterIndex はシグネチャからpgx.Identifierの引数の位置を求める(見つからなければ-1)
+func identifierParameterIndex(sig *types.Signature) int {
+	params := sig.Params()
+	for i := 0; i < params.Len(); i++ {
+		named, ok := params.At(i).Type().(*types.Named)
+		if ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == pgxPackage && named.Obj().Name() == "Identifier" {
+			return i
+		}
+	}
+	return -1
+}
+
+// constantStrings は文字列の複合リテラル(pgx.Identifier{"schema", "table"}や[]string{"id"})の要素を返す
+// 定数でない要素はsqlparser.Placeholderになる
+func constantStrings(expr ast.Expr, info *types.Info) ([]string, bool) {
+	lit, ok := ast.Unparen(expr).(*ast.CompositeLit)
+	if !ok {
+		return nil, false
+	}
+	values := make([]string, 0, len(lit.Elts))
+	for _, elt := range lit.Elts {
+		value := sqlparser.Placeholder
+		if tv, ok := info.Types[elt]; ok && tv.Value != nil && tv.Value.Kind() == constant.String {
+			value = constant.StringVal(tv.Value)
+		}
+		values = append(values, value)
+	}
+	return values, true
+}
+
+func quoteIdentifiers(names []string) []string {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, `"`+strings.ReplaceAll(name, `"`, `""`)+`"`)
+	}
+	return quoted
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// databaseTypes はSQLを実行するメソッドを持つ型(パッケージパスごとの型名)
+var databaseTypes = map[string][]string{
+	"database/sql": {"DB", "Tx", "Conn"},
+	"github.com/jmoiron/sqlx": {
+		"DB", "Tx", "Conn",
+		"Ext", "ExtContext", "Queryer", "QueryerContext", "Execer", "ExecerContext", "Preparer", "PreparerContext",
+	},
+	// pgxのバッチはSendBatchで送られるが、SQLはQueueで渡される
+	pgxPackage:                        {"Conn", "Tx", "Batch"},
+	"github.com/jackc/pgx/v5/pgxpool": {"Pool", "Conn"},
+	// GORMのモデルを使う呼び出しはgormSitesで扱い、ここではSQLを直接受け取るRawとExecだけを対象にする
+	gormPackage: {"DB"},
+}
+
+// sqlxPackage はsqlxのパッケージパス(Getなどのパッケージ関数もSQLを受け取る)
+const sqlxPackage = "github.com/jmoiron/sqlx"
+
+// pgxPackage はpgxのパッケージパス
+const pgxPackage = "github.com/jackc/pgx/v5"
+
+// databaseMethods はSQLを受け取って実行(または準備)するメソッドと、sqlxの同名のパッケージ関数
+// SQLの位置はシグネチャから求めるため、Context付きのものも同じように扱える
+var databaseMethods = map[string]bool{
+	// database/sql
+	"Exec": true, "ExecContext": true,
+	"Query": true, "QueryContext": true,
+	"QueryRow": true, "QueryRowContext": true,
+	"Prepare": true, "PrepareContext": true,
+	// sqlx
+	"Get": true, "GetContext": true,
+	"Select": true, "SelectContext": true,
+	"Queryx": true, "QueryxContext": true,
+	"QueryRowx": true, "QueryRowxContext": true,
+	"MustExec": true, "MustExecContext": true,
+	"NamedExec": true, "NamedExecContext": true,
+	"NamedQuery": true, "NamedQueryContext": true,
+	"Preparex": true, "PreparexContext": true,
+	"PrepareNamed": true, "PrepareNamedContext": true,
+	// pgx
+	"Queue":    true,
+	"CopyFrom": true,
+	// GORM
+	"Raw": true,
+}
+
+// queryRewriters はSQLを書き換えて返すsqlxの関数とメソッド
+// 書き換えてもテーブルは変わらないため、元のSQLまで遡って評価する
+var queryRewriters = map[string]bool{
+	"In":        true,
+	"Rebind":    true,
+	"Named":     true,
+	"BindNamed": true,
+}
+
+// databaseMethod は呼び出しがデータベースの型のメソッドかsqlxのパッケージ関数であれば、その関数を型検査の結果から返す
+// 同じ名前のメソッド(cache.Getなど)や他のパッケージの関数(http.Getなど)は対象にしない
+func databaseMethod(info *types.Info, callExpr *ast.CallExpr) *types.Func {
+	selExpr, ok := callExpr.Fun.(*ast.SelectorExpr)
+	if !ok || info == nil || !databaseMethods[selExpr.Sel.Name] {
+		return nil
+	}
+	selection, ok := info.Selections[selExpr]
+	if !ok {
+		// sqlx.Get(db, &dest, query)のようなパッケージ関数
+		if fn, ok := info.Uses[selExpr.Sel].(*types.Func); ok && fn.Pkg() != nil && fn.Pkg().Path() == sqlxPackage {
+			return fn
+		}
+		return nil
+	}
+	if selection.Kind() != types.MethodVal {
+		return nil
+	}
+	method, ok := selection.Obj().(*types.Func)
+	if !ok {
+		return nil
+	}
+	// *sqlx.DBに埋め込まれた*sql.DBのメソッドのように、宣言された型かレシーバの型のどちらかで判定する
+	declared := method.Type().(*types.Signature).Recv()
+	if isDatabaseType(selection.Recv()) || (declared != nil && isDatabaseType(declared.Type())) {
+		return method
+	}
+	return nil
+}
+
+func isDatabaseType(t types.Type) bool {
+	if pointer, ok := t.(*types.Pointer); ok {
+		t = pointer.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+	for _, name := range databaseTypes[named.Obj().Pkg().Path()] {
+		if named.Obj().Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// sqlParameterIndex はシグネチャからSQLを受け取る引数の位置を求める
+// "query"や"sql"という名前の文字列の引数を優先し、なければ最初の文字列の引数とする(見つからなければ-1)
+// pgxのConn.Prepare(ctx, name, sql)のように、SQLより前に文字列の引数を取るものがある
+func sqlParameterIndex(sig *types.Signature) int {
+	first := -1
+	params := sig.Params()
+	for i := 0; i < params.Len(); i++ {
+		param := params.At(i)
+		basic, ok := param.Type().Underlying().(*types.Basic)
+		if !ok || basic.Kind() != types.String {
+			continue
+		}
+		if param.Name() == "query" || param.Name() == "sql" {
+			return i
+		}
+		if first < 0 {
+			first = i
+		}
+	}
+	return first
+}
+
+// isQueryRewriter はsqlx.InやDB.RebindのようにSQLを書き換える関数かを判定する
+func isQueryRewriter(fn *types.Func) bool {
+	return fn != nil && fn.Pkg() != nil && fn.Pkg().Path() == sqlxPackage && queryRewriters[fn.Name()]
+}
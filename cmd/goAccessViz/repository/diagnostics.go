@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+)
+
+// DiagnosticKind はパッケージの読み込みで発生したエラーの種類
+type DiagnosticKind string
+
+const (
+	DiagnosticLoad    DiagnosticKind = "load"
+	DiagnosticParse   DiagnosticKind = "parse"
+	DiagnosticType    DiagnosticKind = "type"
+	DiagnosticUnknown DiagnosticKind = "unknown"
+	// DiagnosticConfig はsqlcの設定ファイルなど、パッケージ以外に読み込むファイルのエラー
+	DiagnosticConfig DiagnosticKind = "config"
+)
+
+// Diagnostic はパッケージごとの読み込み・構文・型のエラー
+type Diagnostic struct {
+	Package  string
+	Kind     DiagnosticKind
+	Position string
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	if d.Position != "" {
+		return fmt.Sprintf("%s: %s error: %s: %s", d.Package, d.Kind, d.Position, d.Message)
+	}
+	return fmt.Sprintf("%s: %s error: %s", d.Package, d.Kind, d.Message)
+}
+
+// Diagnostics はReadGraphが解析中に見つけたエラーの一覧
+type Diagnostics struct {
+	Diagnostics []Diagnostic
+	// IncompletePackages は自身または依存先にエラーがあり、グラフが不完全な可能性のあるパッケージ
+	IncompletePackages []string
+}
+
+func (d *Diagnostics) HasErrors() bool {
+	return len(d.Diagnostics) > 0
+}
+
+func (d *Diagnostics) isIncomplete(pkgPath string) bool {
+	for _, incomplete := range d.IncompletePackages {
+		if incomplete == pkgPath {
+			return true
+		}
+	}
+	return false
+}
+
+// DiagnosticsError はstrictモードで診断結果をエラーとして返すためのもの
+type DiagnosticsError struct {
+	Diagnostics *Diagnostics
+}
+
+func (e *DiagnosticsError) Error() string {
+	messages := make([]string, 0, len(e.Diagnostics.Diagnostics))
+	for _, diagnostic := range e.Diagnostics.Diagnostics {
+		messages = append(messages, diagnostic.String())
+	}
+	return fmt.Sprintf("%d package error(s):\n%s", len(messages), strings.Join(messages, "\n"))
+}
+
+// collectDiagnostics は読み込んだパッケージとその依存先のエラーを集める
+func collectDiagnostics(patterns []string, pkgs []*packages.Package, ssaPkgs []*ssa.Package) *Diagnostics {
+	diagnostics := &Diagnostics{}
+	if len(pkgs) == 0 {
+		diagnostics.Diagnostics = append(diagnostics.Diagnostics, Diagnostic{
+			Package: strings.Join(patterns, " "),
+			Kind:    DiagnosticLoad,
+			Message: "patterns matched no packages",
+		})
+		return diagnostics
+	}
+
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, err := range pkg.Errors {
+			diagnostics.Diagnostics = append(diagnostics.Diagnostics, Diagnostic{
+				Package:  pkg.PkgPath,
+				Kind:     diagnosticKindOf(err.Kind),
+				Position: err.Pos,
+				Message:  err.Msg,
+			})
+		}
+	})
+
+	// ssautil.Packagesは型情報のないパッケージをnilとして黙って捨てる
+	for i, ssaPkg := range ssaPkgs {
+		if ssaPkg == nil && len(pkgs[i].Errors) == 0 {
+			diagnostics.Diagnostics = append(diagnostics.Diagnostics, Diagnostic{
+				Package: pkgs[i].PkgPath,
+				Kind:    DiagnosticType,
+				Message: "package has no type information and was not analyzed",
+			})
+		}
+	}
+
+	for _, pkg := range pkgs {
+		if hasErrorsInDependencies(pkg, make(map[*packages.Package]bool)) {
+			diagnostics.IncompletePackages = append(diagnostics.IncompletePackages, pkg.PkgPath)
+		}
+	}
+	return diagnostics
+}
+
+func hasErrorsInDependencies(pkg *packages.Package, visited map[*packages.Package]bool) bool {
+	if visited[pkg] {
+		return false
+	}
+	visited[pkg] = true
+	if len(pkg.Errors) > 0 || pkg.Types == nil || pkg.IllTyped {
+		return true
+	}
+	for _, imported := range pkg.Imports {
+		if hasErrorsInDependencies(imported, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+func diagnosticKindOf(kind packages.ErrorKind) DiagnosticKind {
+	switch kind {
+	case packages.ListError:
+		return DiagnosticLoad
+	case packages.ParseError:
+		return DiagnosticParse
+	case packages.TypeError:
+		return DiagnosticType
+	}
+	return DiagnosticUnknown
+}
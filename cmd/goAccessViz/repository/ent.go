@@ -0,0 +1,509 @@
+package repository
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"path"
+	"strings"
+
+	"goAccessViz/cmd/goAccessViz/domain/node"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const (
+	// entPackage はentのパッケージパス(スキーマの型はent.Schemaを埋め込む)
+	entPackage = "entgo.io/ent"
+	// entEdgePackage はスキーマのエッジを宣言するパッケージのパス
+	entEdgePackage = "entgo.io/ent/schema/edge"
+	// entSQLPackage はテーブル名を上書きするentsql.Annotationのパッケージのパス
+	entSQLPackage = "entgo.io/ent/dialect/entsql"
+)
+
+// entEntity はentのスキーマの型一つのテーブルと、メソッド名(Posts)で引くエッジ
+type entEntity struct {
+	table string
+	edges map[string]entEdge
+}
+
+// entEdge はエッジの行き先と、エッジを保存するテーブル
+// joinTableは多対多のエッジの中間テーブルで、foreignは外部キーが行き先のテーブルにあること(edge.Toの一対多と一対一)を表す
+type entEdge struct {
+	target    string
+	joinTable string
+	foreign   bool
+}
+
+// entEdgeDecl はスキーマのEdges()に書かれたedge.Toとedge.Fromの宣言
+type entEdgeDecl struct {
+	name    string
+	target  string
+	inverse bool
+	ref     string
+	unique  bool
+	table   string
+}
+
+// entCatalog は生成されたパッケージのパスごとの、スキーマの型名からテーブルとエッジへの対応
+// entは既定でスキーマのパッケージ(ent/schema)の親のディレクトリにコードを生成する
+type entCatalog struct {
+	entities map[string]map[string]*entEntity
+}
+
+// entReceiverSuffixes は生成される型の名前(UserQueryやUserUpdateOneなど)からスキーマの型名を取り出すための接尾辞
+// 長いものから順に試すため、UserGroupQueryはUserGroupになる
+var entReceiverSuffixes = []string{"CreateBulk", "UpdateOne", "DeleteOne", "GroupBy", "Client", "Create", "Update", "Delete", "Select", "Query", ""}
+
+// entClientModes は<Entity>Clientのメソッドで組み立てる操作
+var entClientModes = map[string]node.AccessMode{
+	"Create":        node.AccessInsert,
+	"CreateBulk":    node.AccessInsert,
+	"MapCreateBulk": node.AccessInsert,
+	"Update":        node.AccessUpdate,
+	"UpdateOne":     node.AccessUpdate,
+	"UpdateOneID":   node.AccessUpdate,
+	"Delete":        node.AccessDelete,
+	"DeleteOne":     node.AccessDelete,
+	"DeleteOneID":   node.AccessDelete,
+	"Get":           node.AccessSelect,
+	"GetX":          node.AccessSelect,
+}
+
+// entQueryFinishers は<Entity>Queryを実行するメソッド
+var entQueryFinishers = map[string]bool{
+	"All": true, "AllX": true,
+	"Only": true, "OnlyX": true, "OnlyID": true, "OnlyIDX": true,
+	"First": true, "FirstX": true, "FirstID": true, "FirstIDX": true,
+	"IDs": true, "IDsX": true,
+	"Count": true, "CountX": true,
+	"Exist": true, "ExistX": true,
+}
+
+// entSelectFinishers は<Entity>Selectと<Entity>GroupByを実行するメソッド
+var entSelectFinishers = map[string]bool{
+	"Scan": true, "ScanX": true,
+	"Strings": true, "StringsX": true, "String": true, "StringX": true,
+	"Ints": true, "IntsX": true, "Int": true, "IntX": true,
+	"Float64s": true, "Float64sX": true, "Float64": true, "Float64X": true,
+	"Bools": true, "BoolsX": true, "Bool": true, "BoolX": true,
+}
+
+// loadEntCatalog は解析するパッケージからentのスキーマ(ent.Schemaを埋め込む型)を探し、テーブルとエッジを読み取る
+func loadEntCatalog(pkgs []*packages.Package) *entCatalog {
+	catalog := &entCatalog{entities: make(map[string]map[string]*entEntity)}
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if pkg.Types == nil || pkg.TypesInfo == nil || len(pkg.Syntax) == 0 {
+			return
+		}
+		decls := make(map[string][]entEdgeDecl)
+		tables := make(map[string]string)
+		for _, name := range pkg.Types.Scope().Names() {
+			if obj, ok := pkg.Types.Scope().Lookup(name).(*types.TypeName); ok && isEntSchema(obj.Type()) {
+				decls[name] = nil
+				tables[name] = entDefaultTableName(name)
+			}
+		}
+		if len(decls) == 0 {
+			return
+		}
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				funcDecl, ok := decl.(*ast.FuncDecl)
+				if !ok || funcDecl.Recv == nil || funcDecl.Body == nil {
+					continue
+				}
+				schema := receiverTypeName(funcDecl.Recv.List[0].Type)
+				if _, ok := decls[schema]; !ok {
+					continue
+				}
+				switch funcDecl.Name.Name {
+				case "Annotations":
+					if table := entAnnotationTable(funcDecl.Body, pkg.TypesInfo); table != "" {
+						tables[schema] = table
+					}
+				case "Edges":
+					decls[schema] = append(decls[schema], entEdgeDecls(funcDecl.Body, pkg.TypesInfo)...)
+				}
+			}
+		}
+		catalog.entities[path.Dir(pkg.PkgPath)] = resolveEntEdges(tables, decls)
+	})
+	return catalog
+}
+
+// isEntSchema は型がent.Schemaを埋め込む構造体かを判定する
+func isEntSchema(t types.Type) bool {
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return false
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		if named, ok := field.Type().(*types.Named); ok && field.Embedded() && named.Obj().Pkg() != nil &&
+			named.Obj().Pkg().Path() == entPackage && named.Obj().Name() == "Schema" {
+			return true
+		}
+	}
+	return false
+}
+
+func receiverTypeName(expr ast.Expr) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// entDefaultTableName はentと同じく、型名を複数形のsnake_caseにする(UserGroup → user_groups)
+func entDefaultTableName(typeName string) string {
+	return pluralize(snakeCase(typeName))
+}
+
+// entAnnotationTable はAnnotations()の中のentsql.Annotation{Table: "..."}からテーブル名を読む
+func entAnnotationTable(body *ast.BlockStmt, info *types.Info) string {
+	var table string
+	ast.Inspect(body, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok || !isNamedType(info.TypeOf(lit), entSQLPackage, "Annotation") {
+			return true
+		}
+		for _, elt := range lit.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			if key, ok := kv.Key.(*ast.Ident); ok && key.Name == "Table" {
+				if value, ok := constantString(kv.Value, info); ok {
+					table = value
+				}
+			}
+		}
+		return true
+	})
+	return table
+}
+
+// entEdgeDecls はEdges()の中のedge.To(...)とedge.From(...)のメソッドチェーンを読む
+// edge.To("following", User.Type).From("followers")は、同じスキーマに逆向きのエッジも宣言する
+func entEdgeDecls(body *ast.BlockStmt, info *types.Info) []entEdgeDecl {
+	var decls []entEdgeDecl
+	ast.Inspect(body, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+		for _, elt := range lit.Elts {
+			decls = append(decls, entEdgeChain(elt, info)...)
+		}
+		return false
+	})
+	return decls
+}
+
+func entEdgeChain(expr ast.Expr, info *types.Info) []entEdgeDecl {
+	// Unwind the chain so that the modifiers apply from the innermost call outwards
+	var calls []*ast.CallExpr
+	for {
+		call, ok := ast.Unparen(expr).(*ast.CallExpr)
+		if !ok {
+			break
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			break
+		}
+		calls = append(calls, call)
+		expr = sel.X
+	}
+	if len(calls) == 0 {
+		return nil
+	}
+
+	root := calls[len(calls)-1]
+	rootSel := root.Fun.(*ast.SelectorExpr)
+	if !isPackageSelector(rootSel, entEdgePackage, info) || len(root.Args) != 2 {
+		return nil
+	}
+	name, ok := constantString(root.Args[0], info)
+	if !ok {
+		return nil
+	}
+	decls := []entEdgeDecl{{name: name, target: entEdgeTarget(root.Args[1]), inverse: rootSel.Sel.Name == "From"}}
+	current := &decls[0]
+	for i := len(calls) - 2; i >= 0; i-- {
+		call := calls[i]
+		switch call.Fun.(*ast.SelectorExpr).Sel.Name {
+		case "Unique":
+			current.unique = true
+		case "Ref":
+			if len(call.Args) == 1 {
+				current.ref, _ = constantString(call.Args[0], info)
+			}
+		case "StorageKey":
+			for _, arg := range call.Args {
+				if option, ok := arg.(*ast.CallExpr); ok && len(option.Args) == 1 {
+					if sel, ok := option.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Table" && isPackageSelector(sel, entEdgePackage, info) {
+						current.table, _ = constantString(option.Args[0], info)
+					}
+				}
+			}
+		case "From":
+			if len(call.Args) == 1 && !current.inverse {
+				inverseName, _ := constantString(call.Args[0], info)
+				decls = append(decls, entEdgeDecl{name: inverseName, target: decls[0].target, inverse: true, ref: decls[0].name})
+				current = &decls[len(decls)-1]
+			}
+		}
+	}
+	return decls
+}
+
+// entEdgeTarget はUser.Typeのような行き先のスキーマの型名を返す
+func entEdgeTarget(expr ast.Expr) string {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Type" {
+		return ""
+	}
+	switch x := sel.X.(type) {
+	case *ast.Ident:
+		return x.Name
+	case *ast.SelectorExpr:
+		return x.Sel.Name
+	}
+	return ""
+}
+
+// resolveEntEdges はエッジの宣言とその逆向きの宣言から、エッジごとの保存先を決める
+// edge.Toは、それ自身もedge.Fromの逆向きもUniqueでなければ多対多で、中間テーブルは<スキーマ>_<エッジ>になる
+// 逆向きの宣言がない場合は、自分自身へのエッジだけが多対多になる
+func resolveEntEdges(tables map[string]string, decls map[string][]entEdgeDecl) map[string]*entEntity {
+	toEdge := func(schema, name string) (entEdgeDecl, bool) {
+		for _, decl := range decls[schema] {
+			if !decl.inverse && decl.name == name {
+				return decl, true
+			}
+		}
+		return entEdgeDecl{}, false
+	}
+	joinTable := func(schema string, decl entEdgeDecl) string {
+		if decl.unique {
+			return ""
+		}
+		manyToMany := decl.target == schema
+		for _, inverse := range decls[decl.target] {
+			if inverse.inverse && inverse.ref == decl.name && inverse.target == schema {
+				manyToMany = !inverse.unique
+			}
+		}
+		if !manyToMany {
+			return ""
+		}
+		if decl.table != "" {
+			return decl.table
+		}
+		return snakeCase(schema) + "_" + decl.name
+	}
+
+	entities := make(map[string]*entEntity)
+	for schema, table := range tables {
+		entity := &entEntity{table: table, edges: make(map[string]entEdge)}
+		for _, decl := range decls[schema] {
+			edge := entEdge{target: decl.target}
+			if decl.inverse {
+				if assoc, ok := toEdge(decl.target, decl.ref); ok {
+					edge.joinTable = joinTable(decl.target, assoc)
+				}
+			} else {
+				edge.joinTable = joinTable(schema, decl)
+				edge.foreign = edge.joinTable == ""
+			}
+			entity.edges[entPascal(decl.name)] = edge
+		}
+		entities[schema] = entity
+	}
+	return entities
+}
+
+// entPascal はentがメソッド名に使う形(owner_pets → OwnerPets、user_id → UserID)にする
+func entPascal(name string) string {
+	var b strings.Builder
+	for _, word := range strings.Split(name, "_") {
+		if word == "" {
+			continue
+		}
+		if upper := strings.ToUpper(word); entAcronyms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+var entAcronyms = map[string]bool{"ID": true, "URL": true, "API": true, "HTTP": true, "JSON": true, "SQL": true, "UUID": true, "IP": true}
+
+// call は呼び出しがentの生成したメソッドであれば、そのメソッドでアクセスするテーブルのSQLを返す
+func (c *entCatalog) call(info *types.Info, callExpr *ast.CallExpr) ([]sqlSite, bool) {
+	selExpr, ok := callExpr.Fun.(*ast.SelectorExpr)
+	if c == nil || len(c.entities) == 0 || info == nil || !ok {
+		return nil, false
+	}
+	selection, ok := info.Selections[selExpr]
+	if !ok || selection.Kind() != types.MethodVal {
+		return nil, false
+	}
+	named, ok := derefType(selection.Recv()).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return nil, false
+	}
+	entities, ok := c.entities[named.Obj().Pkg().Path()]
+	if !ok {
+		return nil, false
+	}
+	schema, kind := entReceiver(entities, named.Obj().Name())
+	if schema == "" {
+		return nil, false
+	}
+
+	statements := entStatements(entities, schema, kind, selExpr.Sel.Name)
+	if len(statements) == 0 {
+		return nil, false
+	}
+	return []sqlSite{{query: strings.Join(statements, "; "), pos: callExpr.Pos(), litPos: callExpr.Pos()}}, true
+}
+
+// entReceiver は生成された型の名前を、スキーマの型名と種類(Query、UpdateOneなど。エンティティ自身は空)に分ける
+func entReceiver(entities map[string]*entEntity, name string) (string, string) {
+	for _, suffix := range entReceiverSuffixes {
+		schema, ok := strings.CutSuffix(name, suffix)
+		if _, exists := entities[schema]; ok && exists {
+			return schema, suffix
+		}
+	}
+	return "", ""
+}
+
+// entStatements はメソッドでアクセスするテーブルごとのSQLを組み立てる
+// エッジを辿る(Query<Edge>、With<Edge>)と多対多の中間テーブルを読み、エッジを変更する(Add、Remove、Clear)と中間テーブルか外部キーを持つテーブルに書き込む
+// 辿った先のテーブルは、その<Entity>Queryを実行したところで読む
+func entStatements(entities map[string]*entEntity, schema, kind, method string) []string {
+	entity := entities[schema]
+	table := func(schema string) string {
+		if entity, ok := entities[schema]; ok {
+			return quoteIdentifiers([]string{entity.table})[0]
+		}
+		return ""
+	}
+	statement := func(mode node.AccessMode, name string) string {
+		return tableStatement(mode, quoteIdentifiers([]string{name})[0])
+	}
+
+	switch kind {
+	case "Client":
+		if mode, ok := entClientModes[method]; ok {
+			return []string{tableStatement(mode, table(schema))}
+		}
+	case "Query":
+		if entQueryFinishers[method] {
+			return []string{tableStatement(node.AccessSelect, table(schema))}
+		}
+	case "Select", "GroupBy":
+		if entSelectFinishers[method] {
+			return []string{tableStatement(node.AccessSelect, table(schema))}
+		}
+	case "":
+		// u.Update()は<Entity>Client.UpdateOne(u)と同じ
+		if method == "Update" {
+			return []string{tableStatement(node.AccessUpdate, table(schema))}
+		}
+	}
+
+	var statements []string
+	switch {
+	case strings.HasPrefix(method, "Query") && (kind == "Client" || kind == "Query" || kind == ""):
+		edge, ok := entity.edges[strings.TrimPrefix(method, "Query")]
+		if !ok {
+			return nil
+		}
+		if kind == "Query" {
+			// The edges of the selected rows are read in a subquery
+			statements = append(statements, tableStatement(node.AccessSelect, table(schema)))
+		}
+		if edge.joinTable != "" {
+			statements = append(statements, statement(node.AccessSelect, edge.joinTable))
+		}
+	case strings.HasPrefix(method, "With") && kind == "Query":
+		edge, ok := entity.edges[strings.TrimPrefix(method, "With")]
+		if !ok {
+			return nil
+		}
+		// Eager loading runs a query for the neighbors after the main query
+		if edge.joinTable != "" {
+			statements = append(statements, statement(node.AccessSelect, edge.joinTable))
+		}
+		statements = append(statements, tableStatement(node.AccessSelect, table(edge.target)))
+	case kind == "Create" || kind == "CreateBulk" || kind == "Update" || kind == "UpdateOne":
+		edge, mode, ok := entEdgeMutation(entity, method)
+		if !ok {
+			return nil
+		}
+		if edge.joinTable != "" {
+			statements = append(statements, statement(mode, edge.joinTable))
+		} else if edge.foreign {
+			// The foreign key of the neighbors is set or cleared
+			statements = append(statements, tableStatement(node.AccessUpdate, table(edge.target)))
+		}
+	}
+	return statements
+}
+
+// entEdgeMutation はAdd<Edge>IDs、Remove<Edge>、Clear<Edge>のようなエッジを変更するメソッドから、エッジと中間テーブルへの操作を求める
+// Add、Removeの後のエッジ名は単数形(edge "groups"ならAddGroupIDs)になる
+func entEdgeMutation(entity *entEntity, method string) (entEdge, node.AccessMode, bool) {
+	prefixes := []struct {
+		prefix string
+		mode   node.AccessMode
+	}{{"Add", node.AccessInsert}, {"Remove", node.AccessDelete}, {"Clear", node.AccessDelete}}
+	for _, p := range prefixes {
+		name, ok := strings.CutPrefix(method, p.prefix)
+		if !ok {
+			continue
+		}
+		if p.prefix != "Clear" {
+			name = strings.TrimSuffix(name, "IDs")
+		}
+		for edgeName, edge := range entity.edges {
+			if edgeName == name || (p.prefix != "Clear" && pluralize(name) == edgeName) {
+				return edge, p.mode, true
+			}
+		}
+	}
+	return entEdge{}, "", false
+}
+
+// isPackageSelector はedge.Toのような、パッケージを修飾した参照かを判定する
+func isPackageSelector(sel *ast.SelectorExpr, pkgPath string, info *types.Info) bool {
+	ident, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	pkgName, ok := info.Uses[ident].(*types.PkgName)
+	return ok && pkgName.Imported().Path() == pkgPath
+}
+
+func isNamedType(t types.Type, pkgPath, name string) bool {
+	named, ok := derefType(t).(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == pkgPath && named.Obj().Name() == name
+}
+
+func constantString(expr ast.Expr, info *types.Info) (string, bool) {
+	tv, ok := info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
@@ -0,0 +1,323 @@
+package repository
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+	"unicode"
+
+	"goAccessViz/cmd/goAccessViz/domain/node"
+	"goAccessViz/cmd/goAccessViz/repository/sqlparser"
+
+	"github.com/jinzhu/inflection"
+	"golang.org/x/tools/go/ssa"
+)
+
+// gormPackage はGORMのパッケージパス
+const gormPackage = "gorm.io/gorm"
+
+// gormFinisher はGORMのメソッドチェーンを実行するメソッドで発行されるSQLの操作
+// modelは最初の引数がモデル(Find(&users)やCreate(&order)など)であることを表す
+type gormFinisher struct {
+	modes []node.AccessMode
+	model bool
+}
+
+var gormFinishers = map[string]gormFinisher{
+	"First":           {modes: []node.AccessMode{node.AccessSelect}, model: true},
+	"Take":            {modes: []node.AccessMode{node.AccessSelect}, model: true},
+	"Last":            {modes: []node.AccessMode{node.AccessSelect}, model: true},
+	"Find":            {modes: []node.AccessMode{node.AccessSelect}, model: true},
+	"FindInBatches":   {modes: []node.AccessMode{node.AccessSelect}, model: true},
+	"FirstOrInit":     {modes: []node.AccessMode{node.AccessSelect}, model: true},
+	"Scan":            {modes: []node.AccessMode{node.AccessSelect}},
+	"Pluck":           {modes: []node.AccessMode{node.AccessSelect}},
+	"Count":           {modes: []node.AccessMode{node.AccessSelect}},
+	"Row":             {modes: []node.AccessMode{node.AccessSelect}},
+	"Rows":            {modes: []node.AccessMode{node.AccessSelect}},
+	"Create":          {modes: []node.AccessMode{node.AccessInsert}, model: true},
+	"CreateInBatches": {modes: []node.AccessMode{node.AccessInsert}, model: true},
+	"FirstOrCreate":   {modes: []node.AccessMode{node.AccessSelect, node.AccessInsert}, model: true},
+	"Save":            {modes: []node.AccessMode{node.AccessUpsert}, model: true},
+	"Update":          {modes: []node.AccessMode{node.AccessUpdate}},
+	"UpdateColumn":    {modes: []node.AccessMode{node.AccessUpdate}},
+	"Updates":         {modes: []node.AccessMode{node.AccessUpdate}, model: true},
+	"UpdateColumns":   {modes: []node.AccessMode{node.AccessUpdate}, model: true},
+	"Delete":          {modes: []node.AccessMode{node.AccessDelete}, model: true},
+}
+
+// gormFinisherMethod は呼び出しが*gorm.DBのチェーンを実行するメソッドであれば、そのメソッドを返す
+func gormFinisherMethod(info *types.Info, callExpr *ast.CallExpr) *types.Func {
+	selExpr, ok := callExpr.Fun.(*ast.SelectorExpr)
+	if !ok || info == nil {
+		return nil
+	}
+	if _, ok := gormFinishers[selExpr.Sel.Name]; !ok {
+		return nil
+	}
+	selection, ok := info.Selections[selExpr]
+	if !ok || selection.Kind() != types.MethodVal || !isGormDB(selection.Recv()) {
+		return nil
+	}
+	method, _ := selection.Obj().(*types.Func)
+	return method
+}
+
+// gormSites はGORMのチェーンが操作するテーブルを、Table()、Model()、実行メソッドのモデルの順に求めてSQLとして表す
+// Raw()で始まるチェーンはRaw()に渡されたSQLとして検出されるため、ここでは扱わない
+func gormSites(callExpr *ast.CallExpr, method *types.Func, sqlArgs *sqlArguments) []sqlSite {
+	instr, ok := sqlArgs.calls[callExpr.Lparen]
+	if !ok {
+		return []sqlSite{}
+	}
+	common := instr.Common()
+	if common.IsInvoke() || len(common.Args) == 0 {
+		return []sqlSite{}
+	}
+	finisher := gormFinishers[method.Name()]
+
+	chain := &gormChain{evaluator: sqlArgs.evaluator, visited: make(map[ssa.Value]bool)}
+	chain.walk(common.Args[0])
+	if chain.raw {
+		return []sqlSite{}
+	}
+	if chain.model == nil && finisher.model && len(common.Args) > 1 {
+		chain.model = modelType(common.Args[1])
+	}
+
+	table := chain.table
+	if table == "" && chain.model != nil {
+		table = gormModelTable(chain.model, instr.Parent().Prog, sqlArgs.evaluator)
+	}
+	if table == "" {
+		return []sqlSite{}
+	}
+
+	statements := make([]string, 0, len(finisher.modes))
+	for _, mode := range finisher.modes {
+		statements = append(statements, tableStatement(mode, table))
+	}
+	return []sqlSite{{query: strings.Join(statements, "; "), pos: callExpr.Pos(), litPos: callExpr.Pos()}}
+}
+
+// gormChain は実行メソッドのレシーバから*gorm.DBのメソッドチェーンを遡り、テーブルとモデルを集める
+// 後から呼ばれたTable()やModel()が優先されるため、遡って最初に見つけたものを使う
+type gormChain struct {
+	evaluator *sqlEvaluator
+	visited   map[ssa.Value]bool
+	table     string
+	model     types.Type
+	raw       bool
+}
+
+func (c *gormChain) walk(v ssa.Value) {
+	if v == nil || c.visited[v] {
+		return
+	}
+	c.visited[v] = true
+
+	switch v := v.(type) {
+	case *ssa.Phi:
+		// 条件付きで付け足したWhere()などの、どちらの経路から来ても同じテーブルとみなす
+		for _, edge := range v.Edges {
+			c.walk(edge)
+		}
+	case *ssa.Call:
+		callee := v.Call.StaticCallee()
+		if callee == nil || callee.Signature.Recv() == nil || !isGormDB(callee.Signature.Recv().Type()) || len(v.Call.Args) == 0 {
+			return
+		}
+		switch callee.Name() {
+		case "Table":
+			if c.table == "" && len(v.Call.Args) > 1 {
+				c.table = gormTableExpression(c.evaluator.partialValue(v.Call.Args[1], 0))
+			}
+		case "Model":
+			if c.model == nil && len(v.Call.Args) > 1 {
+				c.model = modelType(v.Call.Args[1])
+			}
+		case "Raw":
+			c.raw = true
+		}
+		c.walk(v.Call.Args[0])
+	}
+}
+
+// gormTableExpression はTable()の引数をSQLのテーブル式にする
+// GORMと同じく、空白を含まない名前だけをクォートし、"users u"のような式はそのまま使う
+func gormTableExpression(name string) string {
+	if strings.ContainsAny(name, " `\"") {
+		return name
+	}
+	return strings.Join(quoteIdentifiers(strings.Split(name, ".")), ".")
+}
+
+// modelType はinterface{}の引数に渡された値の型を返す
+func modelType(v ssa.Value) types.Type {
+	if mi, ok := v.(*ssa.MakeInterface); ok {
+		return mi.X.Type()
+	}
+	return nil
+}
+
+// gormModelTable はモデルの型からテーブル名を求める
+// TableName()メソッドがあればその戻り値を、なければGORMの既定の命名規則(snake_caseの複数形)を使う
+func gormModelTable(t types.Type, prog *ssa.Program, evaluator *sqlEvaluator) string {
+	named := modelStruct(t)
+	if named == nil {
+		return ""
+	}
+	name := gormDefaultTableName(named.Obj().Name())
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(named), true, named.Obj().Pkg(), "TableName")
+	if fn, ok := obj.(*types.Func); ok && isStringGetter(fn) {
+		name = tableNameValue(prog.FuncValue(fn), evaluator)
+	}
+	return strings.Join(quoteIdentifiers(strings.Split(name, ".")), ".")
+}
+
+// modelStruct は*User、[]User、*[]*Userなどからモデルの構造体の型を取り出す
+func modelStruct(t types.Type) *types.Named {
+	for {
+		switch u := types.Unalias(t).(type) {
+		case *types.Pointer:
+			t = u.Elem()
+		case *types.Slice:
+			t = u.Elem()
+		case *types.Array:
+			t = u.Elem()
+		case *types.Named:
+			if _, ok := u.Underlying().(*types.Struct); ok {
+				return u
+			}
+			return nil
+		default:
+			return nil
+		}
+	}
+}
+
+func isStringGetter(fn *types.Func) bool {
+	sig := fn.Type().(*types.Signature)
+	if sig.Params().Len() != 0 || sig.Results().Len() != 1 {
+		return false
+	}
+	basic, ok := sig.Results().At(0).Type().Underlying().(*types.Basic)
+	return ok && basic.Kind() == types.String
+}
+
+// tableNameValue はTableName()が返す文字列を評価する
+// 返す値が一つに決まらなければ、実行時まで分からない名前とする
+func tableNameValue(fn *ssa.Function, evaluator *sqlEvaluator) string {
+	value := ""
+	if fn != nil {
+		for _, block := range fn.Blocks {
+			ret, ok := block.Instrs[len(block.Instrs)-1].(*ssa.Return)
+			if !ok || len(ret.Results) != 1 {
+				continue
+			}
+			result := evaluator.partialValue(ret.Results[0], 0)
+			if value != "" && result != value {
+				return sqlparser.Placeholder
+			}
+			value = result
+		}
+	}
+	if value == "" {
+		return sqlparser.Placeholder
+	}
+	return value
+}
+
+func isGormDB(t types.Type) bool {
+	pointer, ok := t.(*types.Pointer)
+	if !ok {
+		return false
+	}
+	named, ok := pointer.Elem().(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == gormPackage && named.Obj().Name() == "DB"
+}
+
+// gormDefaultTableName はGORMの既定の命名規則で型名からテーブル名を求める
+// 複数形はGORMのNamingStrategyと同じくjinzhu/inflectionの規則で作る
+func gormDefaultTableName(typeName string) string {
+	return inflection.Plural(snakeCase(typeName))
+}
+
+// snakeCase はGORMと同じく、HTTPRequestやUserIDのような略語を一語として区切る
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				prev := runes[i-1]
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+					b.WriteByte('_')
+				}
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// uncountableWords は複数形にしない語
+var uncountableWords = []string{
+	"equipment", "information", "rice", "money", "species", "series", "fish", "sheep", "jeans", "police", "news", "metadata",
+}
+
+// irregularPlurals は規則に従わない複数形
+var irregularPlurals = map[string]string{
+	"person": "people",
+	"man":    "men",
+	"woman":  "women",
+	"child":  "children",
+	"tooth":  "teeth",
+	"foot":   "feet",
+	"mouse":  "mice",
+}
+
+// pluralize は英単語(snake_caseでは最後の語)を複数形にする
+// GORMが使うjinzhu/inflectionの規則のうち、テーブル名でよく使われるものに従う
+func pluralize(word string) string {
+	lower := strings.ToLower(word)
+	for _, uncountable := range uncountableWords {
+		if lower == uncountable || strings.HasSuffix(lower, "_"+uncountable) {
+			return word
+		}
+	}
+	for singular, plural := range irregularPlurals {
+		if lower == singular || strings.HasSuffix(lower, "_"+singular) {
+			return word[:len(word)-len(singular)] + plural
+		}
+	}
+	switch {
+	case strings.HasSuffix(lower, "quiz"):
+		return word + "zes"
+	case hasAnySuffix(lower, "matrix", "vertex", "index"):
+		return word[:len(word)-2] + "ices"
+	case strings.HasSuffix(lower, "sis"):
+		return word[:len(word)-2] + "es"
+	case hasAnySuffix(lower, "s", "x", "z", "ch", "sh"):
+		return word + "es"
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !strings.ContainsRune("aeiou", rune(lower[len(lower)-2])):
+		return word[:len(word)-1] + "ies"
+	case strings.HasSuffix(lower, "fe") && !strings.HasSuffix(lower, "ffe"):
+		return word[:len(word)-2] + "ves"
+	case hasAnySuffix(lower, "lf", "rf"):
+		return word[:len(word)-1] + "ves"
+	}
+	return word + "s"
+}
+
+func hasAnySuffix(s string, suffixes ...string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
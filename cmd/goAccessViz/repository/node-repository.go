@@ -2,39 +2,184 @@ package repository
 
 import (
 	"go/ast"
+	"go/token"
+	"go/types"
 	"goAccessViz/cmd/goAccessViz/domain/node"
+	"goAccessViz/cmd/goAccessViz/repository/sqlparser"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
 
 	"golang.org/x/tools/go/callgraph"
-	"golang.org/x/tools/go/callgraph/cha"
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/go/ssa"
 	"golang.org/x/tools/go/ssa/ssautil"
 )
 
-func ReadGraph(packagePath string) ([]node.TrackedEntity, error) {
-	prog, pkgs, err := buildSSAProgramWithPackages(packagePath)
+type readGraphConfig struct {
+	algorithm    CallGraphAlgorithm
+	entryPoints  []string
+	foldClosures bool
+	include      []string
+	exclude      []string
+	boundary     BoundaryMode
+	strict       bool
+	diagnostics  *Diagnostics
+	accessModes  []node.AccessMode
+	schema       string
+	columns      bool
+	dir          string
+}
+
+// Option はReadGraphの挙動を変更する
+type Option func(*readGraphConfig)
+
+// WithCallGraphAlgorithm はコールグラフの構築に使うアルゴリズムを指定する(デフォルトはCHA)
+func WithCallGraphAlgorithm(algorithm CallGraphAlgorithm) Option {
+	return func(config *readGraphConfig) {
+		config.algorithm = algorithm
+	}
+}
+
+// WithEntryPoints はRTAの起点とする関数を指定する(例: "goAccessViz/testpkg.FunctionD")
+func WithEntryPoints(entryPoints ...string) Option {
+	return func(config *readGraphConfig) {
+		config.entryPoints = append(config.entryPoints, entryPoints...)
+	}
+}
+
+// WithFoldClosures は無名関数を外側の関数にまとめ、SQLや呼び出しを外側の関数のものとして扱う
+func WithFoldClosures(foldClosures bool) Option {
+	return func(config *readGraphConfig) {
+		config.foldClosures = foldClosures
+	}
+}
+
+// WithIncludePackages は解析対象とするパッケージのパターンを指定する(デフォルトはメインモジュール)
+// パターンはpath.Matchのグロブ、または"example.com/pkg/..."の形式で指定する
+func WithIncludePackages(patterns ...string) Option {
+	return func(config *readGraphConfig) {
+		config.include = append(config.include, patterns...)
+	}
+}
+
+// WithExcludePackages は解析対象から外すパッケージのパターンを指定する
+func WithExcludePackages(patterns ...string) Option {
+	return func(config *readGraphConfig) {
+		config.exclude = append(config.exclude, patterns...)
+	}
+}
+
+// WithBoundaryMode は解析対象外のパッケージへの呼び出しの扱いを指定する(デフォルトはBoundaryDrop)
+func WithBoundaryMode(boundary BoundaryMode) Option {
+	return func(config *readGraphConfig) {
+		config.boundary = boundary
+	}
+}
+
+// WithStrict はパッケージの読み込み・構文・型のエラーがあればグラフを作らずにエラーを返す
+func WithStrict(strict bool) Option {
+	return func(config *readGraphConfig) {
+		config.strict = strict
+	}
+}
+
+// WithDiagnostics は解析中に見つけたエラーの一覧をreportに書き込む
+// strictでない場合もエラーのあるパッケージの関数は不完全なNodeとして印が付く
+func WithDiagnostics(report *Diagnostics) Option {
+	return func(config *readGraphConfig) {
+		config.diagnostics = report
+	}
+}
+
+// WithAccessModes はテーブルへの辺のうち指定した操作のものだけを残す(デフォルトはすべて)
+func WithAccessModes(modes ...node.AccessMode) Option {
+	return func(config *readGraphConfig) {
+		config.accessModes = append(config.accessModes, modes...)
+	}
+}
+
+// WithDefaultSchema はスキーマで修飾されていないテーブルが属するスキーマを指定する(例: PostgreSQLの"public")
+// 指定しない場合、修飾されていないテーブルはスキーマなしのNodeになる
+func WithDefaultSchema(schema string) Option {
+	return func(config *readGraphConfig) {
+		config.schema = schema
+	}
+}
+
+// WithColumns は列ごとのアクセスを追跡し、関数から列Nodeへの辺とテーブルNodeの子としての列Nodeを作る
+func WithColumns(columns bool) Option {
+	return func(config *readGraphConfig) {
+		config.columns = columns
+	}
+}
+
+// WithDir はパッケージパターンを解決するディレクトリを指定する(デフォルトはカレントディレクトリ)
+// カレントディレクトリを変えずに別のモジュールを解析できる
+func WithDir(dir string) Option {
+	return func(config *readGraphConfig) {
+		config.dir = dir
+	}
+}
+
+func newReadGraphConfig(opts []Option) *readGraphConfig {
+	config := &readGraphConfig{
+		algorithm: CHA,
+		boundary:  BoundaryDrop,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return config
+}
+
+// ReadGraph は複数のパッケージパターン(./...を含む)を一つのSSAプログラムとして解析し、統合したグラフを返す
+func ReadGraph(patterns []string, opts ...Option) ([]node.TrackedEntity, error) {
+	config := newReadGraphConfig(opts)
+
+	prog, pkgs, diagnostics, err := buildSSAProgramWithPackages(patterns, config.dir)
 	if err != nil {
 		return nil, err
 	}
+	// Queries of sqlc projects live in .sql files next to the Go code
+	sqlcCatalog, sqlcDiagnostics := loadSQLCCatalog(prog.Fset, pkgs)
+	diagnostics.Diagnostics = append(diagnostics.Diagnostics, sqlcDiagnostics...)
+	// ent maps its generated builders to the tables declared in ent/schema
+	entCatalog := loadEntCatalog(pkgs)
+	if config.diagnostics != nil {
+		*config.diagnostics = *diagnostics
+	}
+	if config.strict && diagnostics.HasErrors() {
+		return nil, &DiagnosticsError{Diagnostics: diagnostics}
+	}
 
 	// Build function call graph
-	cg := cha.CallGraph(prog)
-	nodeMap, childrenMap := buildNodeMaps(cg)
+	cg, err := buildCallGraph(prog, pkgs, config)
+	if err != nil {
+		return nil, err
+	}
+	// Filter packages before building nodes so out-of-scope functions never enter the maps
+	scope := newPackageScope(pkgs, config)
+	nodeMap, childrenMap := buildNodeMaps(cg, config, scope)
 
 	// Add all functions from the package, not just those in call graph
-	addAllPackageFunctions(prog, pkgs, nodeMap, childrenMap)
+	addAllPackageFunctions(prog, pkgs, nodeMap, childrenMap, scope)
 
 	// Analyze SQL strings and create DB table nodes
 	sqlStrings := analyzePackageForSQL(pkgs)
-	dbTableMap := createDBTableNodesMap(sqlStrings)
+	dbTableMap := createDBTableNodesMap(sqlStrings, config.schema)
 
 	// Establish function-to-table relationships
-	establishFunctionTableRelationships(nodeMap, childrenMap, pkgs, dbTableMap)
+	establishFunctionTableRelationships(prog, nodeMap, childrenMap, pkgs, dbTableMap, sqlcCatalog, entCatalog, config, scope)
+
+	// Keep closures reachable from the function that defines them
+	if !config.foldClosures {
+		linkClosuresToParents(nodeMap, childrenMap)
+	}
 
 	// Populate nodes with updated children (including SQL tables)
-	populateNodes(nodeMap, childrenMap)
+	populateNodes(nodeMap, childrenMap, config.algorithm, diagnostics)
 
 	// Return only function nodes (SQL table nodes are now children of functions)
 	var allNodes []node.TrackedEntity
@@ -45,59 +190,109 @@ func ReadGraph(packagePath string) ([]node.TrackedEntity, error) {
 	return allNodes, nil
 }
 
-func buildSSAProgramWithPackages(packagePath string) (*ssa.Program, []*packages.Package, error) {
-	cfg := createPackageConfig()
-	pkgs, err := packages.Load(cfg, packagePath)
+func buildSSAProgramWithPackages(patterns []string, dir string) (*ssa.Program, []*packages.Package, *Diagnostics, error) {
+	cfg := createPackageConfig(dir)
+	pkgs, err := packages.Load(cfg, patterns...)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
-	prog := buildProgram(pkgs)
-	return prog, pkgs, nil
+	prog, ssaPkgs := buildProgram(pkgs)
+	return prog, pkgs, collectDiagnostics(patterns, pkgs, ssaPkgs), nil
 }
 
-func createPackageConfig() *packages.Config {
+func createPackageConfig(dir string) *packages.Config {
 	return &packages.Config{
-		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedImports | packages.NeedTypes | packages.NeedTypesSizes | packages.NeedSyntax | packages.NeedTypesInfo,
+		Dir:  dir,
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedImports | packages.NeedTypes | packages.NeedTypesSizes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedDeps | packages.NeedModule,
 	}
 }
 
-func buildProgram(pkgs []*packages.Package) *ssa.Program {
-	prog, _ := ssautil.Packages(pkgs, ssa.InstantiateGenerics)
+func buildProgram(pkgs []*packages.Package) (*ssa.Program, []*ssa.Package) {
+	prog, ssaPkgs := ssautil.Packages(pkgs, ssa.InstantiateGenerics)
 	prog.Build()
-	return prog
+	return prog, ssaPkgs
 }
 
-func buildNodeMaps(cg *callgraph.Graph) (map[*ssa.Function]*node.FunctionTrackedEntity, map[*ssa.Function][]node.TrackedEntity) {
+func buildNodeMaps(cg *callgraph.Graph, config *readGraphConfig, scope *packageScope) (map[*ssa.Function]*node.FunctionTrackedEntity, map[*ssa.Function][]*node.Edge) {
 	nodeMap := make(map[*ssa.Function]*node.FunctionTrackedEntity)
-	childrenMap := make(map[*ssa.Function][]node.TrackedEntity)
-	callgraph.GraphVisitEdges(cg, createEdgeVisitor(nodeMap, childrenMap))
+	childrenMap := make(map[*ssa.Function][]*node.Edge)
+	boundaryMap := make(map[string]*node.PackageTrackedEntity)
+	callEdges := newCallEdgeMap()
+	callgraph.GraphVisitEdges(cg, createEdgeVisitor(nodeMap, callEdges, boundaryMap, config, scope))
+
+	// Repeated calls to the same callee become one edge carrying every call site
+	for _, caller := range callEdges.callers {
+		for _, edge := range callEdges.edges[caller] {
+			childrenMap[caller] = append(childrenMap[caller], edge.toEdge(config.algorithm))
+		}
+	}
 	return nodeMap, childrenMap
 }
 
-func createEdgeVisitor(nodeMap map[*ssa.Function]*node.FunctionTrackedEntity, childrenMap map[*ssa.Function][]node.TrackedEntity) func(*callgraph.Edge) error {
+func createEdgeVisitor(nodeMap map[*ssa.Function]*node.FunctionTrackedEntity, callEdges *callEdgeMap, boundaryMap map[string]*node.PackageTrackedEntity, config *readGraphConfig, scope *packageScope) func(*callgraph.Edge) error {
 	return func(edge *callgraph.Edge) error {
 		caller, callee := edge.Caller.Func, edge.Callee.Func
+		// RTAなどの合成ルートNodeは関数を持たない
+		if caller == nil || callee == nil {
+			return nil
+		}
+		if config.foldClosures {
+			foldedCaller, foldedCallee := outermostFunction(caller), outermostFunction(callee)
+			// 外側の関数から自身の無名関数への呼び出しは畳み込むと消える
+			if foldedCaller == foldedCallee && caller != callee {
+				return nil
+			}
+			caller, callee = foldedCaller, foldedCallee
+		}
+		if !scope.containsFunction(caller) {
+			return nil
+		}
 		ensureNodeExists(nodeMap, caller)
+		if !scope.containsFunction(callee) {
+			addBoundaryEdge(callEdges, boundaryMap, caller, callee, edge.Site, config)
+			return nil
+		}
 		ensureNodeExists(nodeMap, callee)
-		childrenMap[caller] = append(childrenMap[caller], nodeMap[callee])
+		callEdges.add(caller, nodeMap[callee], edge.Site)
 		return nil
 	}
 }
 
+// addBoundaryEdge は対象外の呼び出し先をパッケージごとの境界Nodeへの辺にまとめる
+func addBoundaryEdge(callEdges *callEdgeMap, boundaryMap map[string]*node.PackageTrackedEntity, caller, callee *ssa.Function, site ssa.CallInstruction, config *readGraphConfig) {
+	pkgPath := functionPackagePath(callee)
+	if config.boundary != BoundaryCollapse || pkgPath == "" {
+		return
+	}
+	boundaryNode, exists := boundaryMap[pkgPath]
+	if !exists {
+		boundaryNode = node.NewPackageTrackedEntity(pkgPath)
+		boundaryMap[pkgPath] = boundaryNode
+	}
+	callEdges.add(caller, boundaryNode, site)
+}
+
 func ensureNodeExists(nodeMap map[*ssa.Function]*node.FunctionTrackedEntity, fn *ssa.Function) {
 	if _, exists := nodeMap[fn]; !exists {
 		nodeMap[fn] = &node.FunctionTrackedEntity{}
 	}
 }
 
-func populateNodes(nodeMap map[*ssa.Function]*node.FunctionTrackedEntity, childrenMap map[*ssa.Function][]node.TrackedEntity) {
+func populateNodes(nodeMap map[*ssa.Function]*node.FunctionTrackedEntity, childrenMap map[*ssa.Function][]*node.Edge, algorithm CallGraphAlgorithm, diagnostics *Diagnostics) {
 	for fn, fnNode := range nodeMap {
-		children := childrenMap[fn]
-		*fnNode = *node.NewFunctionTrackedEntity(fn.String(), children)
+		edges := childrenMap[fn]
+		pkgPath := functionPackagePath(fn)
+		*fnNode = *node.NewFunctionTrackedEntity(fn.String(), nil,
+			node.WithEdges(edges...),
+			node.WithAlgorithm(string(algorithm)),
+			node.WithPackagePath(pkgPath),
+			node.WithPosition(toPosition(fn.Prog.Fset, fn.Pos())),
+			node.WithIncomplete(diagnostics.isIncomplete(pkgPath)),
+		)
 	}
 }
 
-func addAllPackageFunctions(prog *ssa.Program, pkgs []*packages.Package, nodeMap map[*ssa.Function]*node.FunctionTrackedEntity, childrenMap map[*ssa.Function][]node.TrackedEntity) {
+func addAllPackageFunctions(prog *ssa.Program, pkgs []*packages.Package, nodeMap map[*ssa.Function]*node.FunctionTrackedEntity, childrenMap map[*ssa.Function][]*node.Edge, scope *packageScope) {
 	// Iterate through all SSA packages and their functions
 	for _, ssaPkg := range prog.AllPackages() {
 		// Check if this SSA package corresponds to one of our target packages
@@ -105,13 +300,13 @@ func addAllPackageFunctions(prog *ssa.Program, pkgs []*packages.Package, nodeMap
 			if ssaPkg.Pkg.Path() == pkg.PkgPath {
 				// Add all functions from this package
 				for _, member := range ssaPkg.Members {
-					if fn, ok := member.(*ssa.Function); ok {
+					if fn, ok := member.(*ssa.Function); ok && scope.containsFunction(fn) {
 						// Only add if not already in nodeMap
 						if _, exists := nodeMap[fn]; !exists {
 							nodeMap[fn] = &node.FunctionTrackedEntity{}
 							// Initialize empty children slice if not exists
 							if _, exists := childrenMap[fn]; !exists {
-								childrenMap[fn] = []node.TrackedEntity{}
+								childrenMap[fn] = []*node.Edge{}
 							}
 						}
 					}
@@ -121,38 +316,41 @@ func addAllPackageFunctions(prog *ssa.Program, pkgs []*packages.Package, nodeMap
 	}
 }
 
+// sqlTableAccess はSQL文中のテーブル参照とそのテーブルに対する操作
+type sqlTableAccess struct {
+	table tableIdentifier
+	mode  node.AccessMode
+}
+
 // SQL analysis functions
 func extractTablesFromSQL(sql string) []string {
-	sql = strings.ToUpper(strings.TrimSpace(sql))
 	var tables []string
 	tableSet := make(map[string]bool)
-
-	// Patterns for different SQL operations
-	patterns := []string{
-		`FROM\s+(\w+)`,
-		`JOIN\s+(\w+)`,
-		`INTO\s+(\w+)`,
-		`UPDATE\s+(\w+)`,
-		`DELETE\s+FROM\s+(\w+)`,
-	}
-
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		matches := re.FindAllStringSubmatch(sql, -1)
-		for _, match := range matches {
-			if len(match) > 1 {
-				tableName := strings.ToLower(match[1])
-				if !tableSet[tableName] {
-					tableSet[tableName] = true
-					tables = append(tables, tableName)
-				}
-			}
+	for _, access := range extractTableAccessesFromSQL(sql, "") {
+		if key := access.table.key(); !tableSet[key] {
+			tableSet[key] = true
+			tables = append(tables, access.table.qualifiedName())
 		}
 	}
 
 	return tables
 }
 
+func extractTableAccessesFromSQL(sql string, defaultSchema string) []sqlTableAccess {
+	// Statements that fail to parse are skipped; the rest still contribute tables
+	stmts, _ := sqlparser.Parse(sql)
+
+	var accesses []sqlTableAccess
+	for _, ref := range sqlparser.References(stmts...) {
+		accesses = append(accesses, sqlTableAccess{
+			table: resolveTableIdentifier(ref.Table, defaultSchema),
+			mode:  accessModeOfOperation[ref.Operation],
+		})
+	}
+
+	return accesses
+}
+
 func detectSQLStrings(sourceCode string) []string {
 	var sqlStrings []string
 
@@ -176,14 +374,15 @@ func detectSQLStrings(sourceCode string) []string {
 	return sqlStrings
 }
 
-func createDBTableNodesMap(sqlStrings []string) map[string]*node.DatabaseTableTrackedEntity {
+// createDBTableNodesMap はテーブルのキー(tableIdentifier.key)からNodeへの対応を作る
+func createDBTableNodesMap(sqlStrings []string, defaultSchema string) map[string]*node.DatabaseTableTrackedEntity {
 	tableMap := make(map[string]*node.DatabaseTableTrackedEntity)
 
 	for _, sql := range sqlStrings {
-		tables := extractTablesFromSQL(sql)
-		for _, table := range tables {
-			if _, exists := tableMap[table]; !exists {
-				tableMap[table] = node.NewDatabaseTableTrackedEntity(table, []node.TrackedEntity{})
+		for _, access := range extractTableAccessesFromSQL(sql, defaultSchema) {
+			table := access.table
+			if _, exists := tableMap[table.key()]; !exists {
+				tableMap[table.key()] = newTableNode(table)
 			}
 		}
 	}
@@ -197,15 +396,16 @@ func analyzePackageForSQL(pkgs []*packages.Package) []string {
 	for _, pkg := range pkgs {
 		for _, file := range pkg.Syntax {
 			ast.Inspect(file, func(n ast.Node) bool {
-				if lit, ok := n.(*ast.BasicLit); ok && lit.Kind.String() == "STRING" {
-					value := strings.Trim(lit.Value, `"'`+"`")
-					if isSQLString(value) {
+				if lit, ok := n.(*ast.BasicLit); ok {
+					if value, ok := stringLiteral(lit); ok && isSQLString(value) {
 						allSQLStrings = append(allSQLStrings, value)
 					}
 				}
 				return true
 			})
 		}
+		// Queries kept in .sql files are embedded with //go:embed
+		allSQLStrings = append(allSQLStrings, embeddedSQL(pkg)...)
 	}
 
 	return allSQLStrings
@@ -222,124 +422,454 @@ func isSQLString(s string) bool {
 	return false
 }
 
-func establishFunctionTableRelationships(nodeMap map[*ssa.Function]*node.FunctionTrackedEntity, childrenMap map[*ssa.Function][]node.TrackedEntity, pkgs []*packages.Package, dbTableMap map[string]*node.DatabaseTableTrackedEntity) {
-	// Map function names to their SSA functions for lookup
-	funcNameToSSA := make(map[string]*ssa.Function)
-	for ssaFunc := range nodeMap {
-		if ssaFunc.Name() != "" {
-			funcNameToSSA[ssaFunc.Name()] = ssaFunc
+// sqlSite はSQL文字列が見つかった箇所
+// posは実行箇所(sqlxの呼び出し)、litPosは文字列リテラル自体の位置
+// prepareはSQLを準備するだけの呼び出し(db.Prepareなど)のSSA命令で、それ以外ではnil
+type sqlSite struct {
+	query   string
+	pos     token.Pos
+	litPos  token.Pos
+	prepare ssa.CallInstruction
+}
+
+// tableAccess は関数からテーブルへのアクセスとその根拠となった箇所、行われる操作
+// preparationsはプリペアドステートメントを実行した場合に、それを準備した関数と箇所
+type tableAccess struct {
+	table        string
+	positions    []node.Position
+	modes        []node.AccessMode
+	preparations []node.Preparation
+}
+
+func establishFunctionTableRelationships(prog *ssa.Program, nodeMap map[*ssa.Function]*node.FunctionTrackedEntity, childrenMap map[*ssa.Function][]*node.Edge, pkgs []*packages.Package, dbTableMap map[string]*node.DatabaseTableTrackedEntity, sqlcCatalog *sqlcCatalog, entCatalog *entCatalog, config *readGraphConfig, scope *packageScope) {
+	// Each table becomes one edge per function, carrying every site that accesses it
+	accesses := make(map[*ssa.Function][]*tableAccess)
+	var accessingFuncs []*ssa.Function
+	columns := newColumnAccesses()
+	evaluator := newSQLEvaluator(prog)
+	evaluator.files = loadSQLFiles(prog, pkgs)
+	statements := newStatementTracker(prog, scope)
+
+	// recordSQL records the tables (and columns) accessed by query in fn at position
+	recordSQL := func(fn *ssa.Function, query string, position node.Position, preparation *node.Preparation) {
+		ensureNodeExists(nodeMap, fn)
+		if _, exists := accesses[fn]; !exists {
+			accessingFuncs = append(accessingFuncs, fn)
+		}
+		for _, sqlAccess := range extractTableAccessesFromSQL(query, config.schema) {
+			tableKey := sqlAccess.table.key()
+			if _, exists := dbTableMap[tableKey]; !exists {
+				// Tables only named in constants or concatenations never appear as a whole literal
+				dbTableMap[tableKey] = newTableNode(sqlAccess.table)
+			}
+			// With a mode filter (e.g. writes only), other operations never become edges
+			if !config.acceptsAccessMode(sqlAccess.mode) {
+				continue
+			}
+			access := findTableAccess(accesses[fn], tableKey)
+			if access == nil {
+				access = &tableAccess{table: tableKey}
+				accesses[fn] = append(accesses[fn], access)
+			}
+			access.modes = append(access.modes, sqlAccess.mode)
+			if !containsPosition(access.positions, position) {
+				access.positions = append(access.positions, position)
+			}
+			if preparation != nil {
+				access.preparations = append(access.preparations, *preparation)
+			}
+		}
+		if !config.columns {
+			return
+		}
+		for _, columnAccess := range extractColumnAccessesFromSQL(query, config.schema) {
+			if _, exists := dbTableMap[columnAccess.table.key()]; !exists {
+				continue
+			}
+			if config.acceptsAccessMode(columnAccess.mode) {
+				columns.record(fn, columnAccess, position)
+			}
 		}
 	}
 
 	// Analyze each package for SQL strings within functions
 	for _, pkg := range pkgs {
 		for _, file := range pkg.Syntax {
-			ast.Inspect(file, func(n ast.Node) bool {
-				// Look for function declarations
-				if funcDecl, ok := n.(*ast.FuncDecl); ok && funcDecl.Name != nil {
-					funcName := funcDecl.Name.Name
-
-					// Find the corresponding SSA function
-					var targetSSAFunc *ssa.Function
-					for ssaFunc := range nodeMap {
-						if ssaFunc.Name() == funcName {
-							targetSSAFunc = ssaFunc
-							break
+			for _, decl := range file.Decls {
+				funcDecl, ok := decl.(*ast.FuncDecl)
+				if !ok {
+					continue
+				}
+
+				// Find the corresponding SSA function through its type-checked object
+				targetSSAFunc := ssaFunctionForDecl(prog, pkg, funcDecl)
+				if targetSSAFunc == nil {
+					continue
+				}
+
+				// SQL inside a function literal belongs to the innermost SSA function (fn$1 etc.)
+				anonFuncs := make(map[*ast.FuncLit]*ssa.Function)
+				collectAnonFunctions(targetSSAFunc, anonFuncs)
+
+				for owner, body := range functionBodies(funcDecl) {
+					ownerSSAFunc := targetSSAFunc
+					if lit, ok := owner.(*ast.FuncLit); ok {
+						if anonFunc, exists := anonFuncs[lit]; exists {
+							ownerSSAFunc = anonFunc
 						}
 					}
+					// Arguments are evaluated in the function that contains the call, even when folding closures
+					sqlArgs := evaluator.forFunction(pkg.TypesInfo, ownerSSAFunc)
+					sqlArgs.sqlc = sqlcCatalog
+					sqlArgs.ent = entCatalog
+					if config.foldClosures {
+						ownerSSAFunc = outermostFunction(ownerSSAFunc)
+					}
+					if !scope.containsFunction(ownerSSAFunc) {
+						continue
+					}
 
-					if targetSSAFunc != nil {
-						// Find SQL strings within this function (both direct strings and sqlx function calls)
-						sqlStringsInFunc := findSQLStringsInFunction(funcDecl)
-						sqlxStringsInFunc := findSQLXCallsInFunction(funcDecl)
-
-						// Combine both sources of SQL strings
-						allSQLStrings := append(sqlStringsInFunc, sqlxStringsInFunc...)
-
-						// For each SQL string, find referenced tables and add them as children
-						for _, sqlStr := range allSQLStrings {
-							tables := extractTablesFromSQL(sqlStr)
-							for _, tableName := range tables {
-								if dbTableNode, exists := dbTableMap[tableName]; exists {
-									// Add the table node as a child of this function
-									childrenMap[targetSSAFunc] = append(childrenMap[targetSSAFunc], dbTableNode)
-								}
-							}
+					// Find SQL strings within this function (both direct strings and sqlx function calls)
+					sqlxSites := findSQLXCallsInFunction(body, sqlArgs)
+					literalSites := findSQLStringsInFunction(body, sqlArgs)
+					if query, ok := sqlcCatalog.method(ownerSSAFunc); ok && owner == funcDecl {
+						// A generated sqlc method accesses the tables of its query, positioned in the .sql file
+						sqlxSites = append(sqlxSites, sqlSite{query: query.sql, pos: query.pos})
+					}
+
+					// Combine both sources of SQL strings
+					allSites := mergeSQLSites(sqlxSites, literalSites)
+					if len(allSites) == 0 {
+						continue
+					}
+
+					// For each SQL string, find referenced tables and record where they are accessed
+					for _, site := range allSites {
+						position := toPosition(prog.Fset, site.pos)
+						executors := preparedStatementExecutors(statements, site, config, scope)
+						if len(executors) == 0 {
+							// Statements that are never executed in scope still show where the SQL is written
+							recordSQL(ownerSSAFunc, site.query, position, nil)
+							continue
+						}
+						preparation := &node.Preparation{Function: ownerSSAFunc.String(), Position: position}
+						for _, execution := range executors {
+							recordSQL(execution.fn, site.query, toPosition(prog.Fset, execution.pos), preparation)
 						}
 					}
 				}
-				return true
-			})
+			}
 		}
 	}
+
+	// Add the table nodes as children of the functions
+	for _, fn := range accessingFuncs {
+		for _, access := range accesses[fn] {
+			edge := node.NewEdge(dbTableMap[access.table],
+				node.WithEdgePositions(access.positions...),
+				node.WithAccessModes(access.modes...),
+				node.WithPreparations(access.preparations...),
+			)
+			childrenMap[fn] = append(childrenMap[fn], edge)
+		}
+	}
+	columns.link(childrenMap, dbTableMap)
 }
 
-func findSQLStringsInFunction(funcDecl *ast.FuncDecl) []string {
-	var sqlStrings []string
+// preparedStatementExecutors はsiteがプリペアドステートメントの準備であれば、それを実行する対象範囲内の関数と箇所を返す
+func preparedStatementExecutors(statements *statementTracker, site sqlSite, config *readGraphConfig, scope *packageScope) []statementExecution {
+	if site.prepare == nil {
+		return nil
+	}
+	var executors []statementExecution
+	for _, execution := range statements.executions(site.prepare) {
+		if config.foldClosures {
+			execution.fn = outermostFunction(execution.fn)
+		}
+		if scope.containsFunction(execution.fn) {
+			executors = append(executors, execution)
+		}
+	}
+	return executors
+}
 
-	if funcDecl.Body != nil {
-		ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
-			if lit, ok := n.(*ast.BasicLit); ok && lit.Kind.String() == "STRING" {
-				value := strings.Trim(lit.Value, `"'`+"`")
-				if isSQLString(value) {
-					sqlStrings = append(sqlStrings, value)
-				}
-			}
+func containsPosition(positions []node.Position, position node.Position) bool {
+	for _, existing := range positions {
+		if existing == position {
 			return true
-		})
+		}
 	}
+	return false
+}
 
-	return sqlStrings
+func findTableAccess(accesses []*tableAccess, tableName string) *tableAccess {
+	for _, access := range accesses {
+		if access.table == tableName {
+			return access
+		}
+	}
+	return nil
 }
 
-func findSQLXCallsInFunction(funcDecl *ast.FuncDecl) []string {
-	var sqlStrings []string
-	if funcDecl.Body != nil {
-		ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
-			if callExpr, ok := n.(*ast.CallExpr); ok {
-				sqlStrings = append(sqlStrings, extractSQLFromCall(callExpr)...)
-			}
+// mergeSQLSites はsqlxの呼び出しの引数として既に見つかったリテラルを除いて結合する
+// 変数や連結、fmt.Sprintfを経由して渡されたリテラルは、評価したSQLに含まれていれば同じものとみなす
+func mergeSQLSites(callSites []sqlSite, literalSites []sqlSite) []sqlSite {
+	consumed := make(map[token.Pos]bool)
+	for _, site := range callSites {
+		consumed[site.litPos] = true
+	}
+	merged := append([]sqlSite{}, callSites...)
+	for _, site := range literalSites {
+		if !consumed[site.litPos] && !containsQuery(callSites, site.query) {
+			merged = append(merged, site)
+		}
+	}
+	return merged
+}
+
+// containsQuery はリテラルの分かっている部分が、いずれかの呼び出しのSQLに順に含まれるかを判定する
+func containsQuery(sites []sqlSite, query string) bool {
+	for _, site := range sites {
+		if containsFragments(site.query, strings.Split(query, sqlparser.Placeholder)) {
 			return true
-		})
+		}
 	}
-	return sqlStrings
+	return false
 }
 
-func extractSQLFromCall(callExpr *ast.CallExpr) []string {
-	if selExpr, ok := callExpr.Fun.(*ast.SelectorExpr); ok {
-		if isSQLXMethod(selExpr.Sel.Name) {
-			return getSQLFromArgs(callExpr.Args, selExpr.Sel.Name)
+func containsFragments(s string, fragments []string) bool {
+	for _, fragment := range fragments {
+		i := strings.Index(s, fragment)
+		if i < 0 {
+			return false
 		}
+		s = s[i+len(fragment):]
 	}
-	return []string{}
+	return true
 }
 
-func isSQLXMethod(methodName string) bool {
-	methods := []string{"Get", "Select", "Exec", "Query", "QueryRow", "Queryx", "QueryRowx"}
-	for _, method := range methods {
-		if methodName == method {
+func toPosition(fset *token.FileSet, pos token.Pos) node.Position {
+	if !pos.IsValid() {
+		return node.Position{}
+	}
+	position := fset.Position(pos)
+	return node.Position{
+		File:   position.Filename,
+		Line:   position.Line,
+		Column: position.Column,
+	}
+}
+
+// ssaFunctionForDecl は型情報を使ってFuncDeclに対応するSSA関数を返す
+// 名前による照合と異なり、レシーバ違いの同名メソッドや他パッケージの同名関数を取り違えない
+func ssaFunctionForDecl(prog *ssa.Program, pkg *packages.Package, funcDecl *ast.FuncDecl) *ssa.Function {
+	if pkg.TypesInfo == nil || funcDecl.Name == nil {
+		return nil
+	}
+	obj, ok := pkg.TypesInfo.Defs[funcDecl.Name].(*types.Func)
+	if !ok {
+		return nil
+	}
+	return prog.FuncValue(obj)
+}
+
+// findSQLStringsInFunction は関数本体からSQLらしい文字列リテラルを探す
+// 評価できなかった引数の代わりに、データベースや他の検出器の呼び出しに(ローカル変数を経由して)渡るリテラルだけを残す
+// ネストした無名関数は別の関数として扱うため走査しない
+func findSQLStringsInFunction(body *ast.BlockStmt, sqlArgs *sqlArguments) []sqlSite {
+	if body == nil || sqlArgs == nil || sqlArgs.info == nil || !containsSQLLiteral(body) {
+		return nil
+	}
+	flow := newLiteralFlow(body, sqlArgs.info)
+	var sqlSites []sqlSite
+	seen := make(map[token.Pos]bool)
+	ast.Inspect(body, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FuncLit); ok {
+			return false
+		}
+		callExpr, ok := n.(*ast.CallExpr)
+		if !ok || !isSQLSink(callExpr, sqlArgs) {
 			return true
 		}
+		for _, lit := range flow.literals(callExpr) {
+			value, ok := stringLiteral(lit)
+			if seen[lit.Pos()] || !ok || !isSQLString(value) {
+				continue
+			}
+			seen[lit.Pos()] = true
+			// fmt.Sprintfの書式かもしれないため、動詞は実行時まで分からない部分として読む
+			sqlSites = append(sqlSites, sqlSite{query: formatPattern(value), pos: lit.Pos(), litPos: lit.Pos()})
+		}
+		return true
+	})
+	sort.Slice(sqlSites, func(i, j int) bool { return sqlSites[i].pos < sqlSites[j].pos })
+	return sqlSites
+}
+
+// containsSQLLiteral は関数本体にSQLらしい文字列リテラルがあるかを判定する
+func containsSQLLiteral(body *ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FuncLit); ok || found {
+			return false
+		}
+		if lit, ok := n.(*ast.BasicLit); ok {
+			value, ok := stringLiteral(lit)
+			found = ok && isSQLString(value)
+		}
+		return true
+	})
+	return found
+}
+
+// isSQLSink はSQLを受け取る呼び出し(データベースの型のメソッド、sqlc、ent、gorm、クエリビルダー)かを判定する
+// gormとクエリビルダーはメソッドチェーンの最後の呼び出しで、チェーン全体が引数にあたる
+func isSQLSink(callExpr *ast.CallExpr, sqlArgs *sqlArguments) bool {
+	if _, ok := sqlArgs.sqlc.call(sqlArgs.info, callExpr); ok {
+		return true
 	}
-	return false
+	if _, ok := sqlArgs.ent.call(sqlArgs.info, callExpr); ok {
+		return true
+	}
+	return gormFinisherMethod(sqlArgs.info, callExpr) != nil ||
+		builderTerminalMethod(sqlArgs.info, callExpr) != nil ||
+		databaseMethod(sqlArgs.info, callExpr) != nil
+}
+
+// literalFlow はローカル変数ごとの、代入された値に含まれる文字列リテラル
+type literalFlow struct {
+	info *types.Info
+	vars map[types.Object][]*ast.BasicLit
 }
 
-func getSQLFromArgs(args []ast.Expr, methodName string) []string {
-	sqlIndex := getSQLArgumentIndex(methodName)
-	if sqlIndex < len(args) {
-		if lit, ok := args[sqlIndex].(*ast.BasicLit); ok && lit.Kind.String() == "STRING" {
-			value := strings.Trim(lit.Value, `"'`+"`")
-			if isSQLString(value) {
-				return []string{value}
+// newLiteralFlow は関数本体の代入と変数宣言をたどり、変数に流れ込むリテラルを求める
+// 変数から変数への代入もたどるため、変化がなくなるまで繰り返す
+func newLiteralFlow(body *ast.BlockStmt, info *types.Info) *literalFlow {
+	flow := &literalFlow{info: info, vars: make(map[types.Object][]*ast.BasicLit)}
+	for changed := true; changed; {
+		changed = false
+		ast.Inspect(body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncLit:
+				return false
+			case *ast.AssignStmt:
+				if len(n.Lhs) == len(n.Rhs) {
+					for i, lhs := range n.Lhs {
+						changed = flow.assign(lhs, n.Rhs[i]) || changed
+					}
+				}
+			case *ast.ValueSpec:
+				if len(n.Names) == len(n.Values) {
+					for i, name := range n.Names {
+						changed = flow.assign(name, n.Values[i]) || changed
+					}
+				}
 			}
+			return true
+		})
+	}
+	return flow
+}
+
+// assign はrhsに含まれるリテラルを変数lhsに加え、増えたかを返す
+func (f *literalFlow) assign(lhs ast.Expr, rhs ast.Expr) bool {
+	ident, ok := lhs.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	obj := f.info.ObjectOf(ident)
+	if obj == nil {
+		return false
+	}
+	changed := false
+	for _, lit := range f.literals(rhs) {
+		if !slices.Contains(f.vars[obj], lit) {
+			f.vars[obj] = append(f.vars[obj], lit)
+			changed = true
 		}
 	}
-	return []string{}
+	return changed
 }
 
-func getSQLArgumentIndex(methodName string) int {
-	if methodName == "Get" || methodName == "Select" {
-		return 1
+// literals は式に含まれる文字列リテラルと、式で読むローカル変数に流れ込んだリテラルを返す
+func (f *literalFlow) literals(expr ast.Node) []*ast.BasicLit {
+	var lits []*ast.BasicLit
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.BasicLit:
+			if n.Kind == token.STRING {
+				lits = append(lits, n)
+			}
+		case *ast.Ident:
+			if obj := f.info.Uses[n]; obj != nil {
+				lits = append(lits, f.vars[obj]...)
+			}
+		}
+		return true
+	})
+	return lits
+}
+
+func findSQLXCallsInFunction(body *ast.BlockStmt, sqlArgs *sqlArguments) []sqlSite {
+	var sqlSites []sqlSite
+	if body != nil {
+		ast.Inspect(body, func(n ast.Node) bool {
+			if _, ok := n.(*ast.FuncLit); ok {
+				return false
+			}
+			if callExpr, ok := n.(*ast.CallExpr); ok {
+				sqlSites = append(sqlSites, extractSQLFromCall(callExpr, sqlArgs)...)
+			}
+			return true
+		})
+	}
+	return sqlSites
+}
+
+// extractSQLFromCall はデータベースの型のメソッド呼び出しから、シグネチャで決まる位置のSQLを取り出す
+func extractSQLFromCall(callExpr *ast.CallExpr, sqlArgs *sqlArguments) []sqlSite {
+	if sqlArgs == nil {
+		return []sqlSite{}
+	}
+	if query, ok := sqlArgs.sqlc.call(sqlArgs.info, callExpr); ok {
+		return []sqlSite{{query: query.sql, pos: callExpr.Pos(), litPos: callExpr.Pos()}}
+	}
+	if sites, ok := sqlArgs.ent.call(sqlArgs.info, callExpr); ok {
+		return sites
+	}
+	if finisher := gormFinisherMethod(sqlArgs.info, callExpr); finisher != nil {
+		return gormSites(callExpr, finisher, sqlArgs)
+	}
+	if terminal := builderTerminalMethod(sqlArgs.info, callExpr); terminal != nil {
+		return builderSites(callExpr, terminal, sqlArgs)
+	}
+	method := databaseMethod(sqlArgs.info, callExpr)
+	if method == nil {
+		return []sqlSite{}
+	}
+	if isCopyFrom(method) {
+		return copyFromSites(callExpr, method, sqlArgs.info)
+	}
+	sites := getSQLFromArgs(callExpr, sqlParameterIndex(method.Type().(*types.Signature)), sqlArgs)
+	if isPrepareMethod(method) {
+		// The tables are accessed where the returned statement is executed
+		for i := range sites {
+			sites[i].prepare = sqlArgs.calls[callExpr.Lparen]
+		}
+	}
+	return sites
+}
+
+// getSQLFromArgs はSQLの引数をリテラル、定数、パッケージ変数、文字列の連結から求める
+func getSQLFromArgs(callExpr *ast.CallExpr, sqlIndex int, sqlArgs *sqlArguments) []sqlSite {
+	if sqlIndex < 0 {
+		return []sqlSite{}
+	}
+	value, ok := sqlArgs.evaluate(callExpr, sqlIndex)
+	if ok && isSQLString(value) {
+		return []sqlSite{{query: value, pos: callExpr.Pos(), litPos: callExpr.Args[sqlIndex].Pos()}}
 	}
-	return 0
+	return []sqlSite{}
 }
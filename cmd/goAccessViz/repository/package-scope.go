@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+)
+
+// BoundaryMode は解析対象外のパッケージへの呼び出しの扱い
+type BoundaryMode string
+
+const (
+	// BoundaryDrop は対象外の呼び出し先を捨てる
+	BoundaryDrop BoundaryMode = "drop"
+	// BoundaryCollapse は対象外の呼び出し先をパッケージごとに一つのNodeにまとめる
+	BoundaryCollapse BoundaryMode = "collapse"
+)
+
+// ParseBoundaryMode はCLIの引数などから境界の扱いを解決する
+func ParseBoundaryMode(name string) (BoundaryMode, error) {
+	switch mode := BoundaryMode(name); mode {
+	case BoundaryDrop, BoundaryCollapse:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown boundary mode %q (expected drop or collapse)", name)
+}
+
+// packageScope は関数が解析対象のパッケージに属するかを判定する
+// includeが空の場合はメインモジュールのパッケージだけを対象にする
+type packageScope struct {
+	include     []string
+	exclude     []string
+	modulePaths []string
+	cache       map[string]bool
+}
+
+func newPackageScope(pkgs []*packages.Package, config *readGraphConfig) *packageScope {
+	scope := &packageScope{
+		include: config.include,
+		exclude: config.exclude,
+		cache:   make(map[string]bool),
+	}
+	seen := make(map[string]bool)
+	for _, pkg := range pkgs {
+		// モジュール情報がない場合(GOPATHなど)は読み込んだパッケージ自体を対象にする
+		modulePath := pkg.PkgPath
+		if pkg.Module != nil && pkg.Module.Main {
+			modulePath = pkg.Module.Path
+		}
+		if !seen[modulePath] {
+			seen[modulePath] = true
+			scope.modulePaths = append(scope.modulePaths, modulePath)
+		}
+	}
+	return scope
+}
+
+func (s *packageScope) containsFunction(fn *ssa.Function) bool {
+	return s.containsPackage(functionPackagePath(fn))
+}
+
+func (s *packageScope) containsPackage(pkgPath string) bool {
+	if pkgPath == "" {
+		return false
+	}
+	if contained, exists := s.cache[pkgPath]; exists {
+		return contained
+	}
+
+	contained := false
+	if len(s.include) > 0 {
+		contained = matchAnyPackagePattern(s.include, pkgPath)
+	} else {
+		contained = matchAnyPackagePattern(s.moduleRootPatterns(), pkgPath)
+	}
+	if contained && matchAnyPackagePattern(s.exclude, pkgPath) {
+		contained = false
+	}
+
+	s.cache[pkgPath] = contained
+	return contained
+}
+
+func (s *packageScope) moduleRootPatterns() []string {
+	patterns := make([]string, 0, len(s.modulePaths))
+	for _, modulePath := range s.modulePaths {
+		patterns = append(patterns, modulePath+"/...")
+	}
+	return patterns
+}
+
+// functionPackagePath はラッパー関数などPkgを持たない関数でも元のパッケージを返す
+func functionPackagePath(fn *ssa.Function) string {
+	if fn.Pkg != nil {
+		return fn.Pkg.Pkg.Path()
+	}
+	if obj := fn.Object(); obj != nil && obj.Pkg() != nil {
+		return obj.Pkg().Path()
+	}
+	return ""
+}
+
+func matchAnyPackagePattern(patterns []string, pkgPath string) bool {
+	for _, pattern := range patterns {
+		if matchPackagePattern(pattern, pkgPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPackagePattern はpath.Matchのグロブに加えて、goコマンドと同じ末尾の"/..."を受け付ける
+func matchPackagePattern(pattern, pkgPath string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/..."); ok {
+		return pkgPath == prefix || strings.HasPrefix(pkgPath, prefix+"/")
+	}
+	matched, err := path.Match(pattern, pkgPath)
+	return err == nil && matched
+}
@@ -0,0 +1,298 @@
+package repository
+
+import (
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// statementTypes はプリペアドステートメントの型(パッケージパスごとの型名)
+var statementTypes = map[string][]string{
+	"database/sql": {"Stmt"},
+	sqlxPackage:    {"Stmt", "NamedStmt"},
+}
+
+// prepareMethods はSQLを準備してステートメントを返すメソッド
+var prepareMethods = map[string]bool{
+	"Prepare": true, "PrepareContext": true,
+	"Preparex": true, "PreparexContext": true,
+	"PrepareNamed": true, "PrepareNamedContext": true,
+}
+
+// statementExecMethods はステートメントを実行するメソッド
+var statementExecMethods = map[string]bool{
+	"Exec": true, "ExecContext": true,
+	"Query": true, "QueryContext": true,
+	"QueryRow": true, "QueryRowContext": true,
+	"Get": true, "GetContext": true,
+	"Select": true, "SelectContext": true,
+	"Queryx": true, "QueryxContext": true,
+	"QueryRowx": true, "QueryRowxContext": true,
+	"MustExec": true, "MustExecContext": true,
+}
+
+// statementRebinders はステートメントをトランザクションに結び付け直したものを返すメソッド
+var statementRebinders = map[string]bool{
+	"Stmt": true, "StmtContext": true,
+	"Stmtx": true, "StmtxContext": true,
+	"NamedStmt": true, "NamedStmtContext": true,
+}
+
+// statementExecution はプリペアドステートメントを実行する関数と箇所
+type statementExecution struct {
+	fn  *ssa.Function
+	pos token.Pos
+}
+
+// fieldKey は構造体の型とフィールドの位置の組
+type fieldKey struct {
+	structType string
+	index      int
+}
+
+// statementTracker はプリペアドステートメントの値を構造体のフィールド、戻り値、引数、クロージャを通して追跡する
+// 索引は最初にステートメントを追跡するときに作るため、Prepareを使わないプログラムでは命令を走査しない
+type statementTracker struct {
+	prog        *ssa.Program
+	scope       *packageScope
+	indexed     bool
+	fieldAddrs  map[fieldKey][]*ssa.FieldAddr
+	fields      map[fieldKey][]*ssa.Field
+	globalLoads map[*ssa.Global][]*ssa.UnOp
+	callers     map[*ssa.Function][]ssa.CallInstruction
+}
+
+func newStatementTracker(prog *ssa.Program, scope *packageScope) *statementTracker {
+	return &statementTracker{prog: prog, scope: scope}
+}
+
+// index は対象範囲内の関数のフィールドやパッケージ変数の読み出しと、関数の呼び出し元を一度だけ索引する
+func (t *statementTracker) index() {
+	if t.indexed {
+		return
+	}
+	t.indexed = true
+	t.fieldAddrs = make(map[fieldKey][]*ssa.FieldAddr)
+	t.fields = make(map[fieldKey][]*ssa.Field)
+	t.globalLoads = make(map[*ssa.Global][]*ssa.UnOp)
+	t.callers = make(map[*ssa.Function][]ssa.CallInstruction)
+	for fn := range ssautil.AllFunctions(t.prog) {
+		if !t.scope.containsFunction(fn) {
+			continue
+		}
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				switch instr := instr.(type) {
+				case *ssa.FieldAddr:
+					key := fieldKey{structType: derefType(instr.X.Type()).String(), index: instr.Field}
+					t.fieldAddrs[key] = append(t.fieldAddrs[key], instr)
+				case *ssa.Field:
+					key := fieldKey{structType: instr.X.Type().String(), index: instr.Field}
+					t.fields[key] = append(t.fields[key], instr)
+				case *ssa.UnOp:
+					if global, ok := instr.X.(*ssa.Global); ok && instr.Op == token.MUL {
+						t.globalLoads[global] = append(t.globalLoads[global], instr)
+					}
+				}
+				if call, ok := instr.(ssa.CallInstruction); ok {
+					if callee := call.Common().StaticCallee(); callee != nil {
+						t.callers[callee] = append(t.callers[callee], call)
+					}
+				}
+			}
+		}
+	}
+}
+
+// executions はprepareの呼び出しが返したステートメントを実行している箇所を返す
+func (t *statementTracker) executions(prepare ssa.CallInstruction) []statementExecution {
+	call, ok := prepare.(*ssa.Call)
+	if !ok {
+		return nil
+	}
+	t.index()
+	f := &statementFlow{tracker: t, visited: make(map[ssa.Value]bool)}
+	// (stmt, err)のstmt
+	for _, ref := range referrers(call) {
+		if extract, ok := ref.(*ssa.Extract); ok && extract.Index == 0 {
+			f.follow(extract)
+		}
+	}
+	return f.executions
+}
+
+// statementFlow は一つのステートメントの値が流れる先を辿る
+type statementFlow struct {
+	tracker    *statementTracker
+	visited    map[ssa.Value]bool
+	executions []statementExecution
+}
+
+func (f *statementFlow) follow(v ssa.Value) {
+	if v == nil || f.visited[v] {
+		return
+	}
+	f.visited[v] = true
+
+	for _, ref := range referrers(v) {
+		switch ref := ref.(type) {
+		case ssa.CallInstruction:
+			f.call(ref, v)
+		case *ssa.Store:
+			if ref.Val == v {
+				f.stored(ref.Addr)
+			}
+		case *ssa.FieldAddr:
+			// *sqlx.Stmtに埋め込まれた*sql.Stmtも同じステートメント
+			if isStatementType(ref.X.Type()) {
+				f.loads(ref)
+			}
+		case *ssa.Field:
+			if isStatementType(ref.X.Type()) {
+				f.follow(ref)
+			}
+		case *ssa.Phi:
+			f.follow(ref)
+		case *ssa.ChangeType:
+			f.follow(ref)
+		case *ssa.Return:
+			f.returned(ref, v)
+		case *ssa.MakeClosure:
+			if fn, ok := ref.Fn.(*ssa.Function); ok {
+				for i, binding := range ref.Bindings {
+					if binding == v && i < len(fn.FreeVars) {
+						f.follow(fn.FreeVars[i])
+					}
+				}
+			}
+		}
+	}
+}
+
+// call はステートメントを受け取る呼び出しを、実行・結び付け直し・他の関数への受け渡しに分けて辿る
+func (f *statementFlow) call(call ssa.CallInstruction, v ssa.Value) {
+	common := call.Common()
+	if common.IsInvoke() {
+		if common.Value == v && statementExecMethods[common.Method.Name()] {
+			f.executions = append(f.executions, statementExecution{fn: call.Parent(), pos: common.Pos()})
+		}
+		return
+	}
+	callee := common.StaticCallee()
+	if callee == nil {
+		return
+	}
+	if callee.Signature.Recv() != nil && len(common.Args) > 0 && common.Args[0] == v && isStatementType(common.Args[0].Type()) {
+		if statementExecMethods[callee.Name()] {
+			f.executions = append(f.executions, statementExecution{fn: call.Parent(), pos: common.Pos()})
+		}
+		return
+	}
+	if statementRebinders[callee.Name()] && callee.Signature.Recv() != nil {
+		// tx.Stmtx(stmt)は同じSQLのステートメントを返す
+		if value := call.Value(); value != nil {
+			for _, ref := range referrers(value) {
+				if extract, ok := ref.(*ssa.Extract); ok && extract.Index == 0 {
+					f.follow(extract)
+				}
+			}
+			f.follow(value)
+		}
+		return
+	}
+	for i, arg := range common.Args {
+		if arg == v && i < len(callee.Params) {
+			f.follow(callee.Params[i])
+		}
+	}
+}
+
+// stored はステートメントが代入された先(フィールド、パッケージ変数、局所変数)からの読み出しを辿る
+func (f *statementFlow) stored(addr ssa.Value) {
+	switch addr := addr.(type) {
+	case *ssa.FieldAddr:
+		key := fieldKey{structType: derefType(addr.X.Type()).String(), index: addr.Field}
+		for _, fieldAddr := range f.tracker.fieldAddrs[key] {
+			f.loads(fieldAddr)
+		}
+		for _, field := range f.tracker.fields[key] {
+			f.follow(field)
+		}
+	case *ssa.Global:
+		for _, load := range f.tracker.globalLoads[addr] {
+			f.follow(load)
+		}
+	case *ssa.Alloc:
+		f.loads(addr)
+	}
+}
+
+// loads はアドレスから値を読み出す命令を辿る
+func (f *statementFlow) loads(addr ssa.Value) {
+	for _, ref := range referrers(addr) {
+		if load, ok := ref.(*ssa.UnOp); ok && load.Op == token.MUL {
+			f.follow(load)
+		}
+	}
+}
+
+// returned は関数の戻り値として返されたステートメントを呼び出し元で辿る
+func (f *statementFlow) returned(ret *ssa.Return, v ssa.Value) {
+	fn := ret.Parent()
+	for i, result := range ret.Results {
+		if result != v {
+			continue
+		}
+		for _, call := range f.tracker.callers[fn] {
+			value := call.Value()
+			if value == nil {
+				continue
+			}
+			if len(ret.Results) == 1 {
+				f.follow(value)
+				continue
+			}
+			for _, ref := range referrers(value) {
+				if extract, ok := ref.(*ssa.Extract); ok && extract.Index == i {
+					f.follow(extract)
+				}
+			}
+		}
+	}
+}
+
+func referrers(v ssa.Value) []ssa.Instruction {
+	refs := v.Referrers()
+	if refs == nil {
+		return nil
+	}
+	return *refs
+}
+
+func derefType(t types.Type) types.Type {
+	if pointer, ok := t.Underlying().(*types.Pointer); ok {
+		return pointer.Elem()
+	}
+	return t
+}
+
+func isStatementType(t types.Type) bool {
+	t = derefType(t)
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+	for _, name := range statementTypes[named.Obj().Pkg().Path()] {
+		if named.Obj().Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isPrepareMethod はSQLを準備してステートメントを返すメソッドかを判定する
+func isPrepareMethod(fn *types.Func) bool {
+	return fn != nil && prepareMethods[fn.Name()]
+}
@@ -0,0 +1,446 @@
+package repository
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"strings"
+
+	"goAccessViz/cmd/goAccessViz/domain/node"
+	"goAccessViz/cmd/goAccessViz/repository/sqlparser"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+const (
+	// squirrelPackage はsquirrelのパッケージパス(テーブルや条件をSQLの断片として受け取る)
+	squirrelPackage = "github.com/Masterminds/squirrel"
+	// goquPackage はgoquのパッケージパス(テーブルを識別子として受け取り、クォートする)
+	goquPackage = "github.com/doug-martin/goqu/v9"
+)
+
+// builderTerminals はクエリビルダーのチェーンを終わらせてSQLを組み立てる(または実行する)メソッド
+var builderTerminals = map[string]bool{
+	// squirrel
+	"ToSql": true, "RunWith": true,
+	"Exec": true, "ExecContext": true,
+	"Query": true, "QueryContext": true,
+	"QueryRow": true, "QueryRowContext": true,
+	"Scan": true, "ScanContext": true,
+	// goqu
+	"ToSQL": true, "Executor": true,
+	"ScanStructs": true, "ScanStructsContext": true,
+	"ScanStruct": true, "ScanStructContext": true,
+	"ScanVals": true, "ScanValsContext": true,
+	"ScanVal": true, "ScanValContext": true,
+	"Count": true, "CountContext": true,
+	"Pluck": true, "PluckContext": true,
+}
+
+// builderRootModes はチェーンを始めるコンストラクタ(とgoquのデータセットの変換)ごとの操作
+var builderRootModes = map[string]node.AccessMode{
+	"Select":   node.AccessSelect,
+	"From":     node.AccessSelect,
+	"Insert":   node.AccessInsert,
+	"Replace":  node.AccessUpsert,
+	"Update":   node.AccessUpdate,
+	"Delete":   node.AccessDelete,
+	"Truncate": node.AccessTruncate,
+}
+
+// builderJoins はJOINを加えるメソッドとSQLのキーワード
+var builderJoins = map[string]string{
+	"Join":      "JOIN",
+	"InnerJoin": "INNER JOIN",
+	"LeftJoin":  "LEFT JOIN",
+	"RightJoin": "RIGHT JOIN",
+	"FullJoin":  "FULL JOIN",
+	"CrossJoin": "CROSS JOIN",
+}
+
+// builderQuery はクエリビルダーのチェーンから読み取ったSQLの部品
+// squirrelの部品はSQLの断片、goquの部品はクォートした識別子になる
+type builderQuery struct {
+	mode     node.AccessMode
+	table    string
+	columns  []string
+	joins    []string
+	from     []string
+	where    []string
+	sets     []string
+	subquery *builderQuery
+}
+
+// sql は読み取った部品から、テーブルと列の解決に使うSQLを組み立てる
+func (q *builderQuery) sql() string {
+	if q.table == "" {
+		return ""
+	}
+	where := ""
+	if len(q.where) > 0 {
+		where = " WHERE " + strings.Join(q.where, " AND ")
+	}
+	switch q.mode {
+	case node.AccessSelect:
+		columns := "*"
+		if len(q.columns) > 0 {
+			columns = strings.Join(q.columns, ", ")
+		}
+		return "SELECT " + columns + " FROM " + q.table + joinClauses(q.joins) + where
+	case node.AccessInsert, node.AccessUpsert:
+		sql := "INSERT INTO " + q.table
+		if len(q.columns) > 0 {
+			sql += " (" + strings.Join(q.columns, ", ") + ")"
+		}
+		if q.subquery != nil && q.subquery.table != "" {
+			return sql + " " + q.subquery.sql()
+		}
+		if len(q.columns) == 0 {
+			return tableStatement(q.mode, q.table)
+		}
+		sql += " VALUES (NULL)"
+		if q.mode == node.AccessUpsert {
+			sql += " ON CONFLICT DO UPDATE SET " + sqlparser.Placeholder + " = NULL"
+		}
+		return sql
+	case node.AccessUpdate:
+		sets := []string{sqlparser.Placeholder + " = NULL"}
+		if len(q.sets) > 0 {
+			sets = q.sets
+		}
+		sql := "UPDATE " + q.table + " SET " + strings.Join(sets, ", ")
+		if len(q.from) > 0 {
+			sql += " FROM " + strings.Join(q.from, ", ")
+		}
+		return sql + where
+	case node.AccessDelete:
+		return tableStatement(q.mode, q.table) + where
+	case node.AccessTruncate:
+		return tableStatement(q.mode, q.table)
+	}
+	return ""
+}
+
+func joinClauses(joins []string) string {
+	var b strings.Builder
+	for _, join := range joins {
+		b.WriteString(" " + join)
+	}
+	return b.String()
+}
+
+// builderTerminalMethod は呼び出しがsquirrelかgoquのビルダーのチェーンを終わらせるメソッドであれば、そのメソッドを返す
+func builderTerminalMethod(info *types.Info, callExpr *ast.CallExpr) *types.Func {
+	selExpr, ok := callExpr.Fun.(*ast.SelectorExpr)
+	if !ok || info == nil || !builderTerminals[selExpr.Sel.Name] {
+		return nil
+	}
+	selection, ok := info.Selections[selExpr]
+	if !ok || selection.Kind() != types.MethodVal || builderLibrary(selection.Recv()) == "" {
+		return nil
+	}
+	method, _ := selection.Obj().(*types.Func)
+	return method
+}
+
+// builderSites はビルダーのチェーンを遡って組み立てたSQLを、チェーンを終わらせた箇所のSQLとする
+func builderSites(callExpr *ast.CallExpr, method *types.Func, sqlArgs *sqlArguments) []sqlSite {
+	instr, ok := sqlArgs.calls[callExpr.Lparen]
+	if !ok {
+		return []sqlSite{}
+	}
+	common := instr.Common()
+	if common.IsInvoke() || len(common.Args) == 0 {
+		return []sqlSite{}
+	}
+	if method.Name() == "RunWith" && chainedFurther(instr) {
+		// x.RunWith(db).Exec()はExecをチェーンの終わりとする
+		return []sqlSite{}
+	}
+	chain := &builderChain{evaluator: sqlArgs.evaluator, visited: make(map[ssa.Value]bool)}
+	query := chain.read(common.Args[0])
+	if sql := query.sql(); sql != "" {
+		return []sqlSite{{query: sql, pos: callExpr.Pos(), litPos: callExpr.Pos()}}
+	}
+	return []sqlSite{}
+}
+
+// chainedFurther はRunWithの結果に対して、さらにビルダーのメソッドが呼ばれているかを判定する
+func chainedFurther(instr ssa.CallInstruction) bool {
+	value := instr.Value()
+	if value == nil {
+		return false
+	}
+	for _, ref := range referrers(value) {
+		call, ok := ref.(ssa.CallInstruction)
+		if !ok {
+			continue
+		}
+		common := call.Common()
+		if callee := common.StaticCallee(); callee != nil && callee.Signature.Recv() != nil && len(common.Args) > 0 && common.Args[0] == value {
+			return true
+		}
+	}
+	return false
+}
+
+// builderChain はチェーンを終わらせたメソッドのレシーバから、コンストラクタまでビルダーの値を遡る
+// 遡って最初に見つけたテーブル(最後に指定されたもの)を使い、JOINや条件は分岐したチェーンのものもすべて集める
+type builderChain struct {
+	evaluator *sqlEvaluator
+	visited   map[ssa.Value]bool
+}
+
+func (c *builderChain) read(v ssa.Value) *builderQuery {
+	query := &builderQuery{}
+	c.walk(v, query)
+	if query.mode == "" {
+		query.mode = node.AccessSelect
+	}
+	return query
+}
+
+func (c *builderChain) walk(v ssa.Value, query *builderQuery) {
+	if v == nil || c.visited[v] {
+		return
+	}
+	c.visited[v] = true
+
+	switch v := v.(type) {
+	case *ssa.Phi:
+		for _, edge := range v.Edges {
+			c.walk(edge, query)
+		}
+	case *ssa.UnOp:
+		// パッケージ変数に入れたビルダー
+		if global, ok := v.X.(*ssa.Global); ok {
+			c.walk(c.evaluator.globals[global], query)
+		}
+	case *ssa.Call:
+		callee := v.Call.StaticCallee()
+		if callee == nil {
+			return
+		}
+		pkg := functionPackagePath(callee)
+		if pkg != squirrelPackage && pkg != goquPackage {
+			// ビルダーを返す関数は、その戻り値を辿る
+			if len(callee.Blocks) > 0 && builderLibrary(callee.Signature.Results()) != "" {
+				for _, block := range callee.Blocks {
+					if ret, ok := block.Instrs[len(block.Instrs)-1].(*ssa.Return); ok && len(ret.Results) == 1 {
+						c.walk(ret.Results[0], query)
+					}
+				}
+			}
+			return
+		}
+		c.step(callee, v.Call.Args, query)
+	}
+}
+
+// step はビルダーのメソッドやコンストラクタの呼び出し一つを読み取る
+func (c *builderChain) step(callee *ssa.Function, args []ssa.Value, query *builderQuery) {
+	recv := callee.Signature.Recv()
+	if recv == nil || builderLibrary(recv.Type()) == "" {
+		// sq.Select(...)、goqu.From(...)、psql.Update(...)、db.From(...)のようなコンストラクタ
+		if recv != nil {
+			args = args[1:]
+		}
+		c.root(callee, args, query)
+		return
+	}
+
+	name := callee.Name()
+	receiver := builderTypeName(recv.Type())
+	self, args := args[0], args[1:]
+	goqu := functionPackagePath(callee) == goquPackage
+	switch {
+	case goqu && receiver == "SelectDataset" && builderRootModes[name] != "" && name != "From" && name != "Select":
+		// goqu.From("orders").Update()のようなデータセットの変換
+		if query.mode == "" {
+			query.mode = builderRootModes[name]
+		}
+	case name == "From" && receiver == "UpdateBuilder":
+		query.from = append(query.from, c.stringValue(args[0]))
+	case name == "From" && goqu && receiver == "SelectDataset":
+		// SelectDataset.From(tables ...interface{})
+		if tables, ok := variadicArgs(args[0]); ok && len(tables) > 0 && query.table == "" {
+			query.table = c.table(tables[0], true)
+		}
+	case name == "From" || name == "Into" || name == "Table":
+		if query.table == "" && len(args) > 0 {
+			query.table = c.table(args[0], goqu)
+		}
+	case name == "FromSelect" && len(args) == 2:
+		if query.table == "" {
+			sub := (&builderChain{evaluator: c.evaluator, visited: c.visited}).read(args[0])
+			query.table = "(" + sub.sql() + ") AS " + c.stringValue(args[1])
+		}
+	case name == "Select" && receiver == "InsertBuilder" && len(args) == 1:
+		query.subquery = (&builderChain{evaluator: c.evaluator, visited: c.visited}).read(args[0])
+	case builderJoins[name] != "" && len(args) > 0:
+		if goqu {
+			query.joins = append(query.joins, builderJoins[name]+" "+c.table(args[0], true)+" ON TRUE")
+		} else {
+			query.joins = append(query.joins, builderJoins[name]+" "+c.stringValue(args[0]))
+		}
+	case name == "Where" && !goqu && len(args) > 0:
+		if where, ok := c.fragment(args[0]); ok {
+			query.where = append(query.where, where)
+		}
+	case name == "Columns" && !goqu && len(args) == 1:
+		if columns, ok := c.stringList(args[0]); ok && len(query.columns) == 0 {
+			query.columns = columns
+		}
+	case name == "Set" && !goqu && len(args) > 0:
+		query.sets = append(query.sets, c.stringValue(args[0])+" = NULL")
+	}
+	c.walk(self, query)
+}
+
+// root はチェーンを始めるコンストラクタから操作と最初のテーブルを読み取る
+func (c *builderChain) root(callee *ssa.Function, args []ssa.Value, query *builderQuery) {
+	mode, ok := builderRootModes[callee.Name()]
+	if !ok {
+		return
+	}
+	if query.mode == "" {
+		query.mode = mode
+	}
+	goqu := functionPackagePath(callee) == goquPackage
+	if callee.Name() == "Select" && !goqu {
+		// sq.Select("id", "name")は列を受け取る
+		if columns, ok := c.stringList(args[0]); ok && len(query.columns) == 0 {
+			query.columns = columns
+		}
+		return
+	}
+	if query.table != "" || len(args) == 0 {
+		return
+	}
+	if callee.Name() == "From" {
+		// goqu.From(table ...interface{})
+		if tables, ok := variadicArgs(args[0]); ok && len(tables) > 0 {
+			query.table = c.table(tables[0], true)
+		}
+		return
+	}
+	query.table = c.table(args[0], goqu)
+}
+
+// table はテーブルの引数をSQLのテーブル式にする
+// squirrelは"users u"のような断片をそのまま、goquは"schema.table"やgoqu.T("users")を識別子としてクォートする
+func (c *builderChain) table(v ssa.Value, goqu bool) string {
+	if !goqu {
+		return c.stringValue(v)
+	}
+	parts, alias := c.identifier(v)
+	if len(parts) == 0 {
+		return ""
+	}
+	table := strings.Join(quoteIdentifiers(parts), ".")
+	if alias != "" {
+		table += " AS " + alias
+	}
+	return table
+}
+
+// identifier はgoquのテーブルの指定("orders"、goqu.T("orders")、goqu.S("billing").Table("invoices")など)を名前の部分に分ける
+func (c *builderChain) identifier(v ssa.Value) ([]string, string) {
+	switch v := v.(type) {
+	case *ssa.MakeInterface:
+		if basic, ok := v.X.Type().Underlying().(*types.Basic); ok && basic.Kind() == types.String {
+			return strings.Split(c.stringValue(v.X), "."), ""
+		}
+		return c.identifier(v.X)
+	case *ssa.ChangeInterface:
+		return c.identifier(v.X)
+	case *ssa.Call:
+		if v.Call.IsInvoke() {
+			parts, alias := c.identifier(v.Call.Value)
+			switch v.Call.Method.Name() {
+			case "Table":
+				if len(v.Call.Args) == 1 {
+					return append(parts, c.stringValue(v.Call.Args[0])), alias
+				}
+			case "As":
+				if len(v.Call.Args) == 1 {
+					return parts, c.stringValue(v.Call.Args[0])
+				}
+			}
+			return parts, alias
+		}
+		callee := v.Call.StaticCallee()
+		if callee == nil || functionPackagePath(callee) != goquPackage || len(v.Call.Args) != 1 {
+			return []string{sqlparser.Placeholder}, ""
+		}
+		switch callee.Name() {
+		case "T", "S":
+			return []string{c.stringValue(v.Call.Args[0])}, ""
+		case "I":
+			return strings.Split(c.stringValue(v.Call.Args[0]), "."), ""
+		}
+	}
+	return []string{sqlparser.Placeholder}, ""
+}
+
+func (c *builderChain) stringValue(v ssa.Value) string {
+	return c.evaluator.partialValue(v, 0)
+}
+
+// fragment はWhere("u.id = ?", id)のようなSQLの断片を返す(sq.Eq{...}のような式は返さない)
+func (c *builderChain) fragment(v ssa.Value) (string, bool) {
+	mi, ok := v.(*ssa.MakeInterface)
+	if !ok {
+		return "", false
+	}
+	if basic, ok := mi.X.Type().Underlying().(*types.Basic); !ok || basic.Kind() != types.String {
+		return "", false
+	}
+	return c.stringValue(mi.X), true
+}
+
+// stringList は可変長の文字列の引数を評価する
+func (c *builderChain) stringList(v ssa.Value) ([]string, bool) {
+	args, ok := variadicArgs(v)
+	if !ok {
+		return nil, false
+	}
+	values := make([]string, 0, len(args))
+	for _, arg := range args {
+		if cst, ok := arg.(*ssa.Const); ok && cst.Value != nil && cst.Value.Kind() == constant.String {
+			values = append(values, constant.StringVal(cst.Value))
+			continue
+		}
+		values = append(values, c.stringValue(arg))
+	}
+	return values, true
+}
+
+// builderLibrary はsquirrelかgoquのビルダーの型であればそのパッケージパスを返す
+// 戻り値の組(*types.Tuple)は、最初の要素で判定する
+func builderLibrary(t types.Type) string {
+	if tuple, ok := t.(*types.Tuple); ok {
+		if tuple.Len() == 0 {
+			return ""
+		}
+		t = tuple.At(0).Type()
+	}
+	named, ok := derefType(t).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return ""
+	}
+	switch path := named.Obj().Pkg().Path(); {
+	case path == squirrelPackage && strings.HasSuffix(named.Obj().Name(), "Builder") && named.Obj().Name() != "StatementBuilderType":
+		return path
+	case path == goquPackage && strings.HasSuffix(named.Obj().Name(), "Dataset"):
+		return path
+	}
+	return ""
+}
+
+func builderTypeName(t types.Type) string {
+	if named, ok := derefType(t).(*types.Named); ok {
+		return named.Obj().Name()
+	}
+	return ""
+}
@@ -1,14 +1,23 @@
 package repository
 
 import (
+	"go/constant"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 
 	"goAccessViz/cmd/goAccessViz/domain/node"
+	"goAccessViz/cmd/goAccessViz/repository/sqlparser"
+
+	"golang.org/x/tools/go/packages"
 )
 
 func TestReadGraphWithTestPackage(t *testing.T) {
 	// Test reading our custom test package
-	nodes, err := ReadGraph("goAccessViz/testpkg")
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"})
 	if err != nil {
 		t.Fatalf("Failed to read graph: %v", err)
 	}
@@ -99,6 +108,241 @@ func TestExtractTablesFromSQL(t *testing.T) {
 			sql:      "SELECT * FROM users WHERE id IN (SELECT user_id FROM orders)",
 			expected: []string{"users", "orders"},
 		},
+		{
+			name:     "CTE name is not a table",
+			sql:      "WITH recent AS (SELECT * FROM orders WHERE created_at > ?) SELECT * FROM recent JOIN users ON users.id = recent.user_id",
+			expected: []string{"orders", "users"},
+		},
+		{
+			name:     "Multiple CTEs referencing each other",
+			sql:      "WITH a AS (SELECT id FROM accounts), b AS (SELECT id FROM a) SELECT * FROM b",
+			expected: []string{"accounts"},
+		},
+		{
+			name:     "Recursive CTE",
+			sql:      "WITH RECURSIVE tree AS (SELECT id, parent_id FROM categories WHERE parent_id IS NULL UNION ALL SELECT c.id, c.parent_id FROM categories c JOIN tree t ON c.parent_id = t.id) SELECT * FROM tree",
+			expected: []string{"categories"},
+		},
+		{
+			name:     "CTE shadows table only inside its scope",
+			sql:      "SELECT * FROM (WITH users AS (SELECT 1 AS id) SELECT * FROM users) x JOIN users u ON u.id = x.id",
+			expected: []string{"users"},
+		},
+		{
+			name:     "Comma joined tables",
+			sql:      "SELECT * FROM users u, orders o, products WHERE u.id = o.user_id",
+			expected: []string{"users", "orders", "products"},
+		},
+		{
+			name:     "EXTRACT is not a FROM clause",
+			sql:      "SELECT EXTRACT(YEAR FROM created_at) AS y FROM invoices",
+			expected: []string{"invoices"},
+		},
+		{
+			name:     "SUBSTRING and TRIM with FROM",
+			sql:      "SELECT SUBSTRING(name FROM 1 FOR 3), TRIM(BOTH ' ' FROM title) FROM articles",
+			expected: []string{"articles"},
+		},
+		{
+			name:     "Derived table alias is not a table",
+			sql:      "SELECT t.total FROM (SELECT user_id, SUM(amount) AS total FROM payments GROUP BY user_id) AS t",
+			expected: []string{"payments"},
+		},
+		{
+			name:     "UNION of two tables",
+			sql:      "SELECT id FROM admins UNION SELECT id FROM members ORDER BY id",
+			expected: []string{"admins", "members"},
+		},
+		{
+			name:     "Parenthesized set operation",
+			sql:      "(SELECT id FROM a1) EXCEPT (SELECT id FROM a2)",
+			expected: []string{"a1", "a2"},
+		},
+		{
+			name:     "LATERAL subquery",
+			sql:      "SELECT * FROM users u CROSS JOIN LATERAL (SELECT * FROM orders o WHERE o.user_id = u.id LIMIT 3) recent",
+			expected: []string{"users", "orders"},
+		},
+		{
+			name:     "Nested subqueries",
+			sql:      "SELECT * FROM users WHERE id IN (SELECT user_id FROM orders WHERE product_id IN (SELECT id FROM products WHERE price > (SELECT AVG(price) FROM products)))",
+			expected: []string{"users", "orders", "products"},
+		},
+		{
+			name:     "EXISTS subquery",
+			sql:      "SELECT * FROM users u WHERE NOT EXISTS (SELECT 1 FROM bans b WHERE b.user_id = u.id)",
+			expected: []string{"users", "bans"},
+		},
+		{
+			name:     "Scalar subquery in select list",
+			sql:      "SELECT u.name, (SELECT COUNT(*) FROM posts p WHERE p.user_id = u.id) AS post_count FROM users u",
+			expected: []string{"posts", "users"},
+		},
+		{
+			name:     "LEFT JOIN with USING",
+			sql:      "SELECT * FROM orders LEFT OUTER JOIN customers USING (customer_id) INNER JOIN regions r ON r.id = customers.region_id",
+			expected: []string{"orders", "customers", "regions"},
+		},
+		{
+			name:     "Parenthesized joins",
+			sql:      "SELECT * FROM (users u JOIN profiles p ON p.user_id = u.id) LEFT JOIN avatars a ON a.user_id = u.id",
+			expected: []string{"users", "profiles", "avatars"},
+		},
+		{
+			name:     "Lowercase keywords",
+			sql:      "select id from Users where name like ?",
+			expected: []string{"users"},
+		},
+		{
+			name:     "FROM inside string literal is ignored",
+			sql:      "SELECT 'copied FROM legacy' AS note FROM notes",
+			expected: []string{"notes"},
+		},
+		{
+			name:     "FROM inside comment is ignored",
+			sql:      "SELECT id -- FROM ghosts\nFROM visible /* JOIN hidden */",
+			expected: []string{"visible"},
+		},
+		{
+			name:     "Table functions are not tables",
+			sql:      "SELECT * FROM generate_series(1, 10) AS g(n) JOIN slots s ON s.n = g.n",
+			expected: []string{"slots"},
+		},
+		{
+			name:     "Schema-qualified table",
+			sql:      "SELECT * FROM public.users",
+			expected: []string{"public.users"},
+		},
+		{
+			name:     "Quoted identifiers",
+			sql:      "SELECT * FROM \"Order Items\" oi JOIN `legacy_users` lu ON lu.id = oi.user_id",
+			expected: []string{"Order Items", "legacy_users"},
+		},
+		{
+			name:     "Quoted names keep their case",
+			sql:      "SELECT * FROM \"Users\" JOIN users ON users.id = \"Users\".id JOIN USERS ON TRUE",
+			expected: []string{"Users", "users"},
+		},
+		{
+			name:     "Backtick-quoted reserved word",
+			sql:      "SELECT * FROM `order` o JOIN shop.`Line` l ON l.order_id = o.id",
+			expected: []string{"order", "shop.Line"},
+		},
+		{
+			name:     "Quoted schema and table",
+			sql:      "UPDATE \"Billing\".\"Invoices\" SET paid = true",
+			expected: []string{"Billing.Invoices"},
+		},
+		{
+			name:     "Unquoted schema is case-folded",
+			sql:      "INSERT INTO Audit.Events (id) VALUES (1)",
+			expected: []string{"audit.events"},
+		},
+		{
+			name:     "Catalog qualifier is ignored",
+			sql:      "SELECT * FROM warehouse.sales.orders",
+			expected: []string{"sales.orders"},
+		},
+		{
+			name:     "Same table in different schemas",
+			sql:      "SELECT * FROM public.users JOIN auth.users ON auth.users.id = public.users.id",
+			expected: []string{"public.users", "auth.users"},
+		},
+		{
+			name:     "INSERT SELECT",
+			sql:      "INSERT INTO archive (id, body) SELECT id, body FROM messages WHERE created_at < $1",
+			expected: []string{"archive", "messages"},
+		},
+		{
+			name:     "INSERT with ON CONFLICT",
+			sql:      "INSERT INTO counters (name, value) VALUES ($1, 1) ON CONFLICT (name) DO UPDATE SET value = counters.value + 1",
+			expected: []string{"counters"},
+		},
+		{
+			name:     "INSERT with ON DUPLICATE KEY UPDATE",
+			sql:      "INSERT INTO stats (k, v) VALUES (?, ?) ON DUPLICATE KEY UPDATE v = VALUES(v)",
+			expected: []string{"stats"},
+		},
+		{
+			name:     "REPLACE INTO",
+			sql:      "REPLACE INTO caches (k, v) VALUES (?, ?)",
+			expected: []string{"caches"},
+		},
+		{
+			name:     "UPDATE with FROM",
+			sql:      "UPDATE accounts a SET balance = a.balance - t.amount FROM transfers t WHERE t.account_id = a.id",
+			expected: []string{"accounts", "transfers"},
+		},
+		{
+			name:     "UPDATE with JOIN",
+			sql:      "UPDATE orders o JOIN customers c ON c.id = o.customer_id SET o.status = 'vip' WHERE c.tier = 'gold'",
+			expected: []string{"orders", "customers"},
+		},
+		{
+			name:     "UPDATE with subquery",
+			sql:      "UPDATE users SET rank = (SELECT COUNT(*) FROM scores WHERE scores.user_id = users.id)",
+			expected: []string{"users", "scores"},
+		},
+		{
+			name:     "DELETE with USING",
+			sql:      "DELETE FROM sessions s USING users u WHERE s.user_id = u.id AND u.disabled",
+			expected: []string{"sessions", "users"},
+		},
+		{
+			name:     "Multi-table DELETE with aliases",
+			sql:      "DELETE o FROM orders o JOIN customers c ON c.id = o.customer_id WHERE c.deleted = 1",
+			expected: []string{"orders", "customers"},
+		},
+		{
+			name:     "DELETE with RETURNING",
+			sql:      "DELETE FROM jobs WHERE id = $1 RETURNING id",
+			expected: []string{"jobs"},
+		},
+		{
+			name:     "Data-modifying CTE",
+			sql:      "WITH moved AS (DELETE FROM queue WHERE id = $1 RETURNING *) INSERT INTO done SELECT * FROM moved",
+			expected: []string{"queue", "done"},
+		},
+		{
+			name:     "TRUNCATE",
+			sql:      "TRUNCATE TABLE logs, events RESTART IDENTITY",
+			expected: []string{"logs", "events"},
+		},
+		{
+			name:     "MERGE",
+			sql:      "MERGE INTO inventory i USING shipments s ON s.item_id = i.item_id WHEN MATCHED THEN UPDATE SET qty = i.qty + s.qty WHEN NOT MATCHED THEN INSERT (item_id, qty) VALUES (s.item_id, s.qty)",
+			expected: []string{"inventory", "shipments"},
+		},
+		{
+			name:     "Multiple statements",
+			sql:      "SELECT * FROM a_table; DELETE FROM b_table",
+			expected: []string{"a_table", "b_table"},
+		},
+		{
+			name:     "CASE expression and casts",
+			sql:      "SELECT CASE WHEN amount::numeric > 10 THEN 'big' ELSE 'small' END size FROM charges",
+			expected: []string{"charges"},
+		},
+		{
+			name:     "Window function",
+			sql:      "SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY created_at DESC) FROM events",
+			expected: []string{"events"},
+		},
+		{
+			name:     "IS DISTINCT FROM",
+			sql:      "SELECT * FROM pairs WHERE a IS DISTINCT FROM b",
+			expected: []string{"pairs"},
+		},
+		{
+			name:     "Locking clause",
+			sql:      "SELECT * FROM tickets WHERE id = ? FOR UPDATE SKIP LOCKED",
+			expected: []string{"tickets"},
+		},
+		{
+			name:     "Not SQL",
+			sql:      "hello world",
+			expected: []string{},
+		},
 	}
 
 	for _, tt := range tests {
@@ -117,6 +361,19 @@ func TestExtractTablesFromSQL(t *testing.T) {
 	}
 }
 
+func TestExtractTableAccessesWithDefaultSchema(t *testing.T) {
+	accesses := extractTableAccessesFromSQL("SELECT * FROM users JOIN auth.sessions s ON s.user_id = users.id", "public")
+	if len(accesses) != 2 {
+		t.Fatalf("Expected 2 table accesses, got %d", len(accesses))
+	}
+	if accesses[0].table != (tableIdentifier{schema: "public", name: "users"}) {
+		t.Errorf("Expected unqualified table in the default schema, got %+v", accesses[0].table)
+	}
+	if accesses[1].table != (tableIdentifier{schema: "auth", name: "sessions"}) {
+		t.Errorf("Expected explicit schema to win over the default, got %+v", accesses[1].table)
+	}
+}
+
 func TestDetectSQLStrings(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -250,7 +507,7 @@ func TestCreateDBTableNodes(t *testing.T) {
 		"INSERT INTO comments (post_id, content) VALUES (?, ?)",
 	}
 
-	dbNodesMap := createDBTableNodesMap(sqlStrings)
+	dbNodesMap := createDBTableNodesMap(sqlStrings, "")
 
 	expectedTables := map[string]bool{"users": true, "posts": true, "comments": true}
 	if len(dbNodesMap) != len(expectedTables) {
@@ -269,10 +526,48 @@ func TestCreateDBTableNodes(t *testing.T) {
 	}
 }
 
+func TestReadGraphUnquotesQuotedIdentifiersInGoLiterals(t *testing.T) {
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"})
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+
+	tests := []struct {
+		function string
+		table    string
+	}{
+		{"goAccessViz/testpkg.ListQuotedUsers", "Users"},
+		{"goAccessViz/testpkg.ListQuotedAccounts", "Accounts"},
+		// The literal is used directly because strings.TrimSpace is not evaluated
+		{"goAccessViz/testpkg.ListQuotedLedgers", "Ledger Lines"},
+	}
+	for _, tt := range tests {
+		fn := findNodeByLabel(nodes, tt.function)
+		if fn == nil {
+			t.Fatalf("Expected node %s", tt.function)
+		}
+		if tables := tablesOf(fn); len(tables) != 1 || !tables[tt.table] {
+			t.Errorf("Expected %s to access %s, got %v", tt.function, tt.table, tables)
+		}
+	}
+}
+
+func TestCreateDBTableNodesKeepsQuotedDotsApart(t *testing.T) {
+	dbNodesMap := createDBTableNodesMap([]string{`SELECT * FROM "a.b"`, "SELECT * FROM a.b"}, "")
+	if len(dbNodesMap) != 2 {
+		t.Fatalf("Expected the quoted table \"a.b\" and the table b in schema a to be separate nodes, got %d", len(dbNodesMap))
+	}
+	for _, table := range dbNodesMap {
+		if table.GetTableName() == "a.b" && table.GetSchema() != "" {
+			t.Errorf("Expected \"a.b\" to have no schema, got %s", table.GetSchema())
+		}
+	}
+}
+
 func TestReadGraphWithSQLAnalysis(t *testing.T) {
 	// This test will verify that ReadGraph includes SQL table analysis
 	// In the new implementation, DB tables are children of functions, not top-level nodes
-	nodes, err := ReadGraph("goAccessViz/testpkg")
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"})
 	if err != nil {
 		t.Fatalf("Failed to read graph with SQL analysis: %v", err)
 	}
@@ -323,7 +618,7 @@ func TestReadGraphWithSQLAnalysis(t *testing.T) {
 
 func TestFunctionToTableRelationships(t *testing.T) {
 	// Test that functions that query SQL tables have those tables as children
-	nodes, err := ReadGraph("goAccessViz/testpkg")
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"})
 	if err != nil {
 		t.Fatalf("Failed to read graph: %v", err)
 	}
@@ -421,3 +716,1232 @@ func TestFunctionToTableRelationships(t *testing.T) {
 		t.Error("Could not find GetUserPosts function")
 	}
 }
+
+func TestReadGraphWithCallGraphAlgorithms(t *testing.T) {
+	algorithms := []CallGraphAlgorithm{CHA, RTA, VTA, Static}
+
+	for _, algorithm := range algorithms {
+		t.Run(string(algorithm), func(t *testing.T) {
+			nodes, err := ReadGraph([]string{"goAccessViz/testpkg"}, WithCallGraphAlgorithm(algorithm))
+			if err != nil {
+				t.Fatalf("Failed to read graph: %v", err)
+			}
+
+			var functionA *node.FunctionTrackedEntity
+			for _, n := range nodes {
+				fnNode, ok := n.(*node.FunctionTrackedEntity)
+				if !ok {
+					continue
+				}
+				if fnNode.GetAlgorithm() != string(algorithm) {
+					t.Errorf("Expected node %s to be produced by %s, got %s", fnNode.GetLabel(), algorithm, fnNode.GetAlgorithm())
+				}
+				if fnNode.GetLabel() == "goAccessViz/testpkg.FunctionA" {
+					functionA = fnNode
+				}
+			}
+
+			if functionA == nil {
+				t.Fatal("Could not find FunctionA")
+			}
+
+			callsFunctionB := false
+			for _, edge := range functionA.GetEdges() {
+				if edge.GetAlgorithm() != string(algorithm) {
+					t.Errorf("Expected edge to be produced by %s, got %s", algorithm, edge.GetAlgorithm())
+				}
+				if edge.GetChild().GetLabel() == "goAccessViz/testpkg.FunctionB" {
+					callsFunctionB = true
+				}
+			}
+			if !callsFunctionB {
+				t.Error("Expected FunctionA to call FunctionB")
+			}
+		})
+	}
+}
+
+func TestReadGraphWithRTAEntryPoints(t *testing.T) {
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"}, WithCallGraphAlgorithm(RTA), WithEntryPoints("goAccessViz/testpkg.FunctionB"))
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+
+	for _, n := range nodes {
+		if n.GetLabel() == "goAccessViz/testpkg.FunctionA" && len(n.GetChildren()) > 0 {
+			t.Error("FunctionA is not reachable from FunctionB and should have no call edges")
+		}
+	}
+
+	_, err = ReadGraph([]string{"goAccessViz/testpkg"}, WithCallGraphAlgorithm(RTA), WithEntryPoints("goAccessViz/testpkg.Missing"))
+	if err == nil {
+		t.Error("Expected an error for an unknown entry point")
+	}
+}
+
+func TestParseCallGraphAlgorithm(t *testing.T) {
+	for _, name := range []string{"cha", "rta", "vta", "static"} {
+		if _, err := ParseCallGraphAlgorithm(name); err != nil {
+			t.Errorf("Expected %s to be accepted, got %v", name, err)
+		}
+	}
+	if _, err := ParseCallGraphAlgorithm("pointer"); err == nil {
+		t.Error("Expected unknown algorithm to be rejected")
+	}
+}
+
+func TestReadGraphWithRecursiveFunctions(t *testing.T) {
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"})
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+
+	for _, n := range nodes {
+		if n.GetLabel() != "goAccessViz/testpkg.Factorial" {
+			continue
+		}
+		for _, child := range n.GetChildren() {
+			if child == n {
+				return
+			}
+		}
+	}
+	t.Error("Expected Factorial to have itself as a child")
+}
+
+func TestFunctionTableRelationshipsForSameNamedMethods(t *testing.T) {
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"})
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+
+	expected := map[string]string{
+		"(*goAccessViz/testpkg.UserRepository).Get":   "accounts",
+		"(goAccessViz/testpkg.CommentRepository).Get": "comments",
+	}
+	for label, expectedTable := range expected {
+		var methodNode node.TrackedEntity
+		for _, n := range nodes {
+			if n.GetLabel() == label {
+				methodNode = n
+				break
+			}
+		}
+		if methodNode == nil {
+			t.Errorf("Could not find method %s", label)
+			continue
+		}
+
+		var tables []string
+		for _, child := range methodNode.GetChildren() {
+			if dbNode, ok := child.(*node.DatabaseTableTrackedEntity); ok {
+				tables = append(tables, dbNode.GetLabel())
+			}
+		}
+		if len(tables) != 1 || tables[0] != expectedTable {
+			t.Errorf("Expected %s to access only %s, got %v", label, expectedTable, tables)
+		}
+	}
+}
+
+func tablesOf(n node.TrackedEntity) map[string]bool {
+	tables := make(map[string]bool)
+	for _, child := range n.GetChildren() {
+		if dbNode, ok := child.(*node.DatabaseTableTrackedEntity); ok {
+			tables[dbNode.GetLabel()] = true
+		}
+	}
+	return tables
+}
+
+func findNodeByLabel(nodes []node.TrackedEntity, label string) node.TrackedEntity {
+	for _, n := range nodes {
+		if n.GetLabel() == label {
+			return n
+		}
+	}
+	return nil
+}
+
+func TestSQLInClosuresIsAttributedToClosure(t *testing.T) {
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"})
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+
+	outer := findNodeByLabel(nodes, "goAccessViz/testpkg.LoadDashboard")
+	onceFunc := findNodeByLabel(nodes, "goAccessViz/testpkg.LoadDashboard$1")
+	deferredFunc := findNodeByLabel(nodes, "goAccessViz/testpkg.LoadDashboard$2")
+	if outer == nil || onceFunc == nil || deferredFunc == nil {
+		t.Fatal("Expected LoadDashboard and its closures to be nodes")
+	}
+
+	if len(tablesOf(outer)) != 0 {
+		t.Errorf("Expected LoadDashboard to access no tables directly, got %v", tablesOf(outer))
+	}
+	if !tablesOf(onceFunc)["dashboards"] {
+		t.Error("Expected LoadDashboard$1 to access dashboards")
+	}
+	if !tablesOf(deferredFunc)["sessions"] {
+		t.Error("Expected LoadDashboard$2 to access sessions")
+	}
+
+	children := make(map[node.TrackedEntity]bool)
+	for _, child := range outer.GetChildren() {
+		children[child] = true
+	}
+	if !children[onceFunc] || !children[deferredFunc] {
+		t.Error("Expected closures to be children of LoadDashboard")
+	}
+}
+
+func TestSQLInClosuresWithFoldClosures(t *testing.T) {
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"}, WithFoldClosures(true))
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+
+	if findNodeByLabel(nodes, "goAccessViz/testpkg.LoadDashboard$1") != nil {
+		t.Error("Expected closures to be folded into LoadDashboard")
+	}
+	outer := findNodeByLabel(nodes, "goAccessViz/testpkg.LoadDashboard")
+	if outer == nil {
+		t.Fatal("Could not find LoadDashboard")
+	}
+	tables := tablesOf(outer)
+	if !tables["dashboards"] || !tables["sessions"] {
+		t.Errorf("Expected LoadDashboard to access dashboards and sessions, got %v", tables)
+	}
+}
+
+func TestReadGraphKeepsOnlyMainModuleByDefault(t *testing.T) {
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"})
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+
+	for _, n := range nodes {
+		for _, child := range append([]node.TrackedEntity{n}, n.GetChildren()...) {
+			if _, ok := child.(*node.FunctionTrackedEntity); ok && !strings.Contains(child.GetLabel(), "goAccessViz/") {
+				t.Errorf("Expected only main module functions, got %s", child.GetLabel())
+			}
+		}
+	}
+}
+
+func TestReadGraphWithCollapsedBoundary(t *testing.T) {
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"}, WithBoundaryMode(BoundaryCollapse))
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+
+	getUser := findNodeByLabel(nodes, "goAccessViz/testpkg.GetUser")
+	if getUser == nil {
+		t.Fatal("Could not find GetUser")
+	}
+	boundaries := 0
+	for _, child := range getUser.GetChildren() {
+		if pkgNode, ok := child.(*node.PackageTrackedEntity); ok {
+			boundaries++
+			if pkgNode.GetLabel() != "github.com/jmoiron/sqlx" {
+				t.Errorf("Unexpected boundary package %s", pkgNode.GetLabel())
+			}
+		}
+	}
+	if boundaries != 1 {
+		t.Errorf("Expected one boundary node for sqlx, got %d", boundaries)
+	}
+}
+
+func TestReadGraphWithIncludeAndExcludePatterns(t *testing.T) {
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"}, WithIncludePackages("goAccessViz/...", "github.com/jmoiron/*"))
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+	if findNodeByLabel(nodes, "(*github.com/jmoiron/sqlx.DB).Get") == nil {
+		t.Error("Expected included sqlx functions to be nodes")
+	}
+
+	nodes, err = ReadGraph([]string{"goAccessViz/testpkg"}, WithExcludePackages("goAccessViz/testpkg"))
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+	if len(nodes) != 0 {
+		t.Errorf("Expected excluded package to produce no nodes, got %d", len(nodes))
+	}
+}
+
+func TestMatchPackagePattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		pkgPath  string
+		expected bool
+	}{
+		{"goAccessViz/...", "goAccessViz", true},
+		{"goAccessViz/...", "goAccessViz/testpkg", true},
+		{"goAccessViz/...", "goAccessVizExtra/testpkg", false},
+		{"github.com/*/sqlx", "github.com/jmoiron/sqlx", true},
+		{"github.com/*", "github.com/jmoiron/sqlx", false},
+		{"fmt", "fmt", true},
+	}
+
+	for _, tt := range tests {
+		if actual := matchPackagePattern(tt.pattern, tt.pkgPath); actual != tt.expected {
+			t.Errorf("matchPackagePattern(%q, %q) = %v, expected %v", tt.pattern, tt.pkgPath, actual, tt.expected)
+		}
+	}
+}
+
+func TestReadGraphWithMultiplePatterns(t *testing.T) {
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg", "goAccessViz/cmd/goAccessViz/domain/..."})
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+
+	packagePaths := make(map[string]bool)
+	for _, n := range nodes {
+		if fnNode, ok := n.(*node.FunctionTrackedEntity); ok {
+			packagePaths[fnNode.GetPackagePath()] = true
+		}
+	}
+
+	for _, expected := range []string{"goAccessViz/testpkg", "goAccessViz/cmd/goAccessViz/domain/node"} {
+		if !packagePaths[expected] {
+			t.Errorf("Expected functions from %s, got packages %v", expected, packagePaths)
+		}
+	}
+
+	functionA := findNodeByLabel(nodes, "goAccessViz/testpkg.FunctionA")
+	if functionA == nil || functionA.(*node.FunctionTrackedEntity).GetPackagePath() != "goAccessViz/testpkg" {
+		t.Error("Expected FunctionA to belong to goAccessViz/testpkg")
+	}
+}
+
+func TestReadGraphRecordsSourcePositions(t *testing.T) {
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"})
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+
+	getUser, ok := findNodeByLabel(nodes, "goAccessViz/testpkg.GetUser").(*node.FunctionTrackedEntity)
+	if !ok {
+		t.Fatal("Could not find GetUser")
+	}
+	position := getUser.GetPosition()
+	if !strings.HasSuffix(position.File, "testpkg/main.go") || position.Line == 0 || position.Column == 0 {
+		t.Errorf("Expected GetUser to have a position in testpkg/main.go, got %v", position)
+	}
+
+	for _, edge := range getUser.GetEdges() {
+		if edge.GetChild().GetLabel() != "users" {
+			continue
+		}
+		positions := edge.GetPositions()
+		if len(positions) != 1 {
+			t.Fatalf("Expected one SQL site for users, got %v", positions)
+		}
+		if positions[0].File != position.File || positions[0].Line <= position.Line {
+			t.Errorf("Expected SQL site after the function declaration, got %v", positions[0])
+		}
+		return
+	}
+	t.Error("Expected GetUser to access users")
+}
+
+func TestReadGraphReportsDiagnostics(t *testing.T) {
+	var diagnostics Diagnostics
+	nodes, err := ReadGraph([]string{"./testdata/broken", "goAccessViz/testpkg"}, WithDiagnostics(&diagnostics))
+	if err != nil {
+		t.Fatalf("Expected non-strict mode to render a partial graph, got %v", err)
+	}
+	if findNodeByLabel(nodes, "goAccessViz/testpkg.FunctionA") == nil {
+		t.Error("Expected packages without errors to still be rendered")
+	}
+
+	if !diagnostics.HasErrors() {
+		t.Fatal("Expected type errors to be reported")
+	}
+	if diagnostics.Diagnostics[0].Kind != DiagnosticType {
+		t.Errorf("Expected a type error, got %v", diagnostics.Diagnostics[0])
+	}
+	if len(diagnostics.IncompletePackages) != 1 || !strings.HasSuffix(diagnostics.IncompletePackages[0], "testdata/broken") {
+		t.Errorf("Expected only the broken package to be incomplete, got %v", diagnostics.IncompletePackages)
+	}
+
+	for _, n := range nodes {
+		if fnNode, ok := n.(*node.FunctionTrackedEntity); ok && fnNode.IsIncomplete() {
+			t.Errorf("Expected %s in a clean package not to be marked as incomplete", fnNode.GetLabel())
+		}
+	}
+}
+
+func TestReadGraphStrictFailsOnDiagnostics(t *testing.T) {
+	_, err := ReadGraph([]string{"./testdata/broken"}, WithStrict(true))
+	if _, ok := err.(*DiagnosticsError); !ok {
+		t.Fatalf("Expected a DiagnosticsError, got %v", err)
+	}
+
+	_, err = ReadGraph([]string{"goAccessViz/testpkg"}, WithStrict(true))
+	if err != nil {
+		t.Errorf("Expected a clean package to pass strict mode, got %v", err)
+	}
+}
+
+func TestReadGraphReportsUnknownPattern(t *testing.T) {
+	var diagnostics Diagnostics
+	if _, err := ReadGraph([]string{"goAccessViz/doesnotexist"}, WithDiagnostics(&diagnostics)); err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+	if !diagnostics.HasErrors() {
+		t.Error("Expected a typo in the pattern to be reported")
+	}
+}
+
+func findEdge(n node.TrackedEntity, childLabel string) *node.Edge {
+	for _, edge := range n.GetEdges() {
+		if edge.GetChild().GetLabel() == childLabel {
+			return edge
+		}
+	}
+	return nil
+}
+
+func TestReadGraphRecordsCallEdgeMetadata(t *testing.T) {
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"})
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+
+	dispatch := findNodeByLabel(nodes, "goAccessViz/testpkg.Dispatch")
+	if dispatch == nil {
+		t.Fatal("Could not find Dispatch")
+	}
+
+	notify := findEdge(dispatch, "goAccessViz/testpkg.Notify")
+	if notify == nil || notify.GetCallSites() != 2 || notify.IsDynamic() {
+		t.Errorf("Expected one static edge with 2 call sites to Notify, got %+v", notify)
+	}
+	duplicates := 0
+	for _, edge := range dispatch.GetEdges() {
+		if edge.GetChild().GetLabel() == "goAccessViz/testpkg.Notify" {
+			duplicates++
+		}
+	}
+	if duplicates != 1 {
+		t.Errorf("Expected repeated calls to be merged into one edge, got %d", duplicates)
+	}
+
+	if goEdge := findEdge(dispatch, "goAccessViz/testpkg.FunctionC"); goEdge == nil || !goEdge.IsGoroutine() {
+		t.Error("Expected the call to FunctionC to be a goroutine launch")
+	}
+	if deferEdge := findEdge(dispatch, "goAccessViz/testpkg.FunctionB"); deferEdge == nil || !deferEdge.IsDeferred() {
+		t.Error("Expected the call to FunctionB to be deferred")
+	}
+
+	invoke := findEdge(dispatch, "(goAccessViz/testpkg.MailNotifier).Notify")
+	if invoke == nil || len(invoke.GetDispatchKinds()) != 1 || invoke.GetDispatchKinds()[0] != node.DispatchInterface {
+		t.Errorf("Expected an interface call to MailNotifier.Notify, got %+v", invoke)
+	}
+
+	runHook := findNodeByLabel(nodes, "goAccessViz/testpkg.RunHook")
+	if runHook == nil {
+		t.Fatal("Could not find RunHook")
+	}
+	hook := findEdge(runHook, "goAccessViz/testpkg.auditHook")
+	if hook == nil || len(hook.GetDispatchKinds()) != 1 || hook.GetDispatchKinds()[0] != node.DispatchFunctionValue || !hook.IsDynamic() {
+		t.Errorf("Expected a dynamic function-value call to auditHook, got %+v", hook)
+	}
+}
+
+func TestReadGraphRecordsAccessModes(t *testing.T) {
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"})
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+
+	tests := []struct {
+		function string
+		table    string
+		expected node.AccessMode
+	}{
+		{"goAccessViz/testpkg.GetUser", "users", node.AccessSelect},
+		{"goAccessViz/testpkg.CreatePost", "posts", node.AccessInsert},
+		{"goAccessViz/testpkg.UpdateOrder", "orders", node.AccessUpdate},
+		{"goAccessViz/testpkg.DeletePost", "posts", node.AccessDelete},
+	}
+	for _, tt := range tests {
+		fn := findNodeByLabel(nodes, tt.function)
+		if fn == nil {
+			t.Fatalf("Function %s not found", tt.function)
+		}
+		edge := findEdge(fn, tt.table)
+		if edge == nil {
+			t.Fatalf("Expected %s to access %s", tt.function, tt.table)
+		}
+		modes := edge.GetAccessModes()
+		if len(modes) != 1 || modes[0] != tt.expected {
+			t.Errorf("Expected %s -> %s to be %s, got %v", tt.function, tt.table, tt.expected, modes)
+		}
+	}
+}
+
+func TestReadGraphFiltersAccessModes(t *testing.T) {
+	writeModes, err := ParseAccessModes("write")
+	if err != nil {
+		t.Fatalf("Failed to parse access modes: %v", err)
+	}
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"}, WithAccessModes(writeModes...))
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+
+	if tables := tablesOf(findNodeByLabel(nodes, "goAccessViz/testpkg.GetUser")); len(tables) != 0 {
+		t.Errorf("Expected read-only GetUser to have no table edges, got %v", tables)
+	}
+	if tables := tablesOf(findNodeByLabel(nodes, "goAccessViz/testpkg.UpdateOrder")); !tables["orders"] {
+		t.Errorf("Expected UpdateOrder to keep its write to orders, got %v", tables)
+	}
+}
+
+func TestParseAccessModes(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected []node.AccessMode
+	}{
+		{"all", nil},
+		{"read", []node.AccessMode{node.AccessSelect}},
+		{"write", []node.AccessMode{node.AccessInsert, node.AccessUpdate, node.AccessDelete, node.AccessUpsert, node.AccessTruncate}},
+		{"insert, Delete", []node.AccessMode{node.AccessInsert, node.AccessDelete}},
+	}
+	for _, tt := range tests {
+		modes, err := ParseAccessModes(tt.value)
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %v", tt.value, err)
+			continue
+		}
+		if len(modes) != len(tt.expected) {
+			t.Errorf("Expected %v for %q, got %v", tt.expected, tt.value, modes)
+			continue
+		}
+		for i := range modes {
+			if modes[i] != tt.expected[i] {
+				t.Errorf("Expected %v for %q, got %v", tt.expected, tt.value, modes)
+			}
+		}
+	}
+	if _, err := ParseAccessModes("select,merge"); err == nil {
+		t.Error("Expected error for unknown access mode")
+	}
+}
+
+func TestReadGraphWithDefaultSchema(t *testing.T) {
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"}, WithDefaultSchema("public"))
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+
+	getUser := findNodeByLabel(nodes, "goAccessViz/testpkg.GetUser")
+	edge := findEdge(getUser, "public.users")
+	if edge == nil {
+		t.Fatalf("Expected GetUser to access public.users, got %v", tablesOf(getUser))
+	}
+	table := edge.GetChild().(*node.DatabaseTableTrackedEntity)
+	if table.GetSchema() != "public" || table.GetTableName() != "users" {
+		t.Errorf("Expected schema 'public' and table 'users', got '%s' and '%s'", table.GetSchema(), table.GetTableName())
+	}
+}
+
+func TestReadGraphTracksColumns(t *testing.T) {
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"}, WithColumns(true))
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+
+	updateOrder := findNodeByLabel(nodes, "goAccessViz/testpkg.UpdateOrder")
+	status := findEdge(updateOrder, "orders.status")
+	if status == nil {
+		t.Fatalf("Expected UpdateOrder to access orders.status")
+	}
+	if modes := status.GetAccessModes(); len(modes) != 1 || modes[0] != node.AccessUpdate {
+		t.Errorf("Expected orders.status to be updated, got %v", modes)
+	}
+	if id := findEdge(updateOrder, "orders.id"); id == nil || id.IsWrite() {
+		t.Errorf("Expected UpdateOrder to read orders.id")
+	}
+
+	getUser := findNodeByLabel(nodes, "goAccessViz/testpkg.GetUser")
+	all := findEdge(getUser, "users.*")
+	if all == nil {
+		t.Fatalf("Expected SELECT * to link GetUser to users.*")
+	}
+	if !all.GetChild().(*node.ColumnTrackedEntity).IsAllColumns() {
+		t.Error("Expected users.* to be the all-columns marker")
+	}
+
+	// Columns hang off their table, with the all-columns marker first
+	table := findEdge(getUser, "users").GetChild()
+	var columns []string
+	for _, child := range table.GetChildren() {
+		columns = append(columns, child.(*node.ColumnTrackedEntity).GetColumnName())
+	}
+	if len(columns) == 0 || columns[0] != node.AllColumns {
+		t.Errorf("Expected users columns to start with '*', got %v", columns)
+	}
+}
+
+func TestReadGraphWithoutColumns(t *testing.T) {
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"})
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+
+	updateOrder := findNodeByLabel(nodes, "goAccessViz/testpkg.UpdateOrder")
+	if findEdge(updateOrder, "orders.status") != nil {
+		t.Error("Expected no column edges unless columns are enabled")
+	}
+}
+
+func TestReadGraphResolvesSQLFromConstantsAndVariables(t *testing.T) {
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg", "goAccessViz/testpkg/queries"})
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+
+	tests := []struct {
+		function string
+		tables   []string
+	}{
+		{"goAccessViz/testpkg.GetProduct", []string{"products"}},
+		{"goAccessViz/testpkg.ListProducts", []string{"product_catalog"}},
+		{"goAccessViz/testpkg.ArchiveProducts", []string{"product_archive", "products"}},
+		{"goAccessViz/testpkg.GetInvoice", []string{"invoices"}},
+		{"goAccessViz/testpkg.DeleteInvoice", []string{"invoices"}},
+	}
+	for _, tt := range tests {
+		fn := findNodeByLabel(nodes, tt.function)
+		if fn == nil {
+			t.Fatalf("Expected node %s", tt.function)
+		}
+		tables := tablesOf(fn)
+		for _, table := range tt.tables {
+			if !tables[table] {
+				t.Errorf("Expected %s to access %s, got %v", tt.function, table, tables)
+			}
+		}
+	}
+
+	deleteInvoice := findEdge(findNodeByLabel(nodes, "goAccessViz/testpkg.DeleteInvoice"), "invoices")
+	if modes := deleteInvoice.GetAccessModes(); len(modes) != 1 || modes[0] != node.AccessDelete {
+		t.Errorf("Expected DeleteInvoice to delete from invoices, got %v", modes)
+	}
+
+	if tables := tablesOf(findNodeByLabel(nodes, "goAccessViz/testpkg.ListAccounts")); tables["accounts"] && !tables["legacy_accounts"] {
+		t.Errorf("Expected a reassigned package variable not to resolve to its initial value, got %v", tables)
+	}
+}
+
+func TestReadGraphEvaluatesDynamicSQL(t *testing.T) {
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"})
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+
+	tests := []struct {
+		function string
+		table    string
+		dynamic  bool
+	}{
+		{"goAccessViz/testpkg.GetLedgerEntry", "ledger", false},
+		{"goAccessViz/testpkg.WriteAudit", "audit_%s", true},
+		{"goAccessViz/testpkg.CountInvoices", "ledger_archive", false},
+		{"goAccessViz/testpkg.PurgeShard", "events_%s", true},
+		// Writes in either branch of an if/else are not concatenated
+		{"goAccessViz/testpkg.ListOrderHistory", "%s", true},
+	}
+	for _, tt := range tests {
+		fn := findNodeByLabel(nodes, tt.function)
+		if fn == nil {
+			t.Fatalf("Expected node %s", tt.function)
+		}
+		edge := findEdge(fn, tt.table)
+		if edge == nil {
+			t.Errorf("Expected %s to access %s, got %v", tt.function, tt.table, tablesOf(fn))
+			continue
+		}
+		if table := edge.GetChild().(*node.DatabaseTableTrackedEntity); table.IsDynamic() != tt.dynamic {
+			t.Errorf("Expected %s dynamic=%v", tt.table, tt.dynamic)
+		}
+		if len(tablesOf(fn)) != 1 {
+			t.Errorf("Expected format verbs not to become tables, got %v", tablesOf(fn))
+		}
+	}
+
+	// Explicit argument indexes reuse the same argument
+	if tables := tablesOf(findNodeByLabel(nodes, "goAccessViz/testpkg.CopyLedger")); len(tables) != 2 || !tables["ledger"] || !tables["ledger_log"] {
+		t.Errorf("Expected CopyLedger to access ledger and ledger_log, got %v", tables)
+	}
+}
+
+func TestFormatString(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"SELECT * FROM %s WHERE id = %d", "SELECT * FROM # WHERE id = #"},
+		{"SELECT * FROM t WHERE name LIKE 'a%' AND p = 100%%", "SELECT * FROM t WHERE name LIKE 'a%' AND p = 100%"},
+		{"audit_%-10v", "audit_#"},
+		{"INSERT INTO %[1]s_log SELECT * FROM %[1]s", "INSERT INTO #_log SELECT * FROM #"},
+		{"SELECT * FROM t WHERE code LIKE '[a-z]%'", "SELECT * FROM t WHERE code LIKE '[a-z]%'"},
+	}
+	for _, tt := range tests {
+		actual := formatString(tt.format, func(string, byte) string { return "#" })
+		if actual != tt.expected {
+			t.Errorf("formatString(%q) = %q, expected %q", tt.format, actual, tt.expected)
+		}
+	}
+}
+
+func TestFormatArguments(t *testing.T) {
+	tests := []struct {
+		format   string
+		count    int
+		expected string
+	}{
+		{"%s.%s", 2, "%s(0).%s(1)"},
+		{"%[2]s.%[1]s", 2, "%s(1).%s(0)"},
+		{"%[2]s %s %s", 3, "%s(1) %s(2) #"},
+		{"%[3]s", 2, "#"},
+		{"%*d %s", 3, "# #"},
+		{"%*d %[3]s", 3, "# %s(2)"},
+		{"%s %s", 1, "%s(0) #"},
+	}
+	for _, tt := range tests {
+		actual := formatArguments(tt.format, tt.count, func(spec string, verb byte, index int) string {
+			return spec + "(" + strconv.Itoa(index) + ")"
+		})
+		actual = strings.ReplaceAll(actual, sqlparser.Placeholder, "#")
+		if actual != tt.expected {
+			t.Errorf("formatArguments(%q, %d) = %q, expected %q", tt.format, tt.count, actual, tt.expected)
+		}
+	}
+}
+
+func TestFormatConstant(t *testing.T) {
+	tests := []struct {
+		spec     string
+		value    constant.Value
+		expected string
+	}{
+		{"%s", constant.MakeString("orders"), "orders"},
+		{"%03d", constant.MakeInt64(5), "005"},
+		{"%s", constant.MakeInt64(5), sqlparser.Placeholder},
+		{"%d", constant.MakeString("orders"), sqlparser.Placeholder},
+	}
+	for _, tt := range tests {
+		if actual := formatConstant(tt.spec, tt.spec[len(tt.spec)-1], tt.value); actual != tt.expected {
+			t.Errorf("formatConstant(%q, %v) = %q, expected %q", tt.spec, tt.value, actual, tt.expected)
+		}
+	}
+}
+
+func TestReadGraphDetectsDatabaseCallsByType(t *testing.T) {
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"})
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+
+	if tables := tablesOf(findNodeByLabel(nodes, "goAccessViz/testpkg.LookupCache")); len(tables) != 0 {
+		t.Errorf("Expected Cache.Get not to be treated as SQL, got %v", tables)
+	}
+	if tables := tablesOf(findNodeByLabel(nodes, "goAccessViz/testpkg.LookupInline")); len(tables) != 0 {
+		t.Errorf("Expected a literal passed to Cache.Get not to be treated as SQL, got %v", tables)
+	}
+	if tables := tablesOf(findNodeByLabel(nodes, "goAccessViz/testpkg.ListLedgers")); !tables["ledgers"] {
+		t.Errorf("Expected a literal flowing into sql.DB.Query to access ledgers, got %v", tables)
+	}
+	if tables := tablesOf(findNodeByLabel(nodes, "goAccessViz/testpkg.CountMembers")); !tables["members"] {
+		t.Errorf("Expected sqlx.Queryer.Queryx to access members, got %v", tables)
+	}
+	if tables := tablesOf(findNodeByLabel(nodes, "goAccessViz/testpkg.ListTags")); !tables["tags"] {
+		t.Errorf("Expected sql.DB.Query to access tags, got %v", tables)
+	}
+}
+
+func TestReadGraphCoversDatabaseAPI(t *testing.T) {
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"})
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+
+	tests := []struct {
+		function string
+		table    string
+		mode     node.AccessMode
+	}{
+		{"goAccessViz/testpkg.ArchiveOrders", "orders", node.AccessUpdate},
+		{"goAccessViz/testpkg.FindVendor", "vendors", node.AccessSelect},
+		{"goAccessViz/testpkg.RenameVendor", "vendors", node.AccessUpdate},
+		{"goAccessViz/testpkg.ListRegions", "regions", node.AccessSelect},
+		{"goAccessViz/testpkg.AddRegion", "regions", node.AccessInsert},
+	}
+	for _, tt := range tests {
+		edge := findEdge(findNodeByLabel(nodes, tt.function), tt.table)
+		if edge == nil {
+			t.Errorf("Expected %s to access %s", tt.function, tt.table)
+			continue
+		}
+		if modes := edge.GetAccessModes(); len(modes) != 1 || modes[0] != tt.mode {
+			t.Errorf("Expected %s to %s %s, got %v", tt.function, tt.mode, tt.table, modes)
+		}
+	}
+}
+
+func TestReadGraphTracesPreparedStatements(t *testing.T) {
+	nodes, err := ReadGraph([]string{"goAccessViz/testpkg"})
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+
+	tests := []struct {
+		executor string
+		preparer string
+		table    string
+		mode     node.AccessMode
+	}{
+		// Through a struct field set in a constructor
+		{"(*goAccessViz/testpkg.ShipmentStore).Find", "goAccessViz/testpkg.NewShipmentStore", "shipments", node.AccessSelect},
+		// Through the result of a helper
+		{"goAccessViz/testpkg.AddCarrier", "goAccessViz/testpkg.prepareCarrierInsert", "carriers", node.AccessInsert},
+		// Through a package variable rebound to a transaction
+		{"goAccessViz/testpkg.RedeemCoupon", "goAccessViz/testpkg.PrepareCoupons", "coupons", node.AccessDelete},
+	}
+	for _, tt := range tests {
+		edge := findEdge(findNodeByLabel(nodes, tt.executor), tt.table)
+		if edge == nil {
+			t.Errorf("Expected %s to access %s", tt.executor, tt.table)
+			continue
+		}
+		if modes := edge.GetAccessModes(); len(modes) != 1 || modes[0] != tt.mode {
+			t.Errorf("Expected %s to %s %s, got %v", tt.executor, tt.mode, tt.table, modes)
+		}
+		preparations := edge.GetPreparations()
+		if len(preparations) != 1 || preparations[0].Function != tt.preparer {
+			t.Errorf("Expected %s to be prepared in %s, got %v", tt.table, tt.preparer, preparations)
+		}
+		if positions := edge.GetPositions(); len(positions) != 1 || positions[0] == preparations[0].Position {
+			t.Errorf("Expected %s to be positioned at the execution, got %v", tt.executor, positions)
+		}
+		if tables := tablesOf(findNodeByLabel(nodes, tt.preparer)); len(tables) != 0 {
+			t.Errorf("Expected %s not to access tables itself, got %v", tt.preparer, tables)
+		}
+	}
+
+	// Without an execution the preparing function keeps the access
+	edge := findEdge(findNodeByLabel(nodes, "goAccessViz/testpkg.PrepareVoucherCheck"), "vouchers")
+	if edge == nil {
+		t.Fatal("Expected PrepareVoucherCheck to access vouchers")
+	}
+	if len(edge.GetPreparations()) != 0 {
+		t.Errorf("Expected no preparations on a direct access, got %v", edge.GetPreparations())
+	}
+}
+
+// readGraphIn はtestdata内の別モジュール(スタブのライブラリをreplaceしたもの)を解析する
+func readGraphIn(t *testing.T, dir string, opts ...Option) []node.TrackedEntity {
+	t.Helper()
+	nodes, err := ReadGraph([]string{"./..."}, append(opts, WithDir(dir))...)
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+	return nodes
+}
+
+func TestReadGraphDetectsPgxCalls(t *testing.T) {
+	nodes := readGraphIn(t, "testdata/pgx", WithColumns(true))
+
+	tests := []struct {
+		function string
+		tables   map[string][]node.AccessMode
+	}{
+		{"pgxapp.ListAccounts", map[string][]node.AccessMode{"accounts": {node.AccessSelect}}},
+		{"pgxapp.GetAccount", map[string][]node.AccessMode{"accounts": {node.AccessSelect}}},
+		{"pgxapp.CloseSessions", map[string][]node.AccessMode{"sessions": {node.AccessDelete}}},
+		{"pgxapp.TouchAccount", map[string][]node.AccessMode{"accounts": {node.AccessUpdate}}},
+		{"pgxapp.RecordLogins", map[string][]node.AccessMode{"logins": {node.AccessInsert}, "accounts": {node.AccessUpdate}}},
+		{"pgxapp.ImportEvents", map[string][]node.AccessMode{"audit.Events": {node.AccessInsert}}},
+		{"pgxapp.ImportTenantEvents", map[string][]node.AccessMode{"%s.events": {node.AccessInsert}}},
+		{"pgxapp.PrepareAccountLookup", map[string][]node.AccessMode{"accounts": {node.AccessSelect}}},
+	}
+	for _, tt := range tests {
+		fn := findNodeByLabel(nodes, tt.function)
+		if fn == nil {
+			t.Errorf("Could not find %s", tt.function)
+			continue
+		}
+		for table, expected := range tt.tables {
+			edge := findEdge(fn, table)
+			if edge == nil {
+				t.Errorf("Expected %s to access %s, got %v", tt.function, table, tablesOf(fn))
+				continue
+			}
+			if modes := edge.GetAccessModes(); len(modes) != len(expected) || modes[0] != expected[0] {
+				t.Errorf("Expected %s to %v %s, got %v", tt.function, expected, table, modes)
+			}
+		}
+	}
+
+	// CopyFrom names its columns separately from the table
+	importEvents := findNodeByLabel(nodes, "pgxapp.ImportEvents")
+	for _, column := range []string{"Events.kind", "Events.payload"} {
+		if findEdge(importEvents, column) == nil {
+			t.Errorf("Expected ImportEvents to write column %s", column)
+		}
+	}
+}
+
+func TestReadGraphDetectsGormQueries(t *testing.T) {
+	nodes := readGraphIn(t, "testdata/gorm")
+
+	tests := []struct {
+		function string
+		table    string
+		modes    []node.AccessMode
+	}{
+		{"gormapp.GetUser", "users", []node.AccessMode{node.AccessSelect}},
+		{"gormapp.ListOrderItems", "order_items", []node.AccessMode{node.AccessSelect}},
+		{"gormapp.DeactivateUsers", "users", []node.AccessMode{node.AccessUpdate}},
+		{"gormapp.RenameCategory", "categories", []node.AccessMode{node.AccessUpdate}},
+		{"gormapp.CreateInvoice", "billing.invoices", []node.AccessMode{node.AccessInsert}},
+		{"gormapp.SavePeople", "people", []node.AccessMode{node.AccessUpsert}},
+		{"gormapp.PurgeRequests", "http_requests", []node.AccessMode{node.AccessDelete}},
+		{"gormapp.CountAuditLog", "audit_log", []node.AccessMode{node.AccessSelect}},
+		{"gormapp.ShardEvents", "events_%s", []node.AccessMode{node.AccessSelect}},
+		{"gormapp.EnsureCategory", "categories", []node.AccessMode{node.AccessSelect, node.AccessInsert}},
+		{"gormapp.ArchiveInvoices", "billing.invoices", []node.AccessMode{node.AccessUpdate}},
+		{"gormapp.TopUsers", "users", []node.AccessMode{node.AccessSelect}},
+		{"gormapp.ResetScores", "leaderboard", []node.AccessMode{node.AccessUpdate}},
+		{"gormapp.CreateUserInTransaction$1", "users", []node.AccessMode{node.AccessInsert}},
+	}
+	for _, tt := range tests {
+		fn := findNodeByLabel(nodes, tt.function)
+		if fn == nil {
+			t.Errorf("Could not find %s", tt.function)
+			continue
+		}
+		if tables := tablesOf(fn); len(tables) != 1 {
+			t.Errorf("Expected %s to access only %s, got %v", tt.function, tt.table, tables)
+		}
+		edge := findEdge(fn, tt.table)
+		if edge == nil {
+			continue
+		}
+		modes := edge.GetAccessModes()
+		if len(modes) != len(tt.modes) {
+			t.Errorf("Expected %s to %v %s, got %v", tt.function, tt.modes, tt.table, modes)
+			continue
+		}
+		for i := range modes {
+			if modes[i] != tt.modes[i] {
+				t.Errorf("Expected %s to %v %s, got %v", tt.function, tt.modes, tt.table, modes)
+			}
+		}
+	}
+}
+
+func TestGormDefaultTableName(t *testing.T) {
+	tests := map[string]string{
+		"User":        "users",
+		"OrderItem":   "order_items",
+		"UserID":      "user_ids",
+		"HTTPRequest": "http_requests",
+		"Category":    "categories",
+		"Address":     "addresses",
+		"Person":      "people",
+		"SalesPerson": "sales_people",
+		"Box":         "boxes",
+		"Key":         "keys",
+		"Analysis":    "analyses",
+		"Wolf":        "wolves",
+		"Knife":       "knives",
+		"Index":       "indices",
+		"Information": "information",
+		"Child":       "children",
+		"Mouse":       "mice",
+		"Ox":          "oxen",
+		"Virus":       "viri",
+		"Status":      "statuses",
+		"Equipment":   "equipment",
+		// GORM's inflection rules add -oes only to buffalo and tomato
+		"Tomato": "tomatoes",
+		"Hero":   "heros",
+	}
+	for typeName, expected := range tests {
+		if got := gormDefaultTableName(typeName); got != expected {
+			t.Errorf("Expected %s to be named %s, got %s", typeName, expected, got)
+		}
+	}
+}
+
+func TestReadGraphMapsSQLCQueries(t *testing.T) {
+	nodes := readGraphIn(t, "testdata/sqlc")
+
+	tests := []struct {
+		function string
+		tables   map[string]node.AccessMode
+	}{
+		{"(*sqlcapp/service.Service).Profile", map[string]node.AccessMode{"users": node.AccessSelect}},
+		{"(*sqlcapp/service.Service).ChangeEmail", map[string]node.AccessMode{"users": node.AccessUpdate}},
+		{"sqlcapp/service.OrderHistory", map[string]node.AccessMode{"orders": node.AccessSelect, "users": node.AccessSelect}},
+		{"sqlcapp/service.CancelOrder", map[string]node.AccessMode{"orders": node.AccessDelete}},
+		{"(*sqlcapp/internal/db.Queries).GetUser", map[string]node.AccessMode{"users": node.AccessSelect}},
+		{"(*sqlcapp/internal/db.Queries).DeleteOrder", map[string]node.AccessMode{"orders": node.AccessDelete}},
+	}
+	for _, tt := range tests {
+		fn := findNodeByLabel(nodes, tt.function)
+		if fn == nil {
+			t.Errorf("Could not find %s", tt.function)
+			continue
+		}
+		if tables := tablesOf(fn); len(tables) != len(tt.tables) {
+			t.Errorf("Expected %s to access %v, got %v", tt.function, tt.tables, tables)
+		}
+		for table, mode := range tt.tables {
+			edge := findEdge(fn, table)
+			if edge == nil {
+				t.Errorf("Expected %s to access %s", tt.function, table)
+				continue
+			}
+			if modes := edge.GetAccessModes(); len(modes) != 1 || modes[0] != mode {
+				t.Errorf("Expected %s to %s %s, got %v", tt.function, mode, table, modes)
+			}
+		}
+	}
+
+	// The generated method points at the named query in the .sql file
+	edge := findEdge(findNodeByLabel(nodes, "(*sqlcapp/internal/db.Queries).GetUser"), "users")
+	if positions := edge.GetPositions(); len(positions) != 1 || filepath.Base(positions[0].File) != "users.sql" || positions[0].Line != 1 {
+		t.Errorf("Expected GetUser to be positioned at users.sql:1, got %v", positions)
+	}
+}
+
+func TestFindSQLCConfigs(t *testing.T) {
+	pkgs, err := packages.Load(createPackageConfig("testdata/sqlc"), "./...")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := filepath.Abs("testdata/sqlc/sqlc.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configs := findSQLCConfigs(pkgs); len(configs) != 1 || configs[0] != expected {
+		t.Errorf("Expected %s, got %v", expected, configs)
+	}
+
+	// Without sqlc generated code the module is not searched
+	pkgs, err = packages.Load(createPackageConfig(""), "goAccessViz/testpkg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configs := findSQLCConfigs(pkgs); len(configs) != 0 {
+		t.Errorf("Expected no sqlc configs without generated code, got %v", configs)
+	}
+}
+
+func TestReadSQLCConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []sqlcPackage
+	}{
+		{
+			name: "sqlc.yaml",
+			content: `version: "2"
+sql:
+- engine: postgresql
+  queries: &queries ["query/users.sql", 'query/orders.sql']
+  schema: schema.sql
+  gen:
+    go: {package: db, out: internal/db}
+- engine: postgresql
+  queries: *queries
+  gen:
+    go:
+      package: "archive"
+      out: "internal/archive" # generated
+- engine: postgresql
+  queries:
+    - query/drafts.sql
+  gen:
+    kotlin: {out: src/main/kotlin}
+`,
+			expected: []sqlcPackage{
+				{out: "internal/db", queries: []string{"query/users.sql", "query/orders.sql"}},
+				{out: "internal/archive", queries: []string{"query/users.sql", "query/orders.sql"}},
+			},
+		},
+		{
+			name:     "sqlc.json",
+			content:  `{"version": "1", "packages": [{"name": "db", "path": "pkg/db", "queries": "./sql/query/", "schema": "./sql/schema/"}]}`,
+			expected: []sqlcPackage{{out: "pkg/db", queries: []string{"sql/query"}}},
+		},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		path := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		pkgs, err := readSQLCConfig(path)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(pkgs) != len(tt.expected) {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.expected, pkgs)
+			continue
+		}
+		for i, expected := range tt.expected {
+			if pkgs[i].out != filepath.Join(dir, expected.out) || len(pkgs[i].queries) != len(expected.queries) {
+				t.Errorf("%s: expected out %s with queries %v, got %+v", tt.name, expected.out, expected.queries, pkgs[i])
+				continue
+			}
+			for j, query := range expected.queries {
+				if pkgs[i].queries[j] != filepath.Join(dir, query) {
+					t.Errorf("%s: expected query path %s, got %s", tt.name, query, pkgs[i].queries[j])
+				}
+			}
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "sqlc.yaml")
+	if err := os.WriteFile(path, []byte("sql:\n- gen: {go: {out: db}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readSQLCConfig(path); err == nil {
+		t.Error("Expected an error for a malformed sqlc.yaml")
+	}
+}
+
+func TestReadGraphDetectsQueryBuilders(t *testing.T) {
+	nodes := readGraphIn(t, "testdata/builder")
+
+	tests := []struct {
+		function string
+		tables   map[string]node.AccessMode
+	}{
+		{"builderapp.ListPosts", map[string]node.AccessMode{"users": node.AccessSelect, "posts": node.AccessSelect}},
+		{"builderapp.AddComment", map[string]node.AccessMode{"comments": node.AccessInsert}},
+		{"builderapp.PublishPost", map[string]node.AccessMode{"posts": node.AccessUpdate}},
+		{"builderapp.PurgeComments", map[string]node.AccessMode{"comments": node.AccessDelete}},
+		{"builderapp.SearchPosts", map[string]node.AccessMode{"posts": node.AccessSelect, "post_tags": node.AccessSelect}},
+		{"builderapp.ArchiveShard", map[string]node.AccessMode{"archive_%s": node.AccessUpdate}},
+		{"builderapp.ListOrders", map[string]node.AccessMode{"orders": node.AccessSelect, "customers": node.AccessSelect}},
+		{"builderapp.CancelOrders", map[string]node.AccessMode{"orders": node.AccessUpdate}},
+		{"builderapp.InsertInvoiceSQL", map[string]node.AccessMode{"billing.Invoices": node.AccessInsert}},
+		{"builderapp.DeleteOrderSQL", map[string]node.AccessMode{"orders": node.AccessDelete}},
+	}
+	for _, tt := range tests {
+		fn := findNodeByLabel(nodes, tt.function)
+		if fn == nil {
+			t.Errorf("Could not find %s", tt.function)
+			continue
+		}
+		if tables := tablesOf(fn); len(tables) != len(tt.tables) {
+			t.Errorf("Expected %s to access %v, got %v", tt.function, tt.tables, tables)
+		}
+		for table, mode := range tt.tables {
+			edge := findEdge(fn, table)
+			if edge == nil {
+				t.Errorf("Expected %s to access %s", tt.function, table)
+				continue
+			}
+			if modes := edge.GetAccessModes(); len(modes) != 1 || modes[0] != mode {
+				t.Errorf("Expected %s to %s %s, got %v", tt.function, mode, table, modes)
+			}
+		}
+	}
+
+	// The builder returned by archiveQuery is only run by its caller
+	if archiveQuery := findNodeByLabel(nodes, "builderapp.archiveQuery"); archiveQuery != nil && len(tablesOf(archiveQuery)) > 0 {
+		t.Errorf("Expected archiveQuery to access no table, got %v", tablesOf(archiveQuery))
+	}
+}
+
+func TestReadGraphDetectsEntCalls(t *testing.T) {
+	nodes := readGraphIn(t, "testdata/ent")
+
+	tests := []struct {
+		function string
+		tables   map[string]node.AccessMode
+	}{
+		{"entapp/service.ListUsers", map[string]node.AccessMode{"users": node.AccessSelect}},
+		{"entapp/service.GetUser", map[string]node.AccessMode{"users": node.AccessSelect}},
+		{"entapp/service.CreateUser", map[string]node.AccessMode{"users": node.AccessInsert}},
+		{"entapp/service.RenameUser", map[string]node.AccessMode{"users": node.AccessUpdate}},
+		{"entapp/service.DeleteUser", map[string]node.AccessMode{"users": node.AccessDelete}},
+		{"entapp/service.UserPosts", map[string]node.AccessMode{"users": node.AccessSelect, "blog_posts": node.AccessSelect}},
+		{"entapp/service.UserGroups", map[string]node.AccessMode{"user_groups": node.AccessSelect, "groups": node.AccessSelect}},
+		{"entapp/service.JoinGroup", map[string]node.AccessMode{"users": node.AccessUpdate, "user_groups": node.AccessInsert}},
+		{"entapp/service.LeaveGroups", map[string]node.AccessMode{"users": node.AccessUpdate, "user_groups": node.AccessDelete}},
+		{"entapp/service.TaggedPosts", map[string]node.AccessMode{"blog_posts": node.AccessSelect, "post_tag_links": node.AccessSelect, "tags": node.AccessSelect}},
+		{"entapp/service.CountFollowers", map[string]node.AccessMode{"users": node.AccessSelect, "user_following": node.AccessSelect}},
+		{"entapp/service.PostTitles", map[string]node.AccessMode{"blog_posts": node.AccessSelect}},
+		{"entapp/service.UsersWithPosts", map[string]node.AccessMode{"users": node.AccessSelect, "blog_posts": node.AccessSelect}},
+		{"entapp/service.AssignPosts", map[string]node.AccessMode{"users": node.AccessUpdate, "blog_posts": node.AccessUpdate}},
+	}
+	for _, tt := range tests {
+		fn := findNodeByLabel(nodes, tt.function)
+		if fn == nil {
+			t.Errorf("Could not find %s", tt.function)
+			continue
+		}
+		if tables := tablesOf(fn); len(tables) != len(tt.tables) {
+			t.Errorf("Expected %s to access %v, got %v", tt.function, tt.tables, tables)
+		}
+		for table, mode := range tt.tables {
+			edge := findEdge(fn, table)
+			if edge == nil {
+				t.Errorf("Expected %s to access %s", tt.function, table)
+				continue
+			}
+			if modes := edge.GetAccessModes(); len(modes) != 1 || modes[0] != mode {
+				t.Errorf("Expected %s to %s %s, got %v", tt.function, mode, table, modes)
+			}
+		}
+	}
+}
+
+func TestReadGraphReadsSQLFiles(t *testing.T) {
+	nodes := readGraphIn(t, "testdata/sqlfiles")
+
+	tests := []struct {
+		function string
+		tables   map[string]node.AccessMode
+	}{
+		{"sqlfilesapp.ListUsers", map[string]node.AccessMode{"users": node.AccessSelect}},
+		{"sqlfilesapp.CountSessions", map[string]node.AccessMode{"sessions": node.AccessSelect}},
+		{"sqlfilesapp.ArchiveOrders", map[string]node.AccessMode{"archived_orders": node.AccessInsert, "orders": node.AccessDelete}},
+		{"sqlfilesapp.PurgeAudit", map[string]node.AccessMode{"audit_log": node.AccessDelete}},
+		{"sqlfilesapp.MonthlyReport", map[string]node.AccessMode{"invoices": node.AccessSelect}},
+	}
+	for _, tt := range tests {
+		fn := findNodeByLabel(nodes, tt.function)
+		if fn == nil {
+			t.Errorf("Could not find %s", tt.function)
+			continue
+		}
+		if tables := tablesOf(fn); len(tables) != len(tt.tables) {
+			t.Errorf("Expected %s to access %v, got %v", tt.function, tt.tables, tables)
+		}
+		for table, mode := range tt.tables {
+			edge := findEdge(fn, table)
+			if edge == nil {
+				t.Errorf("Expected %s to access %s", tt.function, table)
+				continue
+			}
+			if modes := edge.GetAccessModes(); !slices.Contains(modes, mode) {
+				t.Errorf("Expected %s to %s %s, got %v", tt.function, mode, table, modes)
+			}
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package repository
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"goAccessViz/cmd/goAccessViz/repository/sqlparser"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+)
+
+// embedVar は//go:embedを付けたパッケージ変数と、埋め込まれるファイル
+// filesのキーはembed.FSで開くときの名前(パッケージのディレクトリからの相対パス)、値は絶対パス
+type embedVar struct {
+	obj   *types.Var
+	files map[string]string
+}
+
+// sqlFiles はパッケージ変数に埋め込まれたファイルと、os.ReadFileの相対パスを解決するディレクトリ
+type sqlFiles struct {
+	// embedded はstring、[]byte、embed.FSのパッケージ変数ごとの埋め込まれたファイル
+	embedded map[*ssa.Global]map[string]string
+	// dirs はパッケージのパスごとの、相対パスを探すディレクトリ(パッケージとモジュールのディレクトリ)
+	dirs map[string][]string
+	// contents は読み込んだファイルの内容(読めなかったファイルは空)
+	contents map[string]string
+}
+
+// loadSQLFiles は解析するパッケージの//go:embedを解決し、SSAのパッケージ変数に対応付ける
+func loadSQLFiles(prog *ssa.Program, pkgs []*packages.Package) *sqlFiles {
+	files := &sqlFiles{
+		embedded: make(map[*ssa.Global]map[string]string),
+		dirs:     make(map[string][]string),
+		contents: make(map[string]string),
+	}
+	for _, pkg := range pkgs {
+		if len(pkg.GoFiles) == 0 {
+			continue
+		}
+		dir := filepath.Dir(pkg.GoFiles[0])
+		files.dirs[pkg.PkgPath] = []string{dir}
+		if pkg.Module != nil && pkg.Module.Dir != "" && pkg.Module.Dir != dir {
+			files.dirs[pkg.PkgPath] = append(files.dirs[pkg.PkgPath], pkg.Module.Dir)
+		}
+
+		ssaPkg := prog.Package(pkg.Types)
+		if ssaPkg == nil {
+			continue
+		}
+		for _, v := range embedVars(pkg) {
+			if global, ok := ssaPkg.Members[v.obj.Name()].(*ssa.Global); ok {
+				files.embedded[global] = v.files
+			}
+		}
+	}
+	return files
+}
+
+// embedVars はパッケージの//go:embedの指示を読み、パターンに合うファイルを求める
+func embedVars(pkg *packages.Package) []embedVar {
+	if len(pkg.GoFiles) == 0 || pkg.TypesInfo == nil {
+		return nil
+	}
+	dir := filepath.Dir(pkg.GoFiles[0])
+	var vars []embedVar
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				valueSpec := spec.(*ast.ValueSpec)
+				doc := valueSpec.Doc
+				if doc == nil && len(genDecl.Specs) == 1 {
+					doc = genDecl.Doc
+				}
+				patterns := embedPatterns(doc)
+				if len(patterns) == 0 || len(valueSpec.Names) != 1 {
+					continue
+				}
+				obj, ok := pkg.TypesInfo.Defs[valueSpec.Names[0]].(*types.Var)
+				if !ok {
+					continue
+				}
+				vars = append(vars, embedVar{obj: obj, files: matchEmbedPatterns(dir, patterns)})
+			}
+		}
+	}
+	return vars
+}
+
+// embedPatterns は//go:embedの行からパターンを取り出す(クォートされたパターンも受け付ける)
+func embedPatterns(doc *ast.CommentGroup) []string {
+	if doc == nil {
+		return nil
+	}
+	var patterns []string
+	for _, comment := range doc.List {
+		args, ok := strings.CutPrefix(comment.Text, "//go:embed")
+		if !ok || (args != "" && args[0] != ' ' && args[0] != '\t') {
+			continue
+		}
+		for _, field := range strings.Fields(args) {
+			if unquoted, err := strconv.Unquote(field); err == nil {
+				field = unquoted
+			}
+			patterns = append(patterns, field)
+		}
+	}
+	return patterns
+}
+
+// matchEmbedPatterns はパターンに合うファイルを集める
+// ディレクトリはその中のファイルをすべて含み、all:を付けなければ.と_で始まるファイルを除く
+func matchEmbedPatterns(dir string, patterns []string) map[string]string {
+	files := make(map[string]string)
+	for _, pattern := range patterns {
+		pattern, all := strings.CutPrefix(pattern, "all:")
+		matches, err := filepath.Glob(filepath.Join(dir, filepath.FromSlash(pattern)))
+		if err != nil {
+			continue
+		}
+		for _, match := range matches {
+			_ = filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return nil
+				}
+				if path != match && !all && (strings.HasPrefix(d.Name(), ".") || strings.HasPrefix(d.Name(), "_")) {
+					if d.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if !d.IsDir() {
+					if name, err := filepath.Rel(dir, path); err == nil {
+						files[filepath.ToSlash(name)] = path
+					}
+				}
+				return nil
+			})
+		}
+	}
+	return files
+}
+
+// embeddedSQL はパッケージに埋め込まれた.sqlファイルの内容を返す
+func embeddedSQL(pkg *packages.Package) []string {
+	var queries []string
+	seen := make(map[string]bool)
+	for _, v := range embedVars(pkg) {
+		for _, path := range v.files {
+			if seen[path] || !strings.EqualFold(filepath.Ext(path), ".sql") {
+				continue
+			}
+			seen[path] = true
+			if content, err := os.ReadFile(path); err == nil {
+				queries = append(queries, string(content))
+			}
+		}
+	}
+	return queries
+}
+
+// read はファイルの内容を返す(一度読んだファイルは読み直さない)
+func (f *sqlFiles) read(path string) (string, bool) {
+	if content, ok := f.contents[path]; ok {
+		return content, content != ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		f.contents[path] = ""
+		return "", false
+	}
+	f.contents[path] = string(data)
+	return string(data), true
+}
+
+// global はstringか[]byteのパッケージ変数に埋め込まれたファイルの内容を返す
+func (f *sqlFiles) global(global *ssa.Global) (string, bool) {
+	if f == nil {
+		return "", false
+	}
+	files, ok := f.embedded[global]
+	if !ok || len(files) != 1 || isEmbedFS(global.Type()) {
+		return "", false
+	}
+	for _, path := range files {
+		return f.read(path)
+	}
+	return "", false
+}
+
+// readFile は(embed.FS).ReadFile、fs.ReadFile、os.ReadFileで定数のパスから読むファイルの内容を返す
+// os.ReadFileの相対パスは、呼び出しを含むパッケージのディレクトリ、モジュールのディレクトリの順に探す
+func (f *sqlFiles) readFile(call *ssa.Call, e *sqlEvaluator, depth int) (string, bool) {
+	callee := call.Call.StaticCallee()
+	if f == nil || callee == nil {
+		return "", false
+	}
+	args := call.Call.Args
+	switch callee.String() {
+	case "(embed.FS).ReadFile", "io/fs.ReadFile":
+		if len(args) != 2 {
+			return "", false
+		}
+		files, ok := f.embedded[embedFSGlobal(args[0])]
+		if !ok {
+			return "", false
+		}
+		name, ok := f.path(args[1], e, depth)
+		if !ok {
+			return "", false
+		}
+		path, ok := files[name]
+		if !ok {
+			return "", false
+		}
+		return f.read(path)
+	case "os.ReadFile":
+		if len(args) != 1 || call.Parent() == nil || call.Parent().Pkg == nil {
+			return "", false
+		}
+		name, ok := f.path(args[0], e, depth)
+		if !ok {
+			return "", false
+		}
+		if filepath.IsAbs(name) {
+			return f.read(name)
+		}
+		for _, dir := range f.dirs[call.Parent().Pkg.Pkg.Path()] {
+			if content, ok := f.read(filepath.Join(dir, filepath.FromSlash(name))); ok {
+				return content, true
+			}
+		}
+	}
+	return "", false
+}
+
+// path はファイルのパスの引数を評価する(実行時まで分からない部分があれば読まない)
+func (f *sqlFiles) path(v ssa.Value, e *sqlEvaluator, depth int) (string, bool) {
+	if c, ok := v.(*ssa.Const); ok && c.Value != nil && c.Value.Kind() == constant.String {
+		return constant.StringVal(c.Value), true
+	}
+	name := e.partialValue(v, depth+1)
+	return name, name != "" && !strings.Contains(name, sqlparser.Placeholder)
+}
+
+// embedFSGlobal はembed.FSのパッケージ変数の読み出し(fs.FSに変換したものを含む)から変数を求める
+func embedFSGlobal(v ssa.Value) *ssa.Global {
+	if mi, ok := v.(*ssa.MakeInterface); ok {
+		v = mi.X
+	}
+	if load, ok := v.(*ssa.UnOp); ok && load.Op == token.MUL {
+		if global, ok := load.X.(*ssa.Global); ok {
+			return global
+		}
+	}
+	return nil
+}
+
+func isEmbedFS(t types.Type) bool {
+	return isNamedType(t, "embed", "FS")
+}
+
+func isByteSlice(t types.Type) bool {
+	slice, ok := t.Underlying().(*types.Slice)
+	if !ok {
+		return false
+	}
+	basic, ok := slice.Elem().Underlying().(*types.Basic)
+	return ok && basic.Kind() == types.Byte
+}
@@ -0,0 +1,603 @@
+package repository
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"goAccessViz/cmd/goAccessViz/repository/sqlparser"
+
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// maxEvaluationDepth は連結やパッケージ変数を辿る深さの上限(循環する初期化で止まるように)
+const maxEvaluationDepth = 32
+
+// sqlEvaluator はSQLとして渡された引数を、定数・パッケージ変数・文字列の連結・fmt.Sprintf・strings.Builderを辿って文字列に評価する
+// 実行時まで分からない部分はsqlparser.Placeholderとして残す
+type sqlEvaluator struct {
+	// globals はパッケージ変数の初期値(initで一度だけ代入されるもの)
+	globals map[*ssa.Global]ssa.Value
+	// files は//go:embedで埋め込まれたファイルと、ReadFileで読むファイル(解析しない場合はnil)
+	files *sqlFiles
+	// phis は一つの引数の評価の中で求めたPhiの値(評価中のPhiはsqlparser.Placeholder)
+	phis map[*ssa.Phi]string
+}
+
+// newSQLEvaluator は構文を持つパッケージのinitからパッケージ変数の初期値を集める
+// 依存先のパッケージは構文を読み込まないため、その変数は解析対象に含めた場合だけ評価できる
+// init以外の関数で代入される変数や、アドレスを取られる変数は値が一つに決まらないため評価しない
+func newSQLEvaluator(prog *ssa.Program) *sqlEvaluator {
+	e := &sqlEvaluator{globals: make(map[*ssa.Global]ssa.Value)}
+	stores := make(map[*ssa.Global]int)
+	unstable := make(map[*ssa.Global]bool)
+	for fn := range ssautil.AllFunctions(prog) {
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				switch instr := instr.(type) {
+				case *ssa.Store:
+					if global, ok := instr.Addr.(*ssa.Global); ok {
+						if fn == global.Pkg.Func("init") {
+							e.globals[global] = instr.Val
+							stores[global]++
+						} else {
+							unstable[global] = true
+						}
+					}
+					markAddressTaken(unstable, instr.Val)
+				case *ssa.UnOp:
+					if instr.Op != token.MUL {
+						markAddressTaken(unstable, instr.X)
+					}
+				default:
+					for _, operand := range instr.Operands(nil) {
+						markAddressTaken(unstable, *operand)
+					}
+				}
+			}
+		}
+	}
+	// 初期化で何度も代入される変数は値が一つに決まらない
+	for global := range e.globals {
+		if stores[global] > 1 || unstable[global] {
+			delete(e.globals, global)
+		}
+	}
+	return e
+}
+
+// markAddressTaken は読み出しと代入以外で使われたパッケージ変数を、ポインタを通して書き換えられうるものとする
+func markAddressTaken(unstable map[*ssa.Global]bool, v ssa.Value) {
+	if global, ok := v.(*ssa.Global); ok {
+		unstable[global] = true
+	}
+}
+
+// sqlArguments は一つの関数の中の呼び出しについて、SQLの引数を評価する
+type sqlArguments struct {
+	evaluator *sqlEvaluator
+	info      *types.Info
+	// calls は呼び出しの開き括弧の位置からSSAの呼び出し命令を引く
+	calls map[token.Pos]ssa.CallInstruction
+	// sqlc はsqlcの生成したメソッドの呼び出しをクエリに対応付ける(sqlcを使っていなければ空)
+	sqlc *sqlcCatalog
+	// ent はentの生成したメソッドの呼び出しをテーブルに対応付ける(entを使っていなければ空)
+	ent *entCatalog
+}
+
+// forFunction はfnの本体に書かれた呼び出しを評価できるようにする
+// fnは呼び出しを直接含む関数(無名関数ならその無名関数)でなければならない
+func (e *sqlEvaluator) forFunction(info *types.Info, fn *ssa.Function) *sqlArguments {
+	args := &sqlArguments{
+		evaluator: e,
+		info:      info,
+		calls:     make(map[token.Pos]ssa.CallInstruction),
+	}
+	if fn == nil {
+		return args
+	}
+	for _, block := range fn.Blocks {
+		for _, instr := range block.Instrs {
+			if call, ok := instr.(ssa.CallInstruction); ok {
+				args.calls[call.Common().Pos()] = call
+			}
+		}
+	}
+	return args
+}
+
+// stringLiteral は文字列リテラルのクォートを外し、エスケープを解釈した値を返す
+func stringLiteral(lit *ast.BasicLit) (string, bool) {
+	if lit.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(lit.Value)
+	return value, err == nil
+}
+
+// evaluate は呼び出しのindex番目の引数(レシーバを除く)を文字列に評価する
+// 定数式は型検査の結果から、変数や連結はSSAの値を辿って求める
+// 一部しか分からない場合はその部分をsqlparser.Placeholderにした文字列を返す
+func (a *sqlArguments) evaluate(call *ast.CallExpr, index int) (string, bool) {
+	if index >= len(call.Args) {
+		return "", false
+	}
+	arg := call.Args[index]
+	if lit, ok := arg.(*ast.BasicLit); ok {
+		return stringLiteral(lit)
+	}
+	if a == nil {
+		return "", false
+	}
+	if a.info != nil {
+		if tv, ok := a.info.Types[arg]; ok && tv.Value != nil && tv.Value.Kind() == constant.String {
+			return constant.StringVal(tv.Value), true
+		}
+	}
+
+	instr, ok := a.calls[call.Lparen]
+	if !ok {
+		return "", false
+	}
+	common := instr.Common()
+	// メソッドを静的に呼ぶ場合はレシーバが最初の引数になる
+	if !common.IsInvoke() && common.Signature().Recv() != nil {
+		index++
+	}
+	if index >= len(common.Args) {
+		return "", false
+	}
+	value := a.evaluator.partialValue(common.Args[index], 0)
+	// 何も分からない引数(関数の引数をそのまま渡すなど)はSQLとして扱わない
+	return value, strings.ReplaceAll(value, sqlparser.Placeholder, "") != ""
+}
+
+// partialValue はSSAの値を文字列に評価し、分からない部分をsqlparser.Placeholderにする
+func (e *sqlEvaluator) partialValue(v ssa.Value, depth int) string {
+	if depth > maxEvaluationDepth {
+		return sqlparser.Placeholder
+	}
+	if depth == 0 || e.phis == nil {
+		e.phis = make(map[*ssa.Phi]string)
+	}
+	switch v := v.(type) {
+	case *ssa.Const:
+		if v.Value != nil && v.Value.Kind() == constant.String {
+			return constant.StringVal(v.Value)
+		}
+	case *ssa.BinOp:
+		if v.Op == token.ADD {
+			return e.partialValue(v.X, depth+1) + e.partialValue(v.Y, depth+1)
+		}
+	case *ssa.UnOp:
+		// パッケージ変数の読み出し
+		if global, ok := v.X.(*ssa.Global); ok && v.Op == token.MUL {
+			if content, ok := e.files.global(global); ok {
+				return content
+			}
+			if init, exists := e.globals[global]; exists {
+				return e.partialValue(init, depth+1)
+			}
+		}
+	case *ssa.ChangeType:
+		return e.partialValue(v.X, depth+1)
+	case *ssa.Convert:
+		// string(data)で[]byteとして読んだファイルの内容
+		if isByteSlice(v.X.Type()) || isByteSlice(v.Type()) {
+			return e.partialValue(v.X, depth+1)
+		}
+	case *ssa.Phi:
+		// 分岐の多いループで同じPhiを何度も評価し直さないよう、一度求めた値を使う
+		// 評価中のPhiに戻ってきた場合(ループ)は分からない部分とする
+		if value, ok := e.phis[v]; ok {
+			return value
+		}
+		e.phis[v] = sqlparser.Placeholder
+		value := e.phiValue(v, depth)
+		e.phis[v] = value
+		return value
+	case *ssa.Call:
+		return e.callValue(v, depth)
+	case *ssa.Extract:
+		// query, args, err := sqlx.In(...)のSQL
+		if call, ok := v.Tuple.(*ssa.Call); ok && v.Index == 0 {
+			return e.callValue(call, depth)
+		}
+	}
+	return sqlparser.Placeholder
+}
+
+// phiValue はすべての分岐で同じ文字列になる場合だけPhiを評価する
+func (e *sqlEvaluator) phiValue(phi *ssa.Phi, depth int) string {
+	if len(phi.Edges) == 0 {
+		return sqlparser.Placeholder
+	}
+	var value string
+	for i, edge := range phi.Edges {
+		s := e.partialValue(edge, depth+1)
+		if i > 0 && s != value {
+			return sqlparser.Placeholder
+		}
+		value = s
+	}
+	return value
+}
+
+// callValue はfmt.Sprintf、strings.Builder(bytes.Buffer)のString()、sqlxでの書き換えの結果を評価する
+func (e *sqlEvaluator) callValue(call *ssa.Call, depth int) string {
+	callee := call.Call.StaticCallee()
+	if callee == nil {
+		return sqlparser.Placeholder
+	}
+	if fn, ok := callee.Object().(*types.Func); ok && isQueryRewriter(fn) {
+		index := sqlParameterIndex(callee.Signature)
+		// メソッドはレシーバが最初の引数になる
+		if callee.Signature.Recv() != nil {
+			index++
+		}
+		if index >= 0 && index < len(call.Call.Args) {
+			return e.partialValue(call.Call.Args[index], depth+1)
+		}
+		return sqlparser.Placeholder
+	}
+	if content, ok := e.files.readFile(call, e, depth); ok {
+		return content
+	}
+	if callee.String() == "fmt.Sprintf" && len(call.Call.Args) == 2 {
+		return e.sprintf(call.Call.Args[0], call.Call.Args[1], depth)
+	}
+	if builderMethod(callee) == "String" {
+		return e.builderValue(call, depth)
+	}
+	return sqlparser.Placeholder
+}
+
+// sprintf は書式と可変長引数を評価して書式を埋める
+// 定数の引数は書式どおりに埋め、分からない引数の動詞はsqlparser.Placeholderにする
+func (e *sqlEvaluator) sprintf(formatValue, argsValue ssa.Value, depth int) string {
+	format := e.partialValue(formatValue, depth+1)
+	args, _ := variadicArgs(argsValue)
+	return formatArguments(format, len(args), func(spec string, verb byte, index int) string {
+		return e.formatArg(spec, verb, args[index], depth)
+	})
+}
+
+// formatArguments は書式の動詞ごとに対応する引数の番号を求め、argで置き換える
+// %[n]の明示的な番号に従い、*で幅や精度を引数から取る場合や番号が範囲外の場合はsqlparser.Placeholderにする
+// 引数の番号が分からなくなった後の動詞も、次に%[n]が現れるまでsqlparser.Placeholderにする
+func formatArguments(format string, count int, arg func(spec string, verb byte, index int) string) string {
+	next, known := 0, true
+	return formatString(format, func(spec string, verb byte) string {
+		body := spec[1 : len(spec)-1]
+		if open := strings.LastIndexByte(body, '['); open >= 0 && strings.HasSuffix(body, "]") {
+			if n, err := strconv.Atoi(body[open+1 : len(body)-1]); err == nil {
+				next, known = n-1, true
+				body = body[:open]
+			}
+		}
+		if strings.ContainsAny(body, "*[") {
+			known = false
+		}
+		if !known || next < 0 || next >= count {
+			next++
+			return sqlparser.Placeholder
+		}
+		index := next
+		next++
+		return arg("%"+body+string(verb), verb, index)
+	})
+}
+
+// formatArg は動詞を引数で置き換える
+// 定数はfmtと同じように書式化し、動詞と型の組み合わせがfmtで不正な場合(%!s(int64=5)など)はsqlparser.Placeholderにする
+func (e *sqlEvaluator) formatArg(spec string, verb byte, arg ssa.Value, depth int) string {
+	if boxed, ok := arg.(*ssa.MakeInterface); ok {
+		arg = boxed.X
+	}
+	if c, ok := arg.(*ssa.Const); ok && c.Value != nil {
+		// 名前付きの型はStringやFormatで自身を書式化することがある
+		if _, basic := c.Type().(*types.Basic); basic {
+			return formatConstant(spec, verb, c.Value)
+		}
+		return sqlparser.Placeholder
+	}
+	if basic, ok := arg.Type().Underlying().(*types.Basic); ok && basic.Info()&types.IsString != 0 && (verb == 's' || verb == 'v') {
+		return e.partialValue(arg, depth+1)
+	}
+	return sqlparser.Placeholder
+}
+
+// formatConstant は定数をfmtの書式で文字列にする(fmtが不正な動詞として書き出す場合はsqlparser.Placeholder)
+func formatConstant(spec string, verb byte, value constant.Value) string {
+	v, ok := constantInterface(value)
+	if !ok {
+		return sqlparser.Placeholder
+	}
+	formatted := fmt.Sprintf(spec, v)
+	if strings.Contains(formatted, "%!"+string(verb)+"(") {
+		return sqlparser.Placeholder
+	}
+	return formatted
+}
+
+// formatString はfmtの書式の動詞をargで置き換える("%%"は"%"になる)
+// 英字で終わらない"%"(LIKE 'a%'など)はそのまま残す
+func formatString(format string, arg func(spec string, verb byte) string) string {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			b.WriteByte(format[i])
+			continue
+		}
+		// %[flags][width][.precision][[n]]verb
+		j := i + 1
+		for j < len(format) {
+			if strings.IndexByte("+-# 0123456789.*", format[j]) >= 0 {
+				j++
+			} else if end := argIndexEnd(format, j); end > j {
+				j = end
+			} else {
+				break
+			}
+		}
+		if j < len(format) && format[j] == '%' && j == i+1 {
+			b.WriteByte('%')
+			i = j
+			continue
+		}
+		if j >= len(format) || !isASCIILetter(format[j]) {
+			b.WriteByte('%')
+			continue
+		}
+		b.WriteString(arg(format[i:j+1], format[j]))
+		i = j
+	}
+	return b.String()
+}
+
+// formatPattern は書式文字列のリテラルをSQLとして読めるよう、動詞をsqlparser.Placeholderにする
+func formatPattern(format string) string {
+	return formatString(format, func(string, byte) string {
+		return sqlparser.Placeholder
+	})
+}
+
+// argIndexEnd はformat[start:]が引数の番号[n]で始まればその直後の位置を、そうでなければstartを返す
+func argIndexEnd(format string, start int) int {
+	if start >= len(format) || format[start] != '[' {
+		return start
+	}
+	j := start + 1
+	for j < len(format) && format[j] >= '0' && format[j] <= '9' {
+		j++
+	}
+	if j == start+1 || j >= len(format) || format[j] != ']' {
+		return start
+	}
+	return j + 1
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// variadicArgs は可変長引数としてまとめられたスライスの要素を返す
+func variadicArgs(v ssa.Value) ([]ssa.Value, bool) {
+	if c, ok := v.(*ssa.Const); ok && c.IsNil() {
+		return nil, true
+	}
+	slice, ok := v.(*ssa.Slice)
+	if !ok {
+		return nil, false
+	}
+	alloc, ok := slice.X.(*ssa.Alloc)
+	if !ok {
+		return nil, false
+	}
+	array, ok := alloc.Type().(*types.Pointer).Elem().Underlying().(*types.Array)
+	if !ok {
+		return nil, false
+	}
+	args := make([]ssa.Value, array.Len())
+	for _, ref := range *alloc.Referrers() {
+		indexAddr, ok := ref.(*ssa.IndexAddr)
+		if !ok {
+			continue
+		}
+		index, ok := indexAddr.Index.(*ssa.Const)
+		if !ok {
+			continue
+		}
+		i := index.Int64()
+		for _, store := range *indexAddr.Referrers() {
+			if s, ok := store.(*ssa.Store); ok && i >= 0 && i < int64(len(args)) {
+				args[i] = s.Val
+			}
+		}
+	}
+	for _, arg := range args {
+		if arg == nil {
+			return nil, false
+		}
+	}
+	return args, true
+}
+
+func constantInterface(value constant.Value) (interface{}, bool) {
+	switch value.Kind() {
+	case constant.String:
+		return constant.StringVal(value), true
+	case constant.Int:
+		if i, exact := constant.Int64Val(value); exact {
+			return i, true
+		}
+	case constant.Float:
+		f, _ := constant.Float64Val(value)
+		return f, true
+	case constant.Bool:
+		return constant.BoolVal(value), true
+	}
+	return nil, false
+}
+
+// builderMethod はstrings.Builderかbytes.Bufferのメソッドならその名前を返す
+func builderMethod(fn *ssa.Function) string {
+	recv := fn.Signature.Recv()
+	if recv == nil {
+		return ""
+	}
+	switch recv.Type().String() {
+	case "*strings.Builder", "*bytes.Buffer":
+		return fn.Name()
+	}
+	return ""
+}
+
+// builderValue はString()を支配するブロックでBuilderへ書き込まれた内容を命令の順に連結する
+// 条件分岐やループの中など、String()までに通るとは限らないブロックの書き込みは分からない部分とする
+// Builderを他の関数に渡した場合もその書き込みを分からない部分とする
+func (e *sqlEvaluator) builderValue(call *ssa.Call, depth int) string {
+	recv := call.Call.Args[0]
+	var dominators []*ssa.BasicBlock
+	for block := call.Block(); block != nil; block = block.Idom() {
+		dominators = append([]*ssa.BasicBlock{block}, dominators...)
+	}
+	conditional := e.conditionalWrites(call, recv, dominators, depth)
+
+	var parts []string
+	for _, block := range dominators {
+		parts = e.builderWrites(parts, block, recv, call, depth)
+		if fragments, ok := conditional[block]; ok {
+			parts = append(parts, unknownFragment(fragments))
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// builderWrites はブロックでのBuilderへの書き込みをpartsに加える(String()の呼び出しより後は読まない)
+func (e *sqlEvaluator) builderWrites(parts []string, block *ssa.BasicBlock, recv ssa.Value, call *ssa.Call, depth int) []string {
+	for _, instr := range block.Instrs {
+		if instr == call {
+			break
+		}
+		write, ok := instr.(ssa.CallInstruction)
+		if !ok || !usesValue(write.Common(), recv) {
+			continue
+		}
+		common := write.Common()
+		callee := common.StaticCallee()
+		switch {
+		case callee == nil:
+			parts = append(parts, sqlparser.Placeholder)
+		case callee.String() == "fmt.Fprintf" && len(common.Args) == 3:
+			parts = append(parts, e.sprintf(common.Args[1], common.Args[2], depth))
+		default:
+			switch builderMethod(callee) {
+			case "WriteString":
+				parts = append(parts, e.partialValue(common.Args[1], depth+1))
+			case "WriteByte", "WriteRune":
+				parts = append(parts, runeValue(common.Args[1]))
+			case "Reset":
+				parts = nil
+			case "Len", "Cap", "Grow", "String":
+			default:
+				parts = append(parts, sqlparser.Placeholder)
+			}
+		}
+	}
+	return parts
+}
+
+// conditionalWrites はString()を支配しないブロックでの書き込みを、その後に続く支配ブロックごとにまとめる
+// String()まで到達しないブロックの書き込みは数えない
+func (e *sqlEvaluator) conditionalWrites(call *ssa.Call, recv ssa.Value, dominators []*ssa.BasicBlock, depth int) map[*ssa.BasicBlock][]string {
+	onPath := make(map[*ssa.BasicBlock]bool)
+	for _, block := range dominators {
+		onPath[block] = true
+	}
+	reaching := make(map[*ssa.BasicBlock]bool)
+	queue := []*ssa.BasicBlock{call.Block()}
+	for len(queue) > 0 {
+		block := queue[0]
+		queue = queue[1:]
+		for _, pred := range block.Preds {
+			if !reaching[pred] {
+				reaching[pred] = true
+				queue = append(queue, pred)
+			}
+		}
+	}
+
+	conditional := make(map[*ssa.BasicBlock][]string)
+	for _, block := range call.Parent().Blocks {
+		if onPath[block] || !reaching[block] || !writesTo(block, recv) {
+			continue
+		}
+		dominator := block.Idom()
+		for dominator != nil && !onPath[dominator] {
+			dominator = dominator.Idom()
+		}
+		if dominator != nil {
+			fragment := strings.Join(e.builderWrites(nil, block, recv, call, depth), "")
+			conditional[dominator] = append(conditional[dominator], fragment)
+		}
+	}
+	return conditional
+}
+
+// writesTo はブロックにBuilderを引数に取る呼び出しがあるかを判定する
+func writesTo(block *ssa.BasicBlock, recv ssa.Value) bool {
+	for _, instr := range block.Instrs {
+		if call, ok := instr.(ssa.CallInstruction); ok && usesValue(call.Common(), recv) {
+			return true
+		}
+	}
+	return false
+}
+
+// unknownFragment は通るとは限らない書き込みの代わりに置く分からない部分を返す
+// 書き込まれ得る内容がどれも空白で始まる(終わる)場合は、前後の識別子とつながらないよう空白を残す
+func unknownFragment(fragments []string) string {
+	leading, trailing := true, true
+	for _, fragment := range fragments {
+		if fragment == "" {
+			continue
+		}
+		leading = leading && unicode.IsSpace(rune(fragment[0]))
+		trailing = trailing && unicode.IsSpace(rune(fragment[len(fragment)-1]))
+	}
+	unknown := sqlparser.Placeholder
+	if leading {
+		unknown = " " + unknown
+	}
+	if trailing {
+		unknown += " "
+	}
+	return unknown
+}
+
+// usesValue は呼び出しの引数(interfaceに変換したものを含む)にvが含まれるかを判定する
+func usesValue(common *ssa.CallCommon, v ssa.Value) bool {
+	for _, arg := range common.Args {
+		if boxed, ok := arg.(*ssa.MakeInterface); ok {
+			arg = boxed.X
+		}
+		if arg == v {
+			return true
+		}
+	}
+	return false
+}
+
+func runeValue(v ssa.Value) string {
+	if c, ok := v.(*ssa.Const); ok && c.Value != nil && c.Value.Kind() == constant.Int {
+		return string(rune(c.Int64()))
+	}
+	return sqlparser.Placeholder
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sqlcConfigNames はsqlcが読む設定ファイルの名前
+var sqlcConfigNames = []string{"sqlc.yaml", "sqlc.yml", "sqlc.json"}
+
+// sqlcPackage はsqlcの設定の一つの生成先と、そのSQLを書いたファイルまたはディレクトリ
+// outとqueriesは設定ファイルのディレクトリを基準にした絶対パス
+type sqlcPackage struct {
+	out     string
+	queries []string
+}
+
+// readSQLCConfig はsqlcの設定ファイル(version 1のpackagesとversion 2のsql)から生成先を読み出す
+func readSQLCConfig(path string) ([]sqlcPackage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if filepath.Ext(path) == ".json" {
+		err = json.Unmarshal(data, &doc)
+	} else {
+		err = yaml.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	root, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected a mapping at the top level")
+	}
+
+	dir := filepath.Dir(path)
+	var pkgs []sqlcPackage
+	// version 2: sql[].gen.go.out
+	for _, entry := range asList(root["sql"]) {
+		entry, ok := entry.(map[string]any)
+		if !ok {
+			continue
+		}
+		gen, _ := entry["gen"].(map[string]any)
+		golang, _ := gen["go"].(map[string]any)
+		if out, ok := golang["out"].(string); ok {
+			pkgs = append(pkgs, sqlcPackage{out: filepath.Join(dir, out), queries: resolvePaths(dir, entry["queries"])})
+		}
+	}
+	// version 1: packages[].path
+	for _, entry := range asList(root["packages"]) {
+		entry, ok := entry.(map[string]any)
+		if !ok {
+			continue
+		}
+		if out, ok := entry["path"].(string); ok {
+			pkgs = append(pkgs, sqlcPackage{out: filepath.Join(dir, out), queries: resolvePaths(dir, entry["queries"])})
+		}
+	}
+	return pkgs, nil
+}
+
+// resolvePaths は"query.sql"のような一つのパスと、パスのリストのどちらも受け付ける
+func resolvePaths(dir string, value any) []string {
+	var paths []string
+	for _, item := range asList(value) {
+		if path, ok := item.(string); ok {
+			paths = append(paths, filepath.Join(dir, path))
+		}
+	}
+	return paths
+}
+
+func asList(value any) []any {
+	switch value := value.(type) {
+	case []any:
+		return value
+	case nil:
+		return nil
+	default:
+		return []any{value}
+	}
+}
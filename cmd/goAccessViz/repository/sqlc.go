@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+)
+
+// sqlcQuery はsqlcの名前付きクエリ(-- name: GetUser :one)のSQLと、.sqlファイル上の位置
+type sqlcQuery struct {
+	sql string
+	pos token.Pos
+}
+
+// sqlcReceivers はsqlcが生成するメソッドのレシーバ(emit_interfaceのQuerierを含む)
+var sqlcReceivers = map[string]bool{"Queries": true, "Querier": true}
+
+// sqlcCatalog はsqlcが生成したパッケージのパスごとの、メソッド名から名前付きクエリへの対応
+type sqlcCatalog struct {
+	queries map[string]map[string]sqlcQuery
+}
+
+// loadSQLCCatalog は解析するモジュールにあるsqlcの設定ファイルを探し、生成先のパッケージとクエリを対応付ける
+// .sqlファイルはfsetに登録するため、クエリの位置もGoのコードと同じように扱える
+func loadSQLCCatalog(fset *token.FileSet, pkgs []*packages.Package) (*sqlcCatalog, []Diagnostic) {
+	catalog := &sqlcCatalog{queries: make(map[string]map[string]sqlcQuery)}
+	var diagnostics []Diagnostic
+
+	// Generated packages are matched by directory, including those only reached as dependencies
+	pkgsByDir := make(map[string]string)
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if len(pkg.GoFiles) > 0 {
+			pkgsByDir[filepath.Dir(pkg.GoFiles[0])] = pkg.PkgPath
+		}
+	})
+
+	for _, configPath := range findSQLCConfigs(pkgs) {
+		sqlcPkgs, err := readSQLCConfig(configPath)
+		if err != nil {
+			diagnostics = append(diagnostics, Diagnostic{Package: configPath, Kind: DiagnosticConfig, Message: err.Error()})
+			continue
+		}
+		for _, sqlcPkg := range sqlcPkgs {
+			pkgPath, ok := pkgsByDir[sqlcPkg.out]
+			if !ok {
+				continue
+			}
+			if catalog.queries[pkgPath] == nil {
+				catalog.queries[pkgPath] = make(map[string]sqlcQuery)
+			}
+			for _, file := range queryFiles(sqlcPkg.queries) {
+				content, err := os.ReadFile(file)
+				if err != nil {
+					diagnostics = append(diagnostics, Diagnostic{Package: configPath, Kind: DiagnosticConfig, Message: err.Error()})
+					continue
+				}
+				for name, query := range parseSQLCQueries(fset, file, content) {
+					catalog.queries[pkgPath][name] = query
+				}
+			}
+		}
+	}
+	return catalog, diagnostics
+}
+
+// findSQLCConfigs は読み込んだパッケージのディレクトリと、モジュールのルートまでのその親にあるsqlcの設定ファイルを探す
+// sqlcの生成するQueriesかQuerierの型を宣言するパッケージがなければ探さない
+func findSQLCConfigs(pkgs []*packages.Package) []string {
+	generated := false
+	dirs := make(map[string]bool)
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if pkg.Types != nil && declaresSQLCReceiver(pkg.Types) {
+			generated = true
+		}
+		if len(pkg.GoFiles) == 0 || pkg.Module == nil || pkg.Module.Dir == "" {
+			return
+		}
+		moduleDir := pkg.Module.Dir
+		for dir := filepath.Dir(pkg.GoFiles[0]); !dirs[dir]; dir = filepath.Dir(dir) {
+			dirs[dir] = true
+			if dir == moduleDir || !strings.HasPrefix(dir, moduleDir+string(filepath.Separator)) {
+				break
+			}
+		}
+	})
+	if !generated {
+		return nil
+	}
+
+	var configs []string
+	for dir := range dirs {
+		for _, name := range sqlcConfigNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				configs = append(configs, path)
+			}
+		}
+	}
+	sort.Strings(configs)
+	return configs
+}
+
+// declaresSQLCReceiver はパッケージがsqlcの生成するメソッドのレシーバと同じ名前の型を宣言しているかを判定する
+func declaresSQLCReceiver(pkg *types.Package) bool {
+	for name := range sqlcReceivers {
+		if _, ok := pkg.Scope().Lookup(name).(*types.TypeName); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// queryFiles はqueriesに書かれたファイルとディレクトリから.sqlファイルを集める
+func queryFiles(paths []string) []string {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".sql") {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+	return files
+}
+
+// sqlcQueryName は"-- name: GetUser :one"や"/* name: GetUser :one */"の行
+var sqlcQueryName = regexp.MustCompile(`^\s*(?:--|/\*)\s*name:\s*(\w+)\s+:\w+`)
+
+// sqlcMacros はsqlcの引数の指定(sqlc.arg(id)など)で、列として読まれないようにパラメータに置き換える
+var sqlcMacros = regexp.MustCompile(`(?i)sqlc\.(?:arg|narg|slice)\s*\([^)]*\)`)
+
+// sqlcEmbed はsqlc.embed(users)で、テーブルのすべての列(users.*)を表す
+var sqlcEmbed = regexp.MustCompile(`(?i)sqlc\.embed\s*\(\s*(\w+)\s*\)`)
+
+// parseSQLCQueries は.sqlファイルを名前付きクエリに分ける
+func parseSQLCQueries(fset *token.FileSet, filename string, content []byte) map[string]sqlcQuery {
+	file := fset.AddFile(filename, -1, len(content))
+	file.SetLinesForContent(content)
+
+	queries := make(map[string]sqlcQuery)
+	var name string
+	var start int
+	var body strings.Builder
+	flush := func() {
+		if name != "" {
+			sql := sqlcMacros.ReplaceAllString(body.String(), "?")
+			sql = sqlcEmbed.ReplaceAllString(sql, "$1.*")
+			queries[name] = sqlcQuery{sql: sql, pos: file.Pos(start)}
+		}
+		body.Reset()
+	}
+
+	offset := 0
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		if match := sqlcQueryName.FindStringSubmatch(line); match != nil {
+			flush()
+			name = match[1]
+			start = offset
+		} else if name != "" {
+			body.WriteString(line)
+		}
+		offset += len(line)
+	}
+	flush()
+	return queries
+}
+
+// call は呼び出しがsqlcの生成したメソッドであれば、そのクエリを返す
+func (c *sqlcCatalog) call(info *types.Info, callExpr *ast.CallExpr) (sqlcQuery, bool) {
+	selExpr, ok := callExpr.Fun.(*ast.SelectorExpr)
+	if c == nil || info == nil || !ok {
+		return sqlcQuery{}, false
+	}
+	selection, ok := info.Selections[selExpr]
+	if !ok || selection.Kind() != types.MethodVal {
+		return sqlcQuery{}, false
+	}
+	method, ok := selection.Obj().(*types.Func)
+	if !ok {
+		return sqlcQuery{}, false
+	}
+	return c.lookup(method)
+}
+
+// method は関数がsqlcの生成したメソッドであれば、そのクエリを返す
+func (c *sqlcCatalog) method(fn *ssa.Function) (sqlcQuery, bool) {
+	method, ok := fn.Object().(*types.Func)
+	if c == nil || !ok {
+		return sqlcQuery{}, false
+	}
+	return c.lookup(method)
+}
+
+func (c *sqlcCatalog) lookup(method *types.Func) (sqlcQuery, bool) {
+	recv := method.Type().(*types.Signature).Recv()
+	if method.Pkg() == nil || recv == nil {
+		return sqlcQuery{}, false
+	}
+	named, ok := derefType(recv.Type()).(*types.Named)
+	if !ok || !sqlcReceivers[named.Obj().Name()] {
+		return sqlcQuery{}, false
+	}
+	query, ok := c.queries[method.Pkg().Path()][method.Name()]
+	return query, ok
+}
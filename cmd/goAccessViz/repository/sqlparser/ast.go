@@ -0,0 +1,240 @@
+package sqlparser
+
+import "strings"
+
+// Statement はSQLの1文に相当する
+type Statement interface {
+	statementNode()
+}
+
+// TableExpr はFROM句などに現れるテーブル式
+type TableExpr interface {
+	tableExprNode()
+}
+
+// CommonTableExpression はWITH句で定義される名前付きのクエリ
+// PostgreSQLではINSERTやDELETEなどのデータ変更文も置ける
+type CommonTableExpression struct {
+	Name      Identifier
+	Columns   []Identifier
+	Statement Statement
+}
+
+// With はWITH句
+type With struct {
+	Recursive bool
+	CTEs      []*CommonTableExpression
+}
+
+// Query はWITH句と集合演算(UNION, INTERSECT, EXCEPT)を含むSELECT
+type Query struct {
+	With *With
+	// Selects は集合演算で結合された各SELECT(括弧でネストしたものは平坦化しない)
+	Selects []QueryTerm
+	OrderBy []*Expression
+	Limit   []*Expression
+}
+
+// QueryTerm は集合演算の各項(SELECT, VALUES, 括弧で囲まれたQuery)
+type QueryTerm interface {
+	queryTermNode()
+}
+
+// Select はSELECT ... FROM ... WHERE ...の1ブロック
+type Select struct {
+	Distinct []*Expression
+	Columns  []*SelectItem
+	From     []TableExpr
+	Where    *Expression
+	GroupBy  []*Expression
+	Having   *Expression
+	Window   []*Expression
+}
+
+// Values はVALUES (...), (...)の行リスト
+type Values struct {
+	Rows [][]*Expression
+}
+
+// SelectItem はSELECTリストの要素
+type SelectItem struct {
+	Expr  *Expression
+	Alias string
+}
+
+// TableName は実在するテーブル(またはCTE)への参照
+type TableName struct {
+	// Parts は"."で区切られた名前の各部分(スキーマやデータベースを含む)
+	Parts []Identifier
+	Alias string
+	Pos   int
+	Only  bool
+}
+
+// Identifier は識別子とクォートの有無
+type Identifier struct {
+	Name   string
+	Quoted bool
+}
+
+// Normalized はクォートされていない識別子を大文字小文字を区別せずに比較するための名前にする
+// クォートされた識別子は大文字小文字を保つ
+func (ident Identifier) Normalized() string {
+	if ident.Quoted {
+		return ident.Name
+	}
+	return strings.ToLower(ident.Name)
+}
+
+// Name はテーブル名(修飾子を除いた最後の部分)を返す
+func (t *TableName) Name() Identifier {
+	return t.Parts[len(t.Parts)-1]
+}
+
+// Qualifiers はスキーマやデータベースなどの修飾子を返す
+func (t *TableName) Qualifiers() []Identifier {
+	return t.Parts[:len(t.Parts)-1]
+}
+
+// DerivedTable はFROM句のサブクエリ
+type DerivedTable struct {
+	Query   *Query
+	Alias   string
+	Lateral bool
+}
+
+// TableFunction はunnest(...)やgenerate_series(...)のような関数呼び出し
+type TableFunction struct {
+	Name    []Identifier
+	Args    *Expression
+	Alias   string
+	Lateral bool
+}
+
+// Join は2つのテーブル式の結合
+type Join struct {
+	Left  TableExpr
+	Right TableExpr
+	Kind  string
+	On    *Expression
+	Using []Identifier
+}
+
+// ParenTableExpr は括弧で囲まれた結合
+type ParenTableExpr struct {
+	Expr TableExpr
+}
+
+// Expression は式を平坦化したもので、参照している列とサブクエリだけを持つ
+type Expression struct {
+	Columns    []*ColumnRef
+	Subqueries []*Query
+}
+
+// ColumnRef は列への参照(a, t.a, t.*, *)
+type ColumnRef struct {
+	Parts []Identifier
+	Star  bool
+	Pos   int
+}
+
+// Assignment はUPDATE ... SETやON CONFLICT DO UPDATE SETの代入
+type Assignment struct {
+	Columns []*ColumnRef
+	Value   *Expression
+}
+
+// SelectStatement はSELECT文
+type SelectStatement struct {
+	Query *Query
+}
+
+// InsertStatement はINSERT文とREPLACE文
+type InsertStatement struct {
+	With    *With
+	Table   *TableName
+	Columns []Identifier
+	// Source はINSERT ... SELECTやVALUESで挿入する値
+	Source *Query
+	// Set はMySQLのINSERT ... SET a = 1
+	Set []*Assignment
+	// Replace はREPLACE INTO
+	Replace bool
+	// OnConflict はON CONFLICTやON DUPLICATE KEY UPDATE
+	OnConflict *OnConflict
+	Returning  []*Expression
+}
+
+// OnConflict はINSERTが衝突した場合の動作
+type OnConflict struct {
+	Target     []*Expression
+	DoNothing  bool
+	Assignment []*Assignment
+	Where      *Expression
+}
+
+// UpdateStatement はUPDATE文
+type UpdateStatement struct {
+	With *With
+	// Tables は更新対象(MySQLでは複数テーブルやJOINを含む)
+	Tables    []TableExpr
+	Set       []*Assignment
+	From      []TableExpr
+	Where     *Expression
+	OrderBy   []*Expression
+	Returning []*Expression
+}
+
+// DeleteStatement はDELETE文
+type DeleteStatement struct {
+	With *With
+	// Targets はMySQLのDELETE t1, t2 FROM ...のように明示された削除対象
+	Targets   []*TableName
+	From      []TableExpr
+	Using     []TableExpr
+	Where     *Expression
+	OrderBy   []*Expression
+	Returning []*Expression
+}
+
+// TruncateStatement はTRUNCATE文
+type TruncateStatement struct {
+	Tables []*TableName
+}
+
+// MergeStatement はMERGE文
+type MergeStatement struct {
+	With    *With
+	Target  *TableName
+	Source  TableExpr
+	On      *Expression
+	Clauses []*MergeClause
+}
+
+// MergeClause はMERGEのWHEN [NOT] MATCHED THEN ...
+type MergeClause struct {
+	Matched   bool
+	Condition *Expression
+	// Action はUPDATE, DELETE, INSERT, NOTHINGのいずれか
+	Action  string
+	Set     []*Assignment
+	Columns []Identifier
+	Values  []*Expression
+}
+
+func (*SelectStatement) statementNode()   {}
+func (*InsertStatement) statementNode()   {}
+func (*UpdateStatement) statementNode()   {}
+func (*DeleteStatement) statementNode()   {}
+func (*TruncateStatement) statementNode() {}
+func (*MergeStatement) statementNode()    {}
+
+func (*TableName) tableExprNode()      {}
+func (*DerivedTable) tableExprNode()   {}
+func (*TableFunction) tableExprNode()  {}
+func (*Join) tableExprNode()           {}
+func (*ParenTableExpr) tableExprNode() {}
+
+func (*Select) queryTermNode() {}
+func (*Values) queryTermNode() {}
+func (*Query) queryTermNode()  {}
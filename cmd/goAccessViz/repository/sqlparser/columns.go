@@ -0,0 +1,171 @@
+package sqlparser
+
+import (
+	"sort"
+	"strings"
+)
+
+// ColumnReference は文中の列参照とその列に対する操作
+// SELECT *やINSERTの列リストの省略はStarがtrueの「すべての列」として表す
+type ColumnReference struct {
+	Table     *TableName
+	Column    Identifier
+	Star      bool
+	Operation Operation
+	Pos       int
+}
+
+// Columns は文が参照している実在のテーブルの列を操作の種類とともに出現順に返す
+// 修飾されていない列は、そのクエリのFROM句のテーブルが一つだけの場合に限りそのテーブルの列とみなす
+// CTEやサブクエリの列は実在のテーブルの列ではないので含めない
+func Columns(stmts ...Statement) []ColumnReference {
+	r := &resolver{}
+	for _, stmt := range stmts {
+		r.statement(stmt, nil)
+	}
+	sort.SliceStable(r.columns, func(i, j int) bool {
+		return r.columns[i].Pos < r.columns[j].Pos
+	})
+	return r.columns
+}
+
+// source はFROM句の一つのテーブル式と、列の修飾に使える名前
+// CTEやサブクエリなど実在のテーブルでない場合はtableがnil
+type source struct {
+	names map[string]bool
+	table *TableName
+}
+
+// sourceScope は列の解決に使うFROM句のテーブル式の集まり
+// parentは相関サブクエリから参照できる外側のクエリのスコープ
+type sourceScope struct {
+	sources []*source
+	parent  *sourceScope
+}
+
+func newSourceScope(parent *sourceScope) *sourceScope {
+	return &sourceScope{parent: parent}
+}
+
+// addTable はテーブル名を別名、別名がなければ名前と修飾名で参照できるようにする
+func (s *sourceScope) addTable(table *TableName, scope *cteScope) {
+	src := &source{names: make(map[string]bool), table: table}
+	if table.Alias != "" {
+		src.names[strings.ToLower(table.Alias)] = true
+	} else {
+		src.names[table.Name().Normalized()] = true
+		src.names[qualifiedName(table)] = true
+	}
+	if len(table.Parts) == 1 && scope.defines(table.Name().Normalized()) {
+		src.table = nil
+	}
+	s.sources = append(s.sources, src)
+}
+
+// addDerived はサブクエリやテーブル関数を別名で参照できるようにする
+func (s *sourceScope) addDerived(alias string) {
+	src := &source{names: make(map[string]bool)}
+	if alias != "" {
+		src.names[strings.ToLower(alias)] = true
+	}
+	s.sources = append(s.sources, src)
+}
+
+// lookup は修飾子が指すテーブル式を内側のスコープから順に探す
+func (s *sourceScope) lookup(qualifier []Identifier) *source {
+	name := qualifiedName(&TableName{Parts: qualifier})
+	for ; s != nil; s = s.parent {
+		for _, src := range s.sources {
+			if src.names[name] {
+				return src
+			}
+		}
+	}
+	return nil
+}
+
+// only は最も内側のスコープのテーブル式が一つだけの場合にそれを返す
+func (s *sourceScope) only() *source {
+	if s == nil || len(s.sources) != 1 {
+		return nil
+	}
+	return s.sources[0]
+}
+
+func (r *resolver) columnRef(col *ColumnRef, sources *sourceScope, op Operation) {
+	if sources == nil {
+		return
+	}
+	switch {
+	case col.Star && len(col.Parts) == 0:
+		for _, src := range sources.sources {
+			r.addSourceColumn(src, Identifier{}, true, op, col.Pos)
+		}
+	case col.Star:
+		r.addSourceColumn(sources.lookup(col.Parts), Identifier{}, true, op, col.Pos)
+	case len(col.Parts) == 1:
+		r.addSourceColumn(sources.only(), col.Parts[0], false, op, col.Pos)
+	default:
+		last := len(col.Parts) - 1
+		r.addSourceColumn(sources.lookup(col.Parts[:last]), col.Parts[last], false, op, col.Pos)
+	}
+}
+
+func (r *resolver) addSourceColumn(src *source, col Identifier, star bool, op Operation, pos int) {
+	if src == nil || src.table == nil {
+		return
+	}
+	r.columns = append(r.columns, ColumnReference{Table: src.table, Column: col, Star: star, Operation: op, Pos: pos})
+}
+
+// addColumn はINSERTの列リストのように対象テーブルが決まっている列を記録する
+func (r *resolver) addColumn(table *TableName, scope *cteScope, col Identifier, star bool, op Operation) {
+	if table == nil {
+		return
+	}
+	if len(table.Parts) == 1 && scope.defines(table.Name().Normalized()) {
+		return
+	}
+	r.columns = append(r.columns, ColumnReference{Table: table, Column: col, Star: star, Operation: op, Pos: table.Pos})
+}
+
+// assignedColumns はSET句の代入先の列を記録する
+// 修飾されていない列はtarget、修飾された列(SET o.status = ...)は修飾子が指すテーブルの列とみなす
+func (r *resolver) assignedColumns(assignment *Assignment, target *TableName, scope *cteScope, sources *sourceScope, op Operation) {
+	for _, col := range assignment.Columns {
+		if len(col.Parts) == 1 {
+			if target != nil && !(len(target.Parts) == 1 && scope.defines(target.Name().Normalized())) {
+				r.columns = append(r.columns, ColumnReference{Table: target, Column: col.Parts[0], Operation: op, Pos: col.Pos})
+			}
+			continue
+		}
+		last := len(col.Parts) - 1
+		r.addSourceColumn(sources.lookup(col.Parts[:last]), col.Parts[last], false, op, col.Pos)
+	}
+}
+
+// selectAliases はSELECT句の列の別名を集める
+func selectAliases(sel *Select) map[string]bool {
+	aliases := make(map[string]bool)
+	for _, item := range sel.Columns {
+		if item.Alias != "" {
+			aliases[strings.ToLower(item.Alias)] = true
+		}
+	}
+	return aliases
+}
+
+// withoutAliases はGROUP BYやORDER BYで使われたSELECT句の別名を列参照から取り除く
+func withoutAliases(expr *Expression, aliases map[string]bool) *Expression {
+	if expr == nil || len(aliases) == 0 {
+		return expr
+	}
+	filtered := &Expression{Subqueries: expr.Subqueries}
+	for _, col := range expr.Columns {
+		if !col.Star && len(col.Parts) == 1 && aliases[col.Parts[0].Normalized()] {
+			continue
+		}
+		filtered.Columns = append(filtered.Columns, col)
+	}
+	return filtered
+}
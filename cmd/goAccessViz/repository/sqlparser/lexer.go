@@ -0,0 +1,272 @@
+package sqlparser
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TokenKind はトークンの種類
+type TokenKind int
+
+const (
+	TokenEOF TokenKind = iota
+	// TokenIdent はクォートされていない単語(キーワードを含む)
+	TokenIdent
+	// TokenQuotedIdent は"name"や`name`のようにクォートされた識別子
+	TokenQuotedIdent
+	TokenString
+	TokenNumber
+	// TokenParam は?, $1, :name, @nameのようなプレースホルダ
+	TokenParam
+	// TokenPunct は括弧や演算子などの記号
+	TokenPunct
+)
+
+// Token はSQL文字列の字句
+// Valueはクォートを外した値、Posは元の文字列でのバイト位置
+type Token struct {
+	Kind  TokenKind
+	Text  string
+	Value string
+	Pos   int
+}
+
+func (t Token) String() string {
+	if t.Kind == TokenEOF {
+		return "end of input"
+	}
+	return fmt.Sprintf("%q at %d", t.Text, t.Pos)
+}
+
+// Placeholder はGoのコードで組み立てたSQLのうち、実行時まで分からない部分を表す文字
+// 識別子の一部として読むため、"audit_" + suffixのようなテーブル名はパターンとして残る
+const Placeholder = "\uE000"
+
+const placeholderRune = '\uE000'
+
+// 複数文字からなる演算子(長いものから照合する)
+var multiCharOperators = []string{"->>", "::", "<=", ">=", "<>", "!=", "||", "->", "#>", "@>", "<@", "&&"}
+
+// Tokenize はSQL文字列をトークン列に分割する
+// コメントと空白は捨て、末尾には必ずTokenEOFを置く
+func Tokenize(sql string) ([]Token, error) {
+	l := &lexer{src: sql}
+	var tokens []Token
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return tokens, err
+		}
+		tokens = append(tokens, tok)
+		if tok.Kind == TokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+func (l *lexer) peekRune(offset int) rune {
+	if l.pos+offset >= len(l.src) {
+		return 0
+	}
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos+offset:])
+	return r
+}
+
+func (l *lexer) next() (Token, error) {
+	l.skipSpaceAndComments()
+	if l.pos >= len(l.src) {
+		return Token{Kind: TokenEOF, Pos: l.pos}, nil
+	}
+
+	start := l.pos
+	r := l.peekRune(0)
+	switch {
+	case r == '\'':
+		return l.readString(start, start)
+	case (r == 'E' || r == 'e' || r == 'N' || r == 'n' || r == 'B' || r == 'b' || r == 'X' || r == 'x') && l.peekRune(1) == '\'':
+		return l.readString(start, start+1)
+	case r == '"' || r == '`':
+		return l.readQuotedIdent(start, byte(r))
+	case isIdentStart(r):
+		return l.readIdent(start), nil
+	case isDigit(r) || (r == '.' && isDigit(l.peekRune(1))):
+		return l.readNumber(start), nil
+	case r == '?':
+		l.pos++
+		return Token{Kind: TokenParam, Text: "?", Value: "?", Pos: start}, nil
+	case r == '$':
+		return l.readDollar(start)
+	case (r == ':' || r == '@') && isIdentStart(l.peekRune(1)):
+		l.pos++
+		ident := l.readIdent(l.pos)
+		text := l.src[start:l.pos]
+		return Token{Kind: TokenParam, Text: text, Value: ident.Text, Pos: start}, nil
+	}
+
+	for _, op := range multiCharOperators {
+		if strings.HasPrefix(l.src[l.pos:], op) {
+			l.pos += len(op)
+			return Token{Kind: TokenPunct, Text: op, Value: op, Pos: start}, nil
+		}
+	}
+	_, size := utf8.DecodeRuneInString(l.src[l.pos:])
+	l.pos += size
+	text := l.src[start:l.pos]
+	return Token{Kind: TokenPunct, Text: text, Value: text, Pos: start}, nil
+}
+
+func (l *lexer) skipSpaceAndComments() {
+	for l.pos < len(l.src) {
+		r := l.peekRune(0)
+		switch {
+		case unicode.IsSpace(r):
+			_, size := utf8.DecodeRuneInString(l.src[l.pos:])
+			l.pos += size
+		case strings.HasPrefix(l.src[l.pos:], "--"):
+			end := strings.IndexByte(l.src[l.pos:], '\n')
+			if end < 0 {
+				l.pos = len(l.src)
+			} else {
+				l.pos += end + 1
+			}
+		case strings.HasPrefix(l.src[l.pos:], "/*"):
+			end := strings.Index(l.src[l.pos+2:], "*/")
+			if end < 0 {
+				l.pos = len(l.src)
+			} else {
+				l.pos += end + 4
+			}
+		default:
+			return
+		}
+	}
+}
+
+// readString は'...'を読む。引用符の二重化とバックスラッシュによるエスケープを受け付ける
+func (l *lexer) readString(start, quote int) (Token, error) {
+	var value strings.Builder
+	l.pos = quote + 1
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\\' && l.pos+1 < len(l.src):
+			value.WriteByte(l.src[l.pos+1])
+			l.pos += 2
+		case c == '\'' && l.pos+1 < len(l.src) && l.src[l.pos+1] == '\'':
+			value.WriteByte('\'')
+			l.pos += 2
+		case c == '\'':
+			l.pos++
+			return Token{Kind: TokenString, Text: l.src[start:l.pos], Value: value.String(), Pos: start}, nil
+		default:
+			value.WriteByte(c)
+			l.pos++
+		}
+	}
+	return Token{}, fmt.Errorf("unterminated string starting at %d", start)
+}
+
+// readQuotedIdent は"name"や`name`を読む。クォートを二重にするとエスケープになる
+func (l *lexer) readQuotedIdent(start int, quote byte) (Token, error) {
+	var value strings.Builder
+	l.pos = start + 1
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c == quote {
+			if l.pos+1 < len(l.src) && l.src[l.pos+1] == quote {
+				value.WriteByte(quote)
+				l.pos += 2
+				continue
+			}
+			l.pos++
+			return Token{Kind: TokenQuotedIdent, Text: l.src[start:l.pos], Value: value.String(), Pos: start}, nil
+		}
+		value.WriteByte(c)
+		l.pos++
+	}
+	return Token{}, fmt.Errorf("unterminated quoted identifier starting at %d", start)
+}
+
+func (l *lexer) readIdent(start int) Token {
+	l.pos = start
+	for l.pos < len(l.src) {
+		r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+		if !isIdentPart(r) {
+			break
+		}
+		l.pos += size
+	}
+	text := l.src[start:l.pos]
+	return Token{Kind: TokenIdent, Text: text, Value: text, Pos: start}
+}
+
+func (l *lexer) readNumber(start int) Token {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if isDigit(rune(c)) || c == '.' {
+			l.pos++
+			continue
+		}
+		if (c == 'e' || c == 'E') && l.pos+1 < len(l.src) {
+			next := l.src[l.pos+1]
+			if isDigit(rune(next)) || next == '+' || next == '-' {
+				l.pos += 2
+				continue
+			}
+		}
+		break
+	}
+	text := l.src[start:l.pos]
+	return Token{Kind: TokenNumber, Text: text, Value: text, Pos: start}
+}
+
+// readDollar は$1のようなプレースホルダと$$...$$、$tag$...$tag$の文字列を読む
+func (l *lexer) readDollar(start int) (Token, error) {
+	if isDigit(l.peekRune(1)) {
+		l.pos++
+		for l.pos < len(l.src) && isDigit(rune(l.src[l.pos])) {
+			l.pos++
+		}
+		text := l.src[start:l.pos]
+		return Token{Kind: TokenParam, Text: text, Value: text, Pos: start}, nil
+	}
+
+	end := strings.IndexByte(l.src[start+1:], '$')
+	if end < 0 {
+		l.pos++
+		return Token{Kind: TokenPunct, Text: "$", Value: "$", Pos: start}, nil
+	}
+	tag := l.src[start : start+end+2]
+	for _, r := range tag[1 : len(tag)-1] {
+		if !isIdentPart(r) {
+			l.pos++
+			return Token{Kind: TokenPunct, Text: "$", Value: "$", Pos: start}, nil
+		}
+	}
+	bodyStart := start + len(tag)
+	bodyEnd := strings.Index(l.src[bodyStart:], tag)
+	if bodyEnd < 0 {
+		return Token{}, fmt.Errorf("unterminated dollar-quoted string starting at %d", start)
+	}
+	l.pos = bodyStart + bodyEnd + len(tag)
+	return Token{Kind: TokenString, Text: l.src[start:l.pos], Value: l.src[bodyStart : bodyStart+bodyEnd], Pos: start}, nil
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || r == placeholderRune || unicode.IsLetter(r)
+}
+
+func isIdentPart(r rune) bool {
+	return r == '_' || r == '$' || r == placeholderRune || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
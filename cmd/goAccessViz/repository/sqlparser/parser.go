@@ -0,0 +1,1321 @@
+package sqlparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// reservedKeywords は列名や別名として扱わない単語
+var reservedKeywords = toSet(
+	"ALL", "AND", "ANY", "ARRAY", "AS", "ASC", "AT", "BETWEEN", "BOTH", "BY",
+	"CASE", "CAST", "COLLATE", "CONFLICT", "CROSS", "CURRENT", "CURRENT_DATE",
+	"CURRENT_TIME", "CURRENT_TIMESTAMP", "CURRENT_USER", "DEFAULT", "DELETE",
+	"DESC", "DISTINCT", "DO", "ELSE", "END", "ESCAPE", "EXCEPT", "EXISTS",
+	"FALSE", "FETCH", "FILTER", "FOLLOWING", "FOR", "FORCE", "FROM", "FULL",
+	"GROUP", "HAVING", "IGNORE", "ILIKE", "IN", "INNER", "INSERT", "INTERSECT",
+	"INTERVAL", "INTO", "IS", "ISNULL", "JOIN", "LATERAL", "LEADING", "LEFT",
+	"LIKE", "LIMIT", "LOCALTIME", "LOCALTIMESTAMP", "LOCK", "MATCHED", "MERGE",
+	"MINUS", "NATURAL", "NOT", "NOTHING", "NOTNULL", "NULL", "NULLS", "OFFSET",
+	"ON", "ONLY", "OR", "ORDER", "OUTER", "OVER", "PARTITION", "PRECEDING",
+	"QUALIFY", "RECURSIVE", "REGEXP", "RETURNING", "RIGHT", "RLIKE", "SELECT",
+	"SESSION_USER", "SET", "SIMILAR", "SOME", "STRAIGHT_JOIN", "SYMMETRIC",
+	"TABLESAMPLE", "THEN", "TO", "TRAILING", "TRUE", "UNBOUNDED", "UNION",
+	"UNKNOWN", "UPDATE", "USE", "USING", "VALUES", "WHEN", "WHERE", "WINDOW",
+	"WITH", "WITHIN",
+)
+
+// clauseKeywords は括弧の外で式の終わりを示す単語
+var clauseKeywords = toSet(
+	"AS", "CROSS", "DO", "EXCEPT", "FETCH", "FOR", "FROM", "FULL", "GROUP",
+	"HAVING", "INNER", "INTERSECT", "INTO", "JOIN", "LEFT", "LIMIT", "LOCK",
+	"MINUS", "NATURAL", "OFFSET", "ON", "ORDER", "OUTER", "QUALIFY",
+	"RETURNING", "RIGHT", "SET", "STRAIGHT_JOIN", "THEN", "UNION", "USING",
+	"WHEN", "WHERE", "WINDOW",
+)
+
+// operandKeywords は値として式の項になる単語
+var operandKeywords = toSet(
+	"CURRENT_DATE", "CURRENT_TIME", "CURRENT_TIMESTAMP", "CURRENT_USER",
+	"DEFAULT", "END", "FALSE", "LOCALTIME", "LOCALTIMESTAMP", "NULL",
+	"SESSION_USER", "TRUE", "UNKNOWN",
+)
+
+// functionKeywords は予約語だが関数としても呼び出せる単語
+var functionKeywords = toSet("CAST", "LEFT", "RIGHT")
+
+// intervalUnits はINTERVAL '1' DAYのような単位
+var intervalUnits = toSet(
+	"YEAR", "MONTH", "WEEK", "DAY", "HOUR", "MINUTE", "SECOND", "MICROSECOND",
+)
+
+func toSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// Parse はセミコロンで区切られたSQLを構文解析する
+// 解析できなかった文は結果から除き、最初のエラーを返す
+func Parse(sql string) ([]Statement, error) {
+	tokens, err := Tokenize(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []Statement
+	var firstErr error
+	for _, chunk := range splitStatements(tokens) {
+		p := &parser{tokens: chunk}
+		stmt, err := p.parseStatement()
+		if err == nil && !p.atEOF() {
+			err = fmt.Errorf("unexpected %s", p.peek())
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		statements = append(statements, stmt)
+	}
+	return statements, firstErr
+}
+
+// splitStatements は括弧の外にあるセミコロンでトークン列を分割する
+// 各断片の末尾にはTokenEOFを置く
+func splitStatements(tokens []Token) [][]Token {
+	var chunks [][]Token
+	var current []Token
+	depth := 0
+	for _, tok := range tokens {
+		switch {
+		case tok.Kind == TokenPunct && tok.Text == "(":
+			depth++
+		case tok.Kind == TokenPunct && tok.Text == ")" && depth > 0:
+			depth--
+		case tok.Kind == TokenEOF || (tok.Kind == TokenPunct && tok.Text == ";" && depth == 0):
+			if len(current) > 0 {
+				chunks = append(chunks, append(current, Token{Kind: TokenEOF, Pos: tok.Pos}))
+			}
+			current = nil
+			continue
+		}
+		current = append(current, tok)
+	}
+	return chunks
+}
+
+type parser struct {
+	tokens []Token
+	pos    int
+}
+
+func (p *parser) peek() Token {
+	return p.peekAt(0)
+}
+
+func (p *parser) peekAt(offset int) Token {
+	if p.pos+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *parser) next() Token {
+	tok := p.peek()
+	if p.pos < len(p.tokens)-1 {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) atEOF() bool {
+	return p.peek().Kind == TokenEOF
+}
+
+func isKeyword(tok Token, keywords ...string) bool {
+	if tok.Kind != TokenIdent {
+		return false
+	}
+	for _, kw := range keywords {
+		if strings.EqualFold(tok.Text, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+func isPunct(tok Token, text string) bool {
+	return tok.Kind == TokenPunct && tok.Text == text
+}
+
+func keywordOf(tok Token) string {
+	if tok.Kind != TokenIdent {
+		return ""
+	}
+	return strings.ToUpper(tok.Text)
+}
+
+func (p *parser) acceptKeyword(keywords ...string) bool {
+	if isKeyword(p.peek(), keywords...) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(keyword string) error {
+	if !p.acceptKeyword(keyword) {
+		return fmt.Errorf("expected %s, got %s", keyword, p.peek())
+	}
+	return nil
+}
+
+func (p *parser) acceptPunct(text string) bool {
+	if isPunct(p.peek(), text) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectPunct(text string) error {
+	if !p.acceptPunct(text) {
+		return fmt.Errorf("expected %q, got %s", text, p.peek())
+	}
+	return nil
+}
+
+// startsQuery はoffsetの位置(開き括弧は読み飛ばす)からSELECTなどのクエリが始まるか判定する
+func (p *parser) startsQuery(offset int) bool {
+	for isPunct(p.peekAt(offset), "(") {
+		offset++
+	}
+	return isKeyword(p.peekAt(offset), "SELECT", "WITH", "VALUES", "TABLE")
+}
+
+func (p *parser) parseStatement() (Statement, error) {
+	var with *With
+	if isKeyword(p.peek(), "WITH") {
+		var err error
+		if with, err = p.parseWith(); err != nil {
+			return nil, err
+		}
+	}
+
+	tok := p.peek()
+	switch {
+	case isKeyword(tok, "INSERT", "REPLACE"):
+		return p.parseInsert(with)
+	case isKeyword(tok, "UPDATE"):
+		return p.parseUpdate(with)
+	case isKeyword(tok, "DELETE"):
+		return p.parseDelete(with)
+	case isKeyword(tok, "MERGE"):
+		return p.parseMerge(with)
+	case isKeyword(tok, "TRUNCATE") && with == nil:
+		return p.parseTruncate()
+	case p.startsQuery(0):
+		query, err := p.parseQueryWith(with)
+		if err != nil {
+			return nil, err
+		}
+		return &SelectStatement{Query: query}, nil
+	}
+	return nil, fmt.Errorf("unsupported statement starting with %s", tok)
+}
+
+func (p *parser) parseWith() (*With, error) {
+	if err := p.expectKeyword("WITH"); err != nil {
+		return nil, err
+	}
+	with := &With{Recursive: p.acceptKeyword("RECURSIVE")}
+	for {
+		cte := &CommonTableExpression{}
+		name, err := p.parseIdentifier()
+		if err != nil {
+			return nil, err
+		}
+		cte.Name = name
+		if isPunct(p.peek(), "(") {
+			if cte.Columns, err = p.parseIdentifierList(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expectKeyword("AS"); err != nil {
+			return nil, err
+		}
+		p.acceptKeyword("NOT")
+		p.acceptKeyword("MATERIALIZED")
+		if err := p.expectPunct("("); err != nil {
+			return nil, err
+		}
+		if cte.Statement, err = p.parseStatement(); err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		// PostgreSQLのSEARCH ... SET ...やCYCLE ... SET ... USING ...は読み飛ばす
+		for p.acceptKeyword("SEARCH", "CYCLE") {
+			p.parseExpression(false)
+			for p.acceptKeyword("SET", "USING", "TO", "DEFAULT") {
+				p.parseExpression(false)
+			}
+		}
+		with.CTEs = append(with.CTEs, cte)
+		if !p.acceptPunct(",") {
+			return with, nil
+		}
+	}
+}
+
+func (p *parser) parseQuery() (*Query, error) {
+	var with *With
+	if isKeyword(p.peek(), "WITH") {
+		var err error
+		if with, err = p.parseWith(); err != nil {
+			return nil, err
+		}
+	}
+	return p.parseQueryWith(with)
+}
+
+func (p *parser) parseQueryWith(with *With) (*Query, error) {
+	query := &Query{With: with}
+	for {
+		term, err := p.parseQueryTerm()
+		if err != nil {
+			return nil, err
+		}
+		query.Selects = append(query.Selects, term)
+		if !p.acceptKeyword("UNION", "INTERSECT", "EXCEPT", "MINUS") {
+			break
+		}
+		p.acceptKeyword("ALL", "DISTINCT")
+	}
+
+	for {
+		switch {
+		case isKeyword(p.peek(), "ORDER") && isKeyword(p.peekAt(1), "BY"):
+			p.pos += 2
+			query.OrderBy = append(query.OrderBy, p.parseExpressionList()...)
+		case p.acceptKeyword("LIMIT", "OFFSET"):
+			query.Limit = append(query.Limit, p.parseExpressionList()...)
+			p.acceptKeyword("ROW", "ROWS")
+		case isKeyword(p.peek(), "FETCH", "FOR", "LOCK"):
+			// FETCH FIRST n ROWS ONLYとFOR UPDATE OF t, LOCK IN SHARE MODEなどのロック句
+			p.next()
+			p.parseExpressionList()
+		default:
+			return query, nil
+		}
+	}
+}
+
+func (p *parser) parseQueryTerm() (QueryTerm, error) {
+	switch tok := p.peek(); {
+	case isKeyword(tok, "SELECT"):
+		return p.parseSelect()
+	case isKeyword(tok, "VALUES"):
+		return p.parseValues()
+	case isKeyword(tok, "TABLE"):
+		// TABLE nameはSELECT * FROM nameの省略形
+		p.next()
+		table, err := p.parseTableName()
+		if err != nil {
+			return nil, err
+		}
+		return &Select{
+			Columns: []*SelectItem{{Expr: &Expression{Columns: []*ColumnRef{{Star: true, Pos: tok.Pos}}}}},
+			From:    []TableExpr{table},
+		}, nil
+	case isPunct(tok, "("):
+		p.next()
+		query, err := p.parseQuery()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		return query, nil
+	default:
+		return nil, fmt.Errorf("expected SELECT, got %s", tok)
+	}
+}
+
+func (p *parser) parseSelect() (*Select, error) {
+	if err := p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+	sel := &Select{}
+	p.acceptKeyword("ALL")
+	if p.acceptKeyword("DISTINCT") {
+		if p.acceptKeyword("ON") {
+			expr, err := p.parseParenExpression()
+			if err != nil {
+				return nil, err
+			}
+			sel.Distinct = append(sel.Distinct, expr)
+		}
+	}
+	if p.acceptKeyword("TOP") {
+		if isPunct(p.peek(), "(") {
+			p.parseParenExpression()
+		} else {
+			p.next()
+		}
+	}
+
+	for {
+		start := p.pos
+		item := &SelectItem{Expr: p.parseExpression(true)}
+		if p.pos == start {
+			return nil, fmt.Errorf("expected select item, got %s", p.peek())
+		}
+		alias, err := p.parseAlias()
+		if err != nil {
+			return nil, err
+		}
+		item.Alias = alias
+		sel.Columns = append(sel.Columns, item)
+		if !p.acceptPunct(",") {
+			break
+		}
+	}
+
+	// SELECT ... INTO varは結果の格納先なので読み飛ばす
+	if p.acceptKeyword("INTO") {
+		p.parseExpressionList()
+	}
+
+	if p.acceptKeyword("FROM") {
+		from, err := p.parseTableExprList()
+		if err != nil {
+			return nil, err
+		}
+		sel.From = from
+	}
+	if p.acceptKeyword("WHERE") {
+		sel.Where = p.parseExpression(false)
+	}
+	if isKeyword(p.peek(), "GROUP") && isKeyword(p.peekAt(1), "BY") {
+		p.pos += 2
+		sel.GroupBy = p.parseExpressionList()
+	}
+	if p.acceptKeyword("HAVING") {
+		sel.Having = p.parseExpression(false)
+	}
+	if p.acceptKeyword("WINDOW") {
+		sel.Window = p.parseExpressionList()
+	}
+	if p.acceptKeyword("QUALIFY") {
+		sel.Where = mergeExpressions(sel.Where, p.parseExpression(false))
+	}
+	return sel, nil
+}
+
+func (p *parser) parseValues() (*Values, error) {
+	if !p.acceptKeyword("VALUES", "VALUE") {
+		return nil, fmt.Errorf("expected VALUES, got %s", p.peek())
+	}
+	values := &Values{}
+	for {
+		p.acceptKeyword("ROW")
+		if err := p.expectPunct("("); err != nil {
+			return nil, err
+		}
+		var row []*Expression
+		if !isPunct(p.peek(), ")") {
+			row = p.parseExpressionList()
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		values.Rows = append(values.Rows, row)
+		if !p.acceptPunct(",") {
+			return values, nil
+		}
+	}
+}
+
+func (p *parser) parseInsert(with *With) (*InsertStatement, error) {
+	stmt := &InsertStatement{With: with, Replace: isKeyword(p.next(), "REPLACE")}
+	for p.acceptKeyword("IGNORE", "LOW_PRIORITY", "HIGH_PRIORITY", "DELAYED", "OR") {
+		// SQLiteのINSERT OR REPLACE/IGNORE
+		p.acceptKeyword("REPLACE", "ROLLBACK", "ABORT", "FAIL")
+	}
+	p.acceptKeyword("INTO")
+
+	table, err := p.parseTableName()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Table = table
+	if p.acceptKeyword("AS") {
+		if stmt.Table.Alias, err = p.parseAliasName(); err != nil {
+			return nil, err
+		}
+	}
+	if isPunct(p.peek(), "(") && !p.startsQuery(1) {
+		if stmt.Columns, err = p.parseIdentifierList(); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case isKeyword(p.peek(), "DEFAULT") && isKeyword(p.peekAt(1), "VALUES"):
+		p.pos += 2
+	case p.acceptKeyword("SET"):
+		if stmt.Set, err = p.parseAssignments(); err != nil {
+			return nil, err
+		}
+	case isKeyword(p.peek(), "VALUE"):
+		// MySQLではVALUESの代わりにVALUEとも書ける
+		values, err := p.parseValues()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Source = &Query{Selects: []QueryTerm{values}}
+	default:
+		if stmt.Source, err = p.parseQuery(); err != nil {
+			return nil, err
+		}
+	}
+
+	// MySQLのVALUES (...) AS new ON DUPLICATE KEY UPDATE ...
+	if isKeyword(p.peek(), "AS") {
+		p.next()
+		if _, err := p.parseAliasName(); err != nil {
+			return nil, err
+		}
+		if isPunct(p.peek(), "(") {
+			if _, err := p.parseIdentifierList(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if isKeyword(p.peek(), "ON") && isKeyword(p.peekAt(1), "CONFLICT", "DUPLICATE") {
+		if stmt.OnConflict, err = p.parseOnConflict(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("RETURNING") {
+		stmt.Returning = p.parseExpressionList()
+	}
+	return stmt, nil
+}
+
+func (p *parser) parseOnConflict() (*OnConflict, error) {
+	p.next() // ON
+	onConflict := &OnConflict{}
+	if p.acceptKeyword("DUPLICATE") {
+		p.acceptKeyword("KEY")
+		if err := p.expectKeyword("UPDATE"); err != nil {
+			return nil, err
+		}
+		assignments, err := p.parseAssignments()
+		onConflict.Assignment = assignments
+		return onConflict, err
+	}
+
+	p.next() // CONFLICT
+	switch {
+	case isPunct(p.peek(), "("):
+		target, err := p.parseParenExpression()
+		if err != nil {
+			return nil, err
+		}
+		onConflict.Target = append(onConflict.Target, target)
+		if p.acceptKeyword("WHERE") {
+			onConflict.Target = append(onConflict.Target, p.parseExpression(false))
+		}
+	case isKeyword(p.peek(), "ON") && isKeyword(p.peekAt(1), "CONSTRAINT"):
+		p.pos += 2
+		if _, err := p.parseIdentifier(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expectKeyword("DO"); err != nil {
+		return nil, err
+	}
+	if p.acceptKeyword("NOTHING") {
+		onConflict.DoNothing = true
+		return onConflict, nil
+	}
+	if err := p.expectKeyword("UPDATE"); err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("SET"); err != nil {
+		return nil, err
+	}
+	assignments, err := p.parseAssignments()
+	if err != nil {
+		return nil, err
+	}
+	onConflict.Assignment = assignments
+	if p.acceptKeyword("WHERE") {
+		onConflict.Where = p.parseExpression(false)
+	}
+	return onConflict, nil
+}
+
+func (p *parser) parseUpdate(with *With) (*UpdateStatement, error) {
+	p.next() // UPDATE
+	stmt := &UpdateStatement{With: with}
+	for p.acceptKeyword("LOW_PRIORITY", "IGNORE", "OR") {
+		p.acceptKeyword("REPLACE", "ROLLBACK", "ABORT", "FAIL")
+	}
+
+	tables, err := p.parseTableExprList()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Tables = tables
+	if err := p.expectKeyword("SET"); err != nil {
+		return nil, err
+	}
+	if stmt.Set, err = p.parseAssignments(); err != nil {
+		return nil, err
+	}
+	if p.acceptKeyword("FROM") {
+		if stmt.From, err = p.parseTableExprList(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("WHERE") {
+		stmt.Where = p.parseExpression(false)
+	}
+	stmt.OrderBy, stmt.Returning = p.parseTrailingClauses()
+	return stmt, nil
+}
+
+func (p *parser) parseDelete(with *With) (*DeleteStatement, error) {
+	p.next() // DELETE
+	stmt := &DeleteStatement{With: with}
+	for p.acceptKeyword("LOW_PRIORITY", "QUICK", "IGNORE") {
+		// 修飾子は解析に影響しない
+	}
+
+	// MySQLのDELETE t1, t2 FROM ...やDELETE users WHERE ...(FROMの省略)
+	if !isKeyword(p.peek(), "FROM") {
+		for {
+			target, err := p.parseTableName()
+			if err != nil {
+				return nil, err
+			}
+			if isPunct(p.peek(), ".") && isPunct(p.peekAt(1), "*") {
+				p.pos += 2
+			}
+			stmt.Targets = append(stmt.Targets, target)
+			if !p.acceptPunct(",") {
+				break
+			}
+		}
+	}
+
+	var err error
+	if p.acceptKeyword("FROM") {
+		if stmt.From, err = p.parseTableExprList(); err != nil {
+			return nil, err
+		}
+	} else {
+		// 削除対象だけが書かれている場合はそれを読み取り元とみなす
+		if len(stmt.Targets) == 1 {
+			if stmt.Targets[0].Alias, err = p.parseAlias(); err != nil {
+				return nil, err
+			}
+		}
+		for _, target := range stmt.Targets {
+			stmt.From = append(stmt.From, target)
+		}
+		stmt.Targets = nil
+	}
+	if p.acceptKeyword("USING") {
+		if stmt.Using, err = p.parseTableExprList(); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("WHERE") {
+		stmt.Where = p.parseExpression(false)
+	}
+	stmt.OrderBy, stmt.Returning = p.parseTrailingClauses()
+	return stmt, nil
+}
+
+// parseTrailingClauses はUPDATEとDELETEの末尾のORDER BY, LIMIT, RETURNINGを読む
+func (p *parser) parseTrailingClauses() (orderBy, returning []*Expression) {
+	for {
+		switch {
+		case isKeyword(p.peek(), "ORDER") && isKeyword(p.peekAt(1), "BY"):
+			p.pos += 2
+			orderBy = append(orderBy, p.parseExpressionList()...)
+		case p.acceptKeyword("LIMIT"):
+			p.parseExpressionList()
+		case p.acceptKeyword("RETURNING"):
+			returning = append(returning, p.parseExpressionList()...)
+		default:
+			return orderBy, returning
+		}
+	}
+}
+
+func (p *parser) parseTruncate() (*TruncateStatement, error) {
+	p.next() // TRUNCATE
+	p.acceptKeyword("TABLE")
+	stmt := &TruncateStatement{}
+	for {
+		p.acceptKeyword("ONLY")
+		table, err := p.parseTableName()
+		if err != nil {
+			return nil, err
+		}
+		p.acceptPunct("*")
+		stmt.Tables = append(stmt.Tables, table)
+		if !p.acceptPunct(",") {
+			break
+		}
+	}
+	// RESTART IDENTITY, CASCADEなどのオプション
+	for p.peek().Kind == TokenIdent {
+		p.next()
+	}
+	return stmt, nil
+}
+
+func (p *parser) parseMerge(with *With) (*MergeStatement, error) {
+	p.next() // MERGE
+	p.acceptKeyword("INTO")
+	stmt := &MergeStatement{With: with}
+
+	target, err := p.parseTableName()
+	if err != nil {
+		return nil, err
+	}
+	if target.Alias, err = p.parseAlias(); err != nil {
+		return nil, err
+	}
+	stmt.Target = target
+	if err := p.expectKeyword("USING"); err != nil {
+		return nil, err
+	}
+	if stmt.Source, err = p.parseTablePrimary(); err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("ON"); err != nil {
+		return nil, err
+	}
+	stmt.On = p.parseExpression(false)
+
+	for p.acceptKeyword("WHEN") {
+		clause := &MergeClause{Matched: !p.acceptKeyword("NOT")}
+		if err := p.expectKeyword("MATCHED"); err != nil {
+			return nil, err
+		}
+		if p.acceptKeyword("BY") {
+			p.acceptKeyword("TARGET", "SOURCE")
+		}
+		if p.acceptKeyword("AND") {
+			clause.Condition = p.parseExpression(false)
+		}
+		if err := p.expectKeyword("THEN"); err != nil {
+			return nil, err
+		}
+
+		switch action := p.next(); {
+		case isKeyword(action, "UPDATE"):
+			clause.Action = "UPDATE"
+			if err := p.expectKeyword("SET"); err != nil {
+				return nil, err
+			}
+			if clause.Set, err = p.parseAssignments(); err != nil {
+				return nil, err
+			}
+		case isKeyword(action, "DELETE"):
+			clause.Action = "DELETE"
+		case isKeyword(action, "INSERT"):
+			clause.Action = "INSERT"
+			if isPunct(p.peek(), "(") {
+				if clause.Columns, err = p.parseIdentifierList(); err != nil {
+					return nil, err
+				}
+			}
+			if isKeyword(p.peek(), "DEFAULT") {
+				p.pos += 2
+				break
+			}
+			values, err := p.parseValues()
+			if err != nil {
+				return nil, err
+			}
+			for _, row := range values.Rows {
+				clause.Values = append(clause.Values, row...)
+			}
+		case isKeyword(action, "DO"):
+			clause.Action = "NOTHING"
+			if err := p.expectKeyword("NOTHING"); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unexpected MERGE action %s", action)
+		}
+		stmt.Clauses = append(stmt.Clauses, clause)
+	}
+	return stmt, nil
+}
+
+func (p *parser) parseAssignments() ([]*Assignment, error) {
+	var assignments []*Assignment
+	for {
+		assignment := &Assignment{}
+		if p.acceptPunct("(") {
+			for {
+				col, err := p.parseColumnRef()
+				if err != nil {
+					return nil, err
+				}
+				assignment.Columns = append(assignment.Columns, col)
+				if !p.acceptPunct(",") {
+					break
+				}
+			}
+			if err := p.expectPunct(")"); err != nil {
+				return nil, err
+			}
+		} else {
+			col, err := p.parseColumnRef()
+			if err != nil {
+				return nil, err
+			}
+			assignment.Columns = append(assignment.Columns, col)
+		}
+		if err := p.expectPunct("="); err != nil {
+			return nil, err
+		}
+		assignment.Value = p.parseExpression(false)
+		assignments = append(assignments, assignment)
+		if !p.acceptPunct(",") {
+			return assignments, nil
+		}
+	}
+}
+
+func (p *parser) parseColumnRef() (*ColumnRef, error) {
+	pos := p.peek().Pos
+	parts, err := p.parseQualifiedName()
+	if err != nil {
+		return nil, err
+	}
+	return &ColumnRef{Parts: parts, Pos: pos}, nil
+}
+
+func (p *parser) parseTableExprList() ([]TableExpr, error) {
+	var exprs []TableExpr
+	for {
+		expr, err := p.parseTableExpr()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+		if !p.acceptPunct(",") {
+			return exprs, nil
+		}
+	}
+}
+
+func (p *parser) parseTableExpr() (TableExpr, error) {
+	left, err := p.parseTablePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		kind, ok := p.parseJoinKind()
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseTablePrimary()
+		if err != nil {
+			return nil, err
+		}
+		join := &Join{Left: left, Right: right, Kind: kind}
+		switch {
+		case p.acceptKeyword("ON"):
+			join.On = p.parseExpression(false)
+		case p.acceptKeyword("USING"):
+			if join.Using, err = p.parseIdentifierList(); err != nil {
+				return nil, err
+			}
+		}
+		left = join
+	}
+}
+
+// parseJoinKind はJOINキーワードの並びを読み、結合の種類を返す
+func (p *parser) parseJoinKind() (string, bool) {
+	start := p.pos
+	var words []string
+	for {
+		tok := p.peek()
+		switch {
+		case isKeyword(tok, "NATURAL", "INNER", "LEFT", "RIGHT", "FULL", "OUTER", "CROSS", "SEMI", "ANTI"):
+			words = append(words, keywordOf(tok))
+			p.next()
+		case isKeyword(tok, "JOIN", "STRAIGHT_JOIN"):
+			p.next()
+			if len(words) == 0 {
+				return keywordOf(tok), true
+			}
+			return strings.Join(words, " ") + " JOIN", true
+		case isKeyword(tok, "APPLY") && len(words) > 0:
+			// SQL ServerのCROSS APPLY/OUTER APPLY
+			p.next()
+			return strings.Join(words, " ") + " APPLY", true
+		default:
+			p.pos = start
+			return "", false
+		}
+	}
+}
+
+func (p *parser) parseTablePrimary() (TableExpr, error) {
+	lateral := p.acceptKeyword("LATERAL")
+	tok := p.peek()
+
+	switch {
+	case isPunct(tok, "(") && p.startsQuery(1):
+		p.next()
+		query, err := p.parseQuery()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		alias, err := p.parseTableAlias()
+		if err != nil {
+			return nil, err
+		}
+		return &DerivedTable{Query: query, Alias: alias, Lateral: lateral}, nil
+
+	case isPunct(tok, "("):
+		p.next()
+		inner, err := p.parseTableExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		if _, err := p.parseTableAlias(); err != nil {
+			return nil, err
+		}
+		return &ParenTableExpr{Expr: inner}, nil
+	}
+
+	only := p.acceptKeyword("ONLY")
+	name, err := p.parseTableName()
+	if err != nil {
+		return nil, err
+	}
+	name.Only = only
+
+	if isPunct(p.peek(), "(") {
+		args, err := p.parseParenExpression()
+		if err != nil {
+			return nil, err
+		}
+		p.skipWithOrdinality()
+		alias, err := p.parseTableAlias()
+		if err != nil {
+			return nil, err
+		}
+		return &TableFunction{Name: name.Parts, Args: args, Alias: alias, Lateral: lateral}, nil
+	}
+
+	// PostgreSQLのFROM ONLY t *は継承テーブルを含める指定
+	p.acceptPunct("*")
+	if name.Alias, err = p.parseTableAlias(); err != nil {
+		return nil, err
+	}
+	p.skipTableHints()
+	return name, nil
+}
+
+func (p *parser) skipWithOrdinality() {
+	if isKeyword(p.peek(), "WITH") && isKeyword(p.peekAt(1), "ORDINALITY") {
+		p.pos += 2
+	}
+}
+
+// skipTableHints はMySQLのUSE INDEX (...)やSQL ServerのWITH (NOLOCK)、TABLESAMPLEを読み飛ばす
+func (p *parser) skipTableHints() {
+	for {
+		switch {
+		case isKeyword(p.peek(), "USE", "FORCE", "IGNORE") && isKeyword(p.peekAt(1), "INDEX", "KEY"):
+			p.pos += 2
+			if p.acceptKeyword("FOR") {
+				p.next()
+				p.acceptKeyword("BY")
+			}
+			p.parseParenExpression()
+		case isKeyword(p.peek(), "WITH") && isPunct(p.peekAt(1), "("):
+			p.next()
+			p.parseParenExpression()
+		case p.acceptKeyword("TABLESAMPLE"):
+			p.next()
+			p.parseParenExpression()
+			if p.acceptKeyword("REPEATABLE") {
+				p.parseParenExpression()
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) parseTableName() (*TableName, error) {
+	pos := p.peek().Pos
+	parts, err := p.parseQualifiedName()
+	if err != nil {
+		return nil, err
+	}
+	return &TableName{Parts: parts, Pos: pos}, nil
+}
+
+// parseQualifiedName はschema.tableのように"."で区切られた名前を読む
+func (p *parser) parseQualifiedName() ([]Identifier, error) {
+	var parts []Identifier
+	for {
+		ident, err := p.parseIdentifier()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, ident)
+		if !isPunct(p.peek(), ".") || isPunct(p.peekAt(1), "*") {
+			return parts, nil
+		}
+		p.next()
+	}
+}
+
+func (p *parser) parseIdentifier() (Identifier, error) {
+	tok := p.peek()
+	switch tok.Kind {
+	case TokenIdent:
+		p.next()
+		return Identifier{Name: tok.Value}, nil
+	case TokenQuotedIdent:
+		p.next()
+		return Identifier{Name: tok.Value, Quoted: true}, nil
+	}
+	return Identifier{}, fmt.Errorf("expected identifier, got %s", tok)
+}
+
+// parseIdentifierList は(a, b, c)のような括弧で囲まれた名前のリストを読む
+func (p *parser) parseIdentifierList() ([]Identifier, error) {
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	var idents []Identifier
+	for !isPunct(p.peek(), ")") {
+		parts, err := p.parseQualifiedName()
+		if err != nil {
+			return nil, err
+		}
+		idents = append(idents, parts[len(parts)-1])
+		if !p.acceptPunct(",") {
+			break
+		}
+	}
+	if err := p.expectPunct(")"); err != nil {
+		return nil, err
+	}
+	return idents, nil
+}
+
+// parseAlias はAS nameまたは予約語でない単語を別名として読む
+func (p *parser) parseAlias() (string, error) {
+	if p.acceptKeyword("AS") {
+		return p.parseAliasName()
+	}
+	if p.isAliasCandidate(p.peek()) {
+		return p.parseAliasName()
+	}
+	return "", nil
+}
+
+// parseTableAlias はテーブルの別名と列の別名リスト(AS t(a, b))を読む
+func (p *parser) parseTableAlias() (string, error) {
+	alias, err := p.parseAlias()
+	if err != nil || alias == "" {
+		return alias, err
+	}
+	if isPunct(p.peek(), "(") {
+		if _, err := p.parseIdentifierList(); err != nil {
+			return "", err
+		}
+	}
+	return alias, nil
+}
+
+func (p *parser) parseAliasName() (string, error) {
+	tok := p.peek()
+	switch tok.Kind {
+	case TokenIdent, TokenQuotedIdent, TokenString:
+		p.next()
+		return tok.Value, nil
+	}
+	return "", fmt.Errorf("expected alias, got %s", tok)
+}
+
+func (p *parser) isAliasCandidate(tok Token) bool {
+	switch tok.Kind {
+	case TokenQuotedIdent:
+		return true
+	case TokenIdent:
+		return !reservedKeywords[keywordOf(tok)]
+	}
+	return false
+}
+
+// parseParenExpression は括弧で囲まれた式のリストを1つの式として読む
+func (p *parser) parseParenExpression() (*Expression, error) {
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	expr := &Expression{}
+	if !isPunct(p.peek(), ")") {
+		for _, e := range p.parseExpressionList() {
+			expr = mergeExpressions(expr, e)
+		}
+	}
+	if err := p.expectPunct(")"); err != nil {
+		return nil, err
+	}
+	return expr, nil
+}
+
+func (p *parser) parseExpressionList() []*Expression {
+	var exprs []*Expression
+	for {
+		start := p.pos
+		exprs = append(exprs, p.parseExpression(false))
+		// ASC/DESCやNULLS FIRSTなどの修飾は式の一部として読み飛ばされる
+		if p.pos == start || !p.acceptPunct(",") {
+			return exprs
+		}
+	}
+}
+
+// parseExpression は式を構文木にせず、参照している列とサブクエリだけを集めながら読み進める
+// 括弧の外でカンマや句の開始キーワードに出会うと止まる
+// allowAliasがtrueの場合はSELECTリストの暗黙の別名(SELECT a b)の手前でも止まる
+func (p *parser) parseExpression(allowAlias bool) *Expression {
+	expr := &Expression{}
+	depth := 0
+	caseDepth := 0
+	prevOperand := false
+	skipNextIdent := false
+
+	for {
+		tok := p.peek()
+		kw := keywordOf(tok)
+		topLevel := depth == 0 && caseDepth == 0
+
+		if tok.Kind == TokenEOF {
+			return expr
+		}
+		if depth == 0 && (isPunct(tok, ")") || isPunct(tok, ",") || isPunct(tok, ";")) {
+			return expr
+		}
+		if topLevel && clauseKeywords[kw] && !(isKeyword(tok, "LEFT", "RIGHT") && isPunct(p.peekAt(1), "(")) {
+			return expr
+		}
+		if topLevel && allowAlias && prevOperand && p.isAliasCandidate(tok) {
+			return expr
+		}
+
+		switch {
+		case isPunct(tok, "(") && p.startsQuery(1):
+			p.next()
+			query, err := p.parseQuery()
+			if err == nil {
+				expr.Subqueries = append(expr.Subqueries, query)
+			}
+			p.skipToCloseParen()
+			prevOperand = true
+
+		case isPunct(tok, "("):
+			p.next()
+			depth++
+			prevOperand = false
+
+		case isPunct(tok, ")"):
+			p.next()
+			depth--
+			prevOperand = true
+
+		case isPunct(tok, "::"):
+			// 型キャストの型名は列ではない
+			p.next()
+			skipNextIdent = true
+			prevOperand = false
+
+		case isPunct(tok, "*"):
+			p.next()
+			if !prevOperand && depth == 0 {
+				expr.Columns = append(expr.Columns, &ColumnRef{Star: true, Pos: tok.Pos})
+				prevOperand = true
+			} else {
+				prevOperand = false
+			}
+
+		case tok.Kind == TokenIdent || tok.Kind == TokenQuotedIdent:
+			prevOperand = p.scanIdentifier(expr, &depth, &caseDepth, skipNextIdent)
+			skipNextIdent = false
+			continue
+
+		case tok.Kind == TokenString, tok.Kind == TokenNumber, tok.Kind == TokenParam:
+			p.next()
+			prevOperand = true
+
+		default:
+			p.next()
+			prevOperand = false
+		}
+		skipNextIdent = false
+	}
+}
+
+// scanIdentifier は式の中の単語を1つ読み、列参照であればexprに加える
+// 戻り値は読んだものが式の項として完結しているかどうか
+func (p *parser) scanIdentifier(expr *Expression, depth, caseDepth *int, skip bool) bool {
+	tok := p.peek()
+	kw := keywordOf(tok)
+	next := p.peekAt(1)
+
+	switch {
+	case skip:
+		p.next()
+		return true
+
+	case tok.Kind == TokenIdent && isPunct(next, "(") && (!reservedKeywords[kw] || functionKeywords[kw]):
+		// 関数呼び出し。EXTRACT(YEAR FROM ts)の最初の単語は列ではない
+		p.pos += 2
+		*depth++
+		if kw == "EXTRACT" && p.peek().Kind == TokenIdent {
+			p.next()
+		}
+		return false
+
+	case kw == "CASE":
+		p.next()
+		*caseDepth++
+		return false
+
+	case kw == "END":
+		p.next()
+		if *caseDepth > 0 {
+			*caseDepth--
+		}
+		return true
+
+	case kw == "AS" || kw == "COLLATE":
+		// CAST(x AS type)の型名とCOLLATEの照合順序名
+		p.next()
+		if p.peek().Kind == TokenIdent || p.peek().Kind == TokenQuotedIdent {
+			p.next()
+		}
+		return true
+
+	case kw == "INTERVAL":
+		p.next()
+		if p.peek().Kind == TokenString {
+			p.next()
+			if intervalUnits[keywordOf(p.peek())] {
+				p.next()
+			}
+		}
+		return true
+
+	case kw == "WITHIN" && isKeyword(next, "GROUP"), kw == "DISTINCT" && isKeyword(next, "FROM"):
+		p.pos += 2
+		return false
+
+	case kw == "OVER" && next.Kind == TokenIdent:
+		// OVER wの名前付きウィンドウ
+		p.pos += 2
+		return true
+
+	case tok.Kind == TokenIdent && next.Kind == TokenString:
+		// DATE '2024-01-01'のような型付きリテラル
+		p.next()
+		return false
+
+	case tok.Kind == TokenIdent && reservedKeywords[kw]:
+		p.next()
+		return operandKeywords[kw]
+	}
+
+	ref := &ColumnRef{Pos: tok.Pos}
+	for {
+		ident, _ := p.parseIdentifier()
+		ref.Parts = append(ref.Parts, ident)
+		if !isPunct(p.peek(), ".") {
+			break
+		}
+		after := p.peekAt(1)
+		if isPunct(after, "*") {
+			p.pos += 2
+			ref.Star = true
+			break
+		}
+		if after.Kind != TokenIdent && after.Kind != TokenQuotedIdent {
+			break
+		}
+		p.next()
+	}
+	if isPunct(p.peek(), "(") {
+		// schema.func(...)の呼び出し
+		p.next()
+		*depth++
+		return false
+	}
+	expr.Columns = append(expr.Columns, ref)
+	return true
+}
+
+// skipToCloseParen はサブクエリの閉じ括弧まで読み飛ばす
+// サブクエリの解析に失敗した場合も、括弧の対応を保って式の走査を続けられるようにする
+func (p *parser) skipToCloseParen() {
+	depth := 0
+	for !p.atEOF() {
+		tok := p.next()
+		switch {
+		case isPunct(tok, "("):
+			depth++
+		case isPunct(tok, ")"):
+			if depth == 0 {
+				return
+			}
+			depth--
+		}
+	}
+}
+
+func mergeExpressions(a, b *Expression) *Expression {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	return &Expression{
+		Columns:    append(a.Columns, b.Columns...),
+		Subqueries: append(a.Subqueries, b.Subqueries...),
+	}
+}
@@ -0,0 +1,465 @@
+package sqlparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected []Token
+	}{
+		{
+			name: "Keywords, identifiers and placeholders",
+			sql:  "SELECT id FROM users WHERE id = $1 AND name = ? AND t = :tenant",
+			expected: []Token{
+				{Kind: TokenIdent, Value: "SELECT"}, {Kind: TokenIdent, Value: "id"},
+				{Kind: TokenIdent, Value: "FROM"}, {Kind: TokenIdent, Value: "users"},
+				{Kind: TokenIdent, Value: "WHERE"}, {Kind: TokenIdent, Value: "id"},
+				{Kind: TokenPunct, Value: "="}, {Kind: TokenParam, Value: "$1"},
+				{Kind: TokenIdent, Value: "AND"}, {Kind: TokenIdent, Value: "name"},
+				{Kind: TokenPunct, Value: "="}, {Kind: TokenParam, Value: "?"},
+				{Kind: TokenIdent, Value: "AND"}, {Kind: TokenIdent, Value: "t"},
+				{Kind: TokenPunct, Value: "="}, {Kind: TokenParam, Value: "tenant"},
+			},
+		},
+		{
+			name: "Strings and quoted identifiers",
+			sql:  `'it''s' "Order ""Items""" ` + "`users` E'a\\'b' $$x;y$$ $tag$z$tag$",
+			expected: []Token{
+				{Kind: TokenString, Value: "it's"},
+				{Kind: TokenQuotedIdent, Value: `Order "Items"`},
+				{Kind: TokenQuotedIdent, Value: "users"},
+				{Kind: TokenString, Value: "a'b"},
+				{Kind: TokenString, Value: "x;y"},
+				{Kind: TokenString, Value: "z"},
+			},
+		},
+		{
+			name: "Comments are skipped",
+			sql:  "a -- comment\n/* block\ncomment */ b",
+			expected: []Token{
+				{Kind: TokenIdent, Value: "a"}, {Kind: TokenIdent, Value: "b"},
+			},
+		},
+		{
+			name: "Operators",
+			sql:  "a::int >= 1.5e3 || data->>'k'",
+			expected: []Token{
+				{Kind: TokenIdent, Value: "a"}, {Kind: TokenPunct, Value: "::"},
+				{Kind: TokenIdent, Value: "int"}, {Kind: TokenPunct, Value: ">="},
+				{Kind: TokenNumber, Value: "1.5e3"}, {Kind: TokenPunct, Value: "||"},
+				{Kind: TokenIdent, Value: "data"}, {Kind: TokenPunct, Value: "->>"},
+				{Kind: TokenString, Value: "k"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := Tokenize(tt.sql)
+			if err != nil {
+				t.Fatalf("Tokenize failed: %v", err)
+			}
+			if last := tokens[len(tokens)-1]; last.Kind != TokenEOF {
+				t.Fatalf("Expected trailing EOF token, got %v", last)
+			}
+			tokens = tokens[:len(tokens)-1]
+			if len(tokens) != len(tt.expected) {
+				t.Fatalf("Expected %d tokens, got %d: %v", len(tt.expected), len(tokens), tokens)
+			}
+			for i, expected := range tt.expected {
+				if tokens[i].Kind != expected.Kind || tokens[i].Value != expected.Value {
+					t.Errorf("Token %d: expected %v %q, got %v %q", i, expected.Kind, expected.Value, tokens[i].Kind, tokens[i].Value)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenizeUnterminated(t *testing.T) {
+	for _, sql := range []string{"SELECT 'abc", `SELECT "abc`, "SELECT $$abc"} {
+		if _, err := Tokenize(sql); err == nil {
+			t.Errorf("Expected error for %q", sql)
+		}
+	}
+}
+
+func TestParseSelect(t *testing.T) {
+	stmts, err := Parse("WITH recent AS (SELECT id FROM orders) SELECT u.name n, count(*) AS total, u.* FROM users u JOIN recent r ON r.id = u.id WHERE u.age > 18")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(stmts) != 1 {
+		t.Fatalf("Expected 1 statement, got %d", len(stmts))
+	}
+	sel, ok := stmts[0].(*SelectStatement)
+	if !ok {
+		t.Fatalf("Expected *SelectStatement, got %T", stmts[0])
+	}
+
+	query := sel.Query
+	if query.With == nil || len(query.With.CTEs) != 1 || query.With.CTEs[0].Name.Name != "recent" {
+		t.Fatalf("Expected CTE recent, got %+v", query.With)
+	}
+	block, ok := query.Selects[0].(*Select)
+	if !ok {
+		t.Fatalf("Expected *Select, got %T", query.Selects[0])
+	}
+
+	if len(block.Columns) != 3 {
+		t.Fatalf("Expected 3 select items, got %d", len(block.Columns))
+	}
+	if block.Columns[0].Alias != "n" || block.Columns[1].Alias != "total" {
+		t.Errorf("Expected aliases n and total, got %q and %q", block.Columns[0].Alias, block.Columns[1].Alias)
+	}
+	if cols := block.Columns[1].Expr.Columns; len(cols) != 0 {
+		t.Errorf("Expected count(*) to reference no columns, got %d", len(cols))
+	}
+	if cols := block.Columns[2].Expr.Columns; len(cols) != 1 || !cols[0].Star || cols[0].Parts[0].Name != "u" {
+		t.Errorf("Expected u.* star reference, got %+v", cols)
+	}
+
+	join, ok := block.From[0].(*Join)
+	if !ok {
+		t.Fatalf("Expected *Join, got %T", block.From[0])
+	}
+	if join.Kind != "JOIN" || join.On == nil || len(join.On.Columns) != 2 {
+		t.Errorf("Expected JOIN with 2 columns in ON, got %+v", join)
+	}
+	if left := join.Left.(*TableName); left.Name().Name != "users" || left.Alias != "u" {
+		t.Errorf("Expected users u, got %+v", left)
+	}
+	if block.Where == nil || len(block.Where.Columns) != 1 {
+		t.Errorf("Expected one column in WHERE, got %+v", block.Where)
+	}
+}
+
+func TestParseInsert(t *testing.T) {
+	stmts, err := Parse("INSERT INTO app.users AS u (id, name) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name RETURNING id")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	insert, ok := stmts[0].(*InsertStatement)
+	if !ok {
+		t.Fatalf("Expected *InsertStatement, got %T", stmts[0])
+	}
+	if qualifiers := insert.Table.Qualifiers(); len(qualifiers) != 1 || qualifiers[0].Name != "app" {
+		t.Errorf("Expected schema app, got %+v", qualifiers)
+	}
+	if insert.Table.Name().Name != "users" || insert.Table.Alias != "u" {
+		t.Errorf("Expected app.users AS u, got %+v", insert.Table)
+	}
+	if len(insert.Columns) != 2 || insert.Columns[1].Name != "name" {
+		t.Errorf("Expected columns (id, name), got %+v", insert.Columns)
+	}
+	if insert.OnConflict == nil || insert.OnConflict.DoNothing || len(insert.OnConflict.Assignment) != 1 {
+		t.Errorf("Expected ON CONFLICT DO UPDATE with one assignment, got %+v", insert.OnConflict)
+	}
+	if len(insert.Returning) != 1 {
+		t.Errorf("Expected RETURNING id, got %+v", insert.Returning)
+	}
+}
+
+func TestParseUpdateAndDelete(t *testing.T) {
+	stmts, err := Parse("UPDATE users SET (a, b) = (1, 2), c = c + 1 WHERE id = ?; DELETE FROM sessions WHERE expires_at < now()")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(stmts) != 2 {
+		t.Fatalf("Expected 2 statements, got %d", len(stmts))
+	}
+
+	update, ok := stmts[0].(*UpdateStatement)
+	if !ok {
+		t.Fatalf("Expected *UpdateStatement, got %T", stmts[0])
+	}
+	if len(update.Set) != 2 || len(update.Set[0].Columns) != 2 {
+		t.Errorf("Expected tuple assignment and one single assignment, got %+v", update.Set)
+	}
+
+	del, ok := stmts[1].(*DeleteStatement)
+	if !ok {
+		t.Fatalf("Expected *DeleteStatement, got %T", stmts[1])
+	}
+	if table := del.From[0].(*TableName); table.Name().Name != "sessions" {
+		t.Errorf("Expected sessions, got %+v", table)
+	}
+}
+
+func TestParseSkipsInvalidStatements(t *testing.T) {
+	stmts, err := Parse("SELECT * FROM ok_table; CREATE TABLE t (id int); SELECT FROM WHERE")
+	if err == nil {
+		t.Error("Expected an error for the unsupported statements")
+	}
+	if len(stmts) != 1 {
+		t.Fatalf("Expected 1 parsed statement, got %d", len(stmts))
+	}
+	tables := Tables(stmts...)
+	if len(tables) != 1 || tables[0].Name().Name != "ok_table" {
+		t.Errorf("Expected ok_table, got %+v", tables)
+	}
+}
+
+func TestTables(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected []string
+	}{
+		{
+			name:     "Recursive CTE references itself",
+			sql:      "WITH RECURSIVE r(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM r WHERE n < 10) SELECT * FROM r",
+			expected: nil,
+		},
+		{
+			name:     "Non-recursive CTE cannot see later CTEs",
+			sql:      "WITH a AS (SELECT * FROM b), b AS (SELECT * FROM base) SELECT * FROM a",
+			expected: []string{"b", "base"},
+		},
+		{
+			name:     "Qualified name is never a CTE",
+			sql:      "WITH users AS (SELECT 1) SELECT * FROM users, auth.users",
+			expected: []string{"auth.users"},
+		},
+		{
+			name:     "Results follow text order",
+			sql:      "SELECT (SELECT max(id) FROM z_last), a.id FROM a_first a",
+			expected: []string{"z_last", "a_first"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmts, err := Parse(tt.sql)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			var names []string
+			for _, table := range Tables(stmts...) {
+				var parts []string
+				for _, part := range table.Parts {
+					parts = append(parts, part.Name)
+				}
+				names = append(names, strings.Join(parts, "."))
+			}
+			if strings.Join(names, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
+
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected []string
+	}{
+		{
+			name:     "SELECT reads every table",
+			sql:      "SELECT * FROM users u JOIN orders o ON o.user_id = u.id",
+			expected: []string{"users:SELECT", "orders:SELECT"},
+		},
+		{
+			name:     "INSERT SELECT",
+			sql:      "INSERT INTO archive SELECT * FROM messages",
+			expected: []string{"archive:INSERT", "messages:SELECT"},
+		},
+		{
+			name:     "ON CONFLICT DO UPDATE is an upsert",
+			sql:      "INSERT INTO counters (k) VALUES ($1) ON CONFLICT (k) DO UPDATE SET n = counters.n + 1",
+			expected: []string{"counters:UPSERT"},
+		},
+		{
+			name:     "ON CONFLICT DO NOTHING is an insert",
+			sql:      "INSERT INTO counters (k) VALUES ($1) ON CONFLICT DO NOTHING",
+			expected: []string{"counters:INSERT"},
+		},
+		{
+			name:     "REPLACE is an upsert",
+			sql:      "REPLACE INTO caches (k, v) VALUES (?, ?)",
+			expected: []string{"caches:UPSERT"},
+		},
+		{
+			name:     "UPDATE reading the same table",
+			sql:      "UPDATE users SET rank = (SELECT COUNT(*) FROM users u2 WHERE u2.score > users.score)",
+			expected: []string{"users:UPDATE", "users:SELECT"},
+		},
+		{
+			name:     "UPDATE with JOIN updates the qualified table",
+			sql:      "UPDATE orders o JOIN customers c ON c.id = o.customer_id SET o.status = 'vip'",
+			expected: []string{"orders:UPDATE", "customers:SELECT"},
+		},
+		{
+			name:     "UPDATE FROM",
+			sql:      "UPDATE accounts SET balance = 0 FROM closures c WHERE c.account_id = accounts.id",
+			expected: []string{"accounts:UPDATE", "closures:SELECT"},
+		},
+		{
+			name:     "DELETE USING",
+			sql:      "DELETE FROM sessions USING users WHERE sessions.user_id = users.id",
+			expected: []string{"sessions:DELETE", "users:SELECT"},
+		},
+		{
+			name:     "Multi-table DELETE resolves aliases",
+			sql:      "DELETE o FROM orders o JOIN customers c ON c.id = o.customer_id",
+			expected: []string{"orders:DELETE", "customers:SELECT"},
+		},
+		{
+			name:     "TRUNCATE",
+			sql:      "TRUNCATE logs",
+			expected: []string{"logs:TRUNCATE"},
+		},
+		{
+			name:     "MERGE with update and insert is an upsert",
+			sql:      "MERGE INTO stock s USING incoming i ON i.id = s.id WHEN MATCHED THEN UPDATE SET qty = i.qty WHEN NOT MATCHED THEN INSERT (id, qty) VALUES (i.id, i.qty)",
+			expected: []string{"stock:UPSERT", "incoming:SELECT"},
+		},
+		{
+			name:     "Data-modifying CTE",
+			sql:      "WITH gone AS (DELETE FROM queue RETURNING *) INSERT INTO done SELECT * FROM gone",
+			expected: []string{"queue:DELETE", "done:INSERT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmts, err := Parse(tt.sql)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			var refs []string
+			for _, ref := range References(stmts...) {
+				refs = append(refs, ref.Table.Name().Name+":"+string(ref.Operation))
+			}
+			if strings.Join(refs, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected %v, got %v", tt.expected, refs)
+			}
+		})
+	}
+}
+
+func TestColumns(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		expected []string
+	}{
+		{
+			name:     "SELECT list and WHERE of a single table",
+			sql:      "SELECT id, name FROM users WHERE email = $1",
+			expected: []string{"users.id:SELECT", "users.name:SELECT", "users.email:SELECT"},
+		},
+		{
+			name:     "SELECT * is an all-columns marker",
+			sql:      "SELECT * FROM users WHERE id = ?",
+			expected: []string{"users.*:SELECT", "users.id:SELECT"},
+		},
+		{
+			name:     "Qualified columns resolve aliases",
+			sql:      "SELECT u.name, o.* FROM users u JOIN orders o ON o.user_id = u.id",
+			expected: []string{"users.name:SELECT", "orders.*:SELECT", "orders.user_id:SELECT", "users.id:SELECT"},
+		},
+		{
+			name:     "Unqualified columns of a join are ambiguous",
+			sql:      "SELECT name FROM users JOIN orders ON orders.user_id = users.id",
+			expected: []string{"orders.user_id:SELECT", "users.id:SELECT"},
+		},
+		{
+			name:     "INSERT column list",
+			sql:      "INSERT INTO users (name, email) VALUES ($1, $2)",
+			expected: []string{"users.name:INSERT", "users.email:INSERT"},
+		},
+		{
+			name:     "INSERT without column list writes all columns",
+			sql:      "INSERT INTO archive SELECT * FROM messages",
+			expected: []string{"archive.*:INSERT", "messages.*:SELECT"},
+		},
+		{
+			name:     "ON CONFLICT SET is an upsert and ignores EXCLUDED",
+			sql:      "INSERT INTO counters (k, n) VALUES ($1, 1) ON CONFLICT (k) DO UPDATE SET n = EXCLUDED.n",
+			expected: []string{"counters.k:UPSERT", "counters.n:UPSERT", "counters.n:UPSERT"},
+		},
+		{
+			name:     "UPDATE SET and WHERE",
+			sql:      "UPDATE orders SET status = $1 WHERE id = $2",
+			expected: []string{"orders.status:UPDATE", "orders.id:SELECT"},
+		},
+		{
+			name:     "UPDATE with JOIN writes the qualified column",
+			sql:      "UPDATE orders o JOIN customers c ON c.id = o.customer_id SET o.status = c.tier",
+			expected: []string{"customers.id:SELECT", "orders.customer_id:SELECT", "orders.status:UPDATE", "customers.tier:SELECT"},
+		},
+		{
+			name:     "Correlated subquery reads the outer table",
+			sql:      "SELECT id FROM users u WHERE EXISTS (SELECT 1 FROM orders o WHERE o.user_id = u.id)",
+			expected: []string{"users.id:SELECT", "orders.user_id:SELECT", "users.id:SELECT"},
+		},
+		{
+			name:     "Select aliases in ORDER BY are not columns",
+			sql:      "SELECT count(*) AS total, status FROM orders GROUP BY status ORDER BY total",
+			expected: []string{"orders.status:SELECT", "orders.status:SELECT"},
+		},
+		{
+			name:     "CTE and derived table columns are not table columns",
+			sql:      "WITH recent AS (SELECT id FROM orders) SELECT r.id, d.n FROM recent r, (SELECT count(*) AS n FROM users) d",
+			expected: []string{"orders.id:SELECT"},
+		},
+		{
+			name:     "DELETE reads the WHERE columns",
+			sql:      "DELETE FROM sessions WHERE expires_at < now()",
+			expected: []string{"sessions.expires_at:SELECT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmts, err := Parse(tt.sql)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			var columns []string
+			for _, col := range Columns(stmts...) {
+				name := col.Column.Name
+				if col.Star {
+					name = "*"
+				}
+				columns = append(columns, col.Table.Name().Name+"."+name+":"+string(col.Operation))
+			}
+			if strings.Join(columns, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected %v, got %v", tt.expected, columns)
+			}
+		})
+	}
+}
+
+func TestParsePlaceholders(t *testing.T) {
+	sql := "SELECT * FROM audit_" + Placeholder + " WHERE " + Placeholder + " ORDER BY id"
+	stmts, err := Parse(sql)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	tables := Tables(stmts...)
+	if len(tables) != 1 || tables[0].Name().Name != "audit_"+Placeholder {
+		t.Errorf("Expected the placeholder to stay part of the table name, got %v", tables)
+	}
+}
+
+func TestIdentifierNormalized(t *testing.T) {
+	tests := []struct {
+		ident    Identifier
+		expected string
+	}{
+		{Identifier{Name: "Users"}, "users"},
+		{Identifier{Name: "Users", Quoted: true}, "Users"},
+		{Identifier{Name: "a.b", Quoted: true}, "a.b"},
+	}
+	for _, tt := range tests {
+		if got := tt.ident.Normalized(); got != tt.expected {
+			t.Errorf("Expected %+v to normalize to %s, got %s", tt.ident, tt.expected, got)
+		}
+	}
+}
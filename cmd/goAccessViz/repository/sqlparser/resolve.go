@@ -0,0 +1,481 @@
+package sqlparser
+
+import (
+	"sort"
+	"strings"
+)
+
+// Operation はテーブルに対する操作の種類
+type Operation string
+
+const (
+	OperationSelect   Operation = "SELECT"
+	OperationInsert   Operation = "INSERT"
+	OperationUpdate   Operation = "UPDATE"
+	OperationDelete   Operation = "DELETE"
+	OperationUpsert   Operation = "UPSERT"
+	OperationTruncate Operation = "TRUNCATE"
+)
+
+// TableReference は文中のテーブル参照とそのテーブルに対する操作
+// 同じテーブルを読み書きする場合(UPDATE t SET a = (SELECT ... FROM t))は操作ごとに別の参照になる
+type TableReference struct {
+	Table     *TableName
+	Operation Operation
+}
+
+// References は文が参照している実在のテーブルを操作の種類とともに出現順に返す
+// CTEの名前やサブクエリの別名はテーブルとして扱わない
+func References(stmts ...Statement) []TableReference {
+	r := &resolver{}
+	for _, stmt := range stmts {
+		r.statement(stmt, nil)
+	}
+	sort.SliceStable(r.refs, func(i, j int) bool {
+		return r.refs[i].Table.Pos < r.refs[j].Table.Pos
+	})
+	return r.refs
+}
+
+// Tables は文が参照している実在のテーブルを出現順に返す
+func Tables(stmts ...Statement) []*TableName {
+	var tables []*TableName
+	seen := make(map[*TableName]bool)
+	for _, ref := range References(stmts...) {
+		if !seen[ref.Table] {
+			seen[ref.Table] = true
+			tables = append(tables, ref.Table)
+		}
+	}
+	return tables
+}
+
+// cteScope はWITH句で定義された名前の有効範囲
+type cteScope struct {
+	names  map[string]bool
+	parent *cteScope
+}
+
+func (s *cteScope) defines(name string) bool {
+	for ; s != nil; s = s.parent {
+		if s.names[name] {
+			return true
+		}
+	}
+	return false
+}
+
+type resolver struct {
+	refs    []TableReference
+	columns []ColumnReference
+}
+
+// with はWITH句のCTEを解決し、本体から参照できるスコープを返す
+func (r *resolver) with(with *With, scope *cteScope) *cteScope {
+	if with == nil {
+		return scope
+	}
+	inner := &cteScope{names: make(map[string]bool), parent: scope}
+	if with.Recursive {
+		// WITH RECURSIVEでは各CTEが自分自身と他のCTEを参照できる
+		for _, cte := range with.CTEs {
+			inner.names[cte.Name.Normalized()] = true
+		}
+	}
+	for _, cte := range with.CTEs {
+		r.statement(cte.Statement, inner)
+		inner.names[cte.Name.Normalized()] = true
+	}
+	return inner
+}
+
+func (r *resolver) statement(stmt Statement, scope *cteScope) {
+	switch s := stmt.(type) {
+	case *SelectStatement:
+		r.query(s.Query, scope, nil)
+	case *InsertStatement:
+		r.insert(s, scope)
+	case *UpdateStatement:
+		r.update(s, scope)
+	case *DeleteStatement:
+		scope = r.with(s.With, scope)
+		start := len(r.refs)
+		sources := r.tableExprs(s.From, scope, nil)
+		r.deleteTargets(s.Targets, r.refs[start:], tableNamesOf(s.From), scope)
+		sources = r.tableExprs(s.Using, scope, sources)
+		r.joinConditions(append(s.From, s.Using...), scope, sources)
+		r.expression(s.Where, scope, sources, OperationSelect)
+		r.expressions(s.OrderBy, scope, sources, OperationSelect)
+		r.expressions(s.Returning, scope, sources, OperationSelect)
+	case *TruncateStatement:
+		for _, table := range s.Tables {
+			r.add(table, scope, OperationTruncate)
+		}
+	case *MergeStatement:
+		r.merge(s, scope)
+	}
+}
+
+func (r *resolver) insert(s *InsertStatement, scope *cteScope) {
+	scope = r.with(s.With, scope)
+	op := insertOperation(s)
+	r.add(s.Table, scope, op)
+	target := r.targetScope(s.Table, scope)
+
+	for _, col := range s.Columns {
+		r.addColumn(s.Table, scope, col, false, op)
+	}
+	if len(s.Columns) == 0 && s.Source != nil {
+		// 列リストのないINSERTはすべての列に書き込む
+		r.addColumn(s.Table, scope, Identifier{}, true, op)
+	}
+	r.query(s.Source, scope, nil)
+	r.assignments(s.Set, scope, target, op)
+	if s.OnConflict != nil {
+		// EXCLUDED.colは挿入しようとした行なのでテーブルの列としては扱わない
+		r.expressions(s.OnConflict.Target, scope, nil, OperationSelect)
+		r.assignments(s.OnConflict.Assignment, scope, target, OperationUpsert)
+		r.expression(s.OnConflict.Where, scope, target, OperationSelect)
+	}
+	r.expressions(s.Returning, scope, target, OperationSelect)
+}
+
+func (r *resolver) update(s *UpdateStatement, scope *cteScope) {
+	scope = r.with(s.With, scope)
+	start := len(r.refs)
+	sources := r.tableExprs(s.Tables, scope, nil)
+	r.markTargets(r.refs[start:], tableNamesOf(s.Tables), updateTargets(s), OperationUpdate)
+	var target *TableName
+	for _, ref := range r.refs[start:] {
+		if ref.Operation == OperationUpdate {
+			target = ref.Table
+			break
+		}
+	}
+	sources = r.tableExprs(s.From, scope, sources)
+
+	r.joinConditions(append(s.Tables, s.From...), scope, sources)
+	for _, assignment := range s.Set {
+		r.assignedColumns(assignment, target, scope, sources, OperationUpdate)
+		r.expression(assignment.Value, scope, sources, OperationSelect)
+	}
+	r.expression(s.Where, scope, sources, OperationSelect)
+	r.expressions(s.OrderBy, scope, sources, OperationSelect)
+	r.expressions(s.Returning, scope, sources, OperationSelect)
+}
+
+func (r *resolver) merge(s *MergeStatement, scope *cteScope) {
+	scope = r.with(s.With, scope)
+	ops := mergeOperations(s)
+	for _, op := range ops {
+		r.add(s.Target, scope, op)
+	}
+	sources := r.targetScope(s.Target, scope)
+	sources = r.tableExprs([]TableExpr{s.Source}, scope, sources)
+	r.expression(s.On, scope, sources, OperationSelect)
+
+	for _, clause := range s.Clauses {
+		r.expression(clause.Condition, scope, sources, OperationSelect)
+		switch clause.Action {
+		case "UPDATE":
+			op := OperationUpdate
+			if ops[0] == OperationUpsert {
+				op = OperationUpsert
+			}
+			for _, assignment := range clause.Set {
+				r.assignedColumns(assignment, s.Target, scope, sources, op)
+				r.expression(assignment.Value, scope, sources, OperationSelect)
+			}
+		case "INSERT":
+			for _, col := range clause.Columns {
+				r.addColumn(s.Target, scope, col, false, ops[0])
+			}
+			r.expressions(clause.Values, scope, sources, OperationSelect)
+		}
+	}
+}
+
+// query はsourcesを外側のクエリのスコープとして解決する(相関サブクエリの列参照のため)
+func (r *resolver) query(query *Query, scope *cteScope, outer *sourceScope) {
+	if query == nil {
+		return
+	}
+	scope = r.with(query.With, scope)
+	var orderScope *sourceScope
+	var aliases map[string]bool
+	for _, term := range query.Selects {
+		switch t := term.(type) {
+		case *Select:
+			orderScope = r.selectBlock(t, scope, outer)
+			aliases = selectAliases(t)
+		case *Values:
+			for _, row := range t.Rows {
+				r.expressions(row, scope, outer, OperationSelect)
+			}
+		case *Query:
+			r.query(t, scope, outer)
+		}
+	}
+	if len(query.Selects) != 1 {
+		// UNIONなどのORDER BYは結果の列を指すのでテーブルの列としては扱わない
+		orderScope = nil
+	}
+	for _, expr := range query.OrderBy {
+		r.expression(withoutAliases(expr, aliases), scope, orderScope, OperationSelect)
+	}
+	r.expressions(query.Limit, scope, nil, OperationSelect)
+}
+
+func (r *resolver) selectBlock(sel *Select, scope *cteScope, outer *sourceScope) *sourceScope {
+	sources := r.tableExprs(sel.From, scope, newSourceScope(outer))
+	r.joinConditions(sel.From, scope, sources)
+
+	r.expressions(sel.Distinct, scope, sources, OperationSelect)
+	for _, item := range sel.Columns {
+		r.expression(item.Expr, scope, sources, OperationSelect)
+	}
+	r.expression(sel.Where, scope, sources, OperationSelect)
+	aliases := selectAliases(sel)
+	for _, expr := range sel.GroupBy {
+		r.expression(withoutAliases(expr, aliases), scope, sources, OperationSelect)
+	}
+	r.expression(withoutAliases(sel.Having, aliases), scope, sources, OperationSelect)
+	r.expressions(sel.Window, scope, sources, OperationSelect)
+	return sources
+}
+
+// tableExprs はテーブル式の参照を記録し、列の解決に使う名前をsourcesに加えて返す
+// sourcesがnilの場合は新しいスコープを作る
+func (r *resolver) tableExprs(exprs []TableExpr, scope *cteScope, sources *sourceScope) *sourceScope {
+	if sources == nil {
+		sources = newSourceScope(nil)
+	}
+	for _, expr := range exprs {
+		r.tableExpr(expr, scope, sources)
+	}
+	return sources
+}
+
+func (r *resolver) tableExpr(expr TableExpr, scope *cteScope, sources *sourceScope) {
+	switch t := expr.(type) {
+	case *TableName:
+		r.table(t, scope)
+		sources.addTable(t, scope)
+	case *DerivedTable:
+		// LATERALでない派生テーブルは同じFROMの他のテーブルを参照できない
+		var outer *sourceScope
+		if t.Lateral {
+			outer = sources
+		} else {
+			outer = sources.parent
+		}
+		r.query(t.Query, scope, outer)
+		sources.addDerived(t.Alias)
+	case *TableFunction:
+		r.expression(t.Args, scope, sources, OperationSelect)
+		sources.addDerived(t.Alias)
+	case *Join:
+		r.tableExpr(t.Left, scope, sources)
+		r.tableExpr(t.Right, scope, sources)
+	case *ParenTableExpr:
+		r.tableExpr(t.Expr, scope, sources)
+	}
+}
+
+// joinConditions はJOIN ... ONの条件をFROM句全体のスコープで解決する
+func (r *resolver) joinConditions(exprs []TableExpr, scope *cteScope, sources *sourceScope) {
+	for _, expr := range exprs {
+		switch t := expr.(type) {
+		case *Join:
+			r.joinConditions([]TableExpr{t.Left, t.Right}, scope, sources)
+			r.expression(t.On, scope, sources, OperationSelect)
+		case *ParenTableExpr:
+			r.joinConditions([]TableExpr{t.Expr}, scope, sources)
+		}
+	}
+}
+
+// targetScope はINSERTやMERGEの対象テーブルだけを含むスコープを作る
+func (r *resolver) targetScope(table *TableName, scope *cteScope) *sourceScope {
+	sources := newSourceScope(nil)
+	sources.addTable(table, scope)
+	return sources
+}
+
+func (r *resolver) table(table *TableName, scope *cteScope) {
+	r.add(table, scope, OperationSelect)
+}
+
+func (r *resolver) add(table *TableName, scope *cteScope, op Operation) {
+	if table == nil {
+		return
+	}
+	if len(table.Parts) == 1 && scope.defines(table.Name().Normalized()) {
+		return
+	}
+	r.refs = append(r.refs, TableReference{Table: table, Operation: op})
+}
+
+func (r *resolver) assignments(assignments []*Assignment, scope *cteScope, sources *sourceScope, op Operation) {
+	var target *TableName
+	if sources != nil && len(sources.sources) > 0 {
+		target = sources.sources[0].table
+	}
+	for _, assignment := range assignments {
+		r.assignedColumns(assignment, target, scope, sources, op)
+		r.expression(assignment.Value, scope, sources, OperationSelect)
+	}
+}
+
+func (r *resolver) expressions(exprs []*Expression, scope *cteScope, sources *sourceScope, op Operation) {
+	for _, expr := range exprs {
+		r.expression(expr, scope, sources, op)
+	}
+}
+
+func (r *resolver) expression(expr *Expression, scope *cteScope, sources *sourceScope, op Operation) {
+	if expr == nil {
+		return
+	}
+	for _, col := range expr.Columns {
+		r.columnRef(col, sources, op)
+	}
+	for _, sub := range expr.Subqueries {
+		r.query(sub, scope, sources)
+	}
+}
+
+// tableNamesOf はテーブル式に直接書かれたテーブル名を集める(派生テーブルの中は含まない)
+func tableNamesOf(exprs []TableExpr) map[*TableName]bool {
+	names := make(map[*TableName]bool)
+	collectTableNames(exprs, names)
+	return names
+}
+
+func collectTableNames(exprs []TableExpr, names map[*TableName]bool) {
+	for _, expr := range exprs {
+		switch t := expr.(type) {
+		case *TableName:
+			names[t] = true
+		case *Join:
+			collectTableNames([]TableExpr{t.Left, t.Right}, names)
+		case *ParenTableExpr:
+			collectTableNames([]TableExpr{t.Expr}, names)
+		}
+	}
+}
+
+// markTargets はcandidatesに含まれる参照のうち、名前か別名がtargetsに含まれるものの操作をopにする
+// どれにも一致しない場合は最初の候補を対象とみなす
+func (r *resolver) markTargets(refs []TableReference, candidates map[*TableName]bool, targets map[string]bool, op Operation) {
+	first := -1
+	matched := false
+	for i := range refs {
+		if !candidates[refs[i].Table] {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		if matchesTarget(refs[i].Table, targets) {
+			refs[i].Operation = op
+			matched = true
+		}
+	}
+	if !matched && first >= 0 {
+		refs[first].Operation = op
+	}
+}
+
+// deleteTargets はDELETEの削除対象を解決する
+// 対象が書かれていなければFROMのテーブルがすべて削除対象(DELETE FROM t1, t2 USING ...)
+// DELETE t1, t2 FROM ...ではFROMの別名やテーブル名を指す対象の参照を削除に変え、FROMにないものは新たな参照として加える
+func (r *resolver) deleteTargets(targets []*TableName, refs []TableReference, from map[*TableName]bool, scope *cteScope) {
+	if len(targets) == 0 {
+		for i := range refs {
+			if from[refs[i].Table] {
+				refs[i].Operation = OperationDelete
+			}
+		}
+		return
+	}
+
+	var unmatched []*TableName
+	for _, target := range targets {
+		matched := false
+		for i := range refs {
+			if from[refs[i].Table] && matchesTarget(refs[i].Table, map[string]bool{qualifiedName(target): true}) {
+				refs[i].Operation = OperationDelete
+				matched = true
+			}
+		}
+		if !matched {
+			unmatched = append(unmatched, target)
+		}
+	}
+	for _, target := range unmatched {
+		r.add(target, scope, OperationDelete)
+	}
+}
+
+// matchesTarget はテーブルの別名、名前、修飾名のいずれかがtargetsに含まれるかを判定する
+func matchesTarget(table *TableName, targets map[string]bool) bool {
+	if table.Alias != "" {
+		return targets[strings.ToLower(table.Alias)]
+	}
+	return targets[table.Name().Normalized()] || targets[qualifiedName(table)]
+}
+
+func qualifiedName(table *TableName) string {
+	parts := make([]string, 0, len(table.Parts))
+	for _, part := range table.Parts {
+		parts = append(parts, part.Normalized())
+	}
+	return strings.Join(parts, ".")
+}
+
+func insertOperation(stmt *InsertStatement) Operation {
+	if stmt.Replace || (stmt.OnConflict != nil && !stmt.OnConflict.DoNothing) {
+		return OperationUpsert
+	}
+	return OperationInsert
+}
+
+// updateTargets はSET句で修飾に使われたテーブル名や別名(SET o.status = ...のo)を集める
+func updateTargets(stmt *UpdateStatement) map[string]bool {
+	targets := make(map[string]bool)
+	for _, assignment := range stmt.Set {
+		for _, col := range assignment.Columns {
+			if len(col.Parts) > 1 {
+				qualifier := &TableName{Parts: col.Parts[:len(col.Parts)-1]}
+				targets[qualifiedName(qualifier)] = true
+			}
+		}
+	}
+	return targets
+}
+
+// mergeOperations はMERGEの各WHEN句から対象テーブルへの操作を求める
+// 一致すれば更新し、しなければ挿入する場合はUPSERTとする
+func mergeOperations(stmt *MergeStatement) []Operation {
+	actions := make(map[string]bool)
+	for _, clause := range stmt.Clauses {
+		actions[clause.Action] = true
+	}
+	var ops []Operation
+	switch {
+	case actions["INSERT"] && actions["UPDATE"]:
+		ops = append(ops, OperationUpsert)
+	case actions["INSERT"]:
+		ops = append(ops, OperationInsert)
+	case actions["UPDATE"]:
+		ops = append(ops, OperationUpdate)
+	}
+	if actions["DELETE"] {
+		ops = append(ops, OperationDelete)
+	}
+	if len(ops) == 0 {
+		ops = append(ops, OperationSelect)
+	}
+	return ops
+}
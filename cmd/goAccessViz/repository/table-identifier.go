@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"strings"
+
+	"goAccessViz/cmd/goAccessViz/domain/node"
+	"goAccessViz/cmd/goAccessViz/repository/sqlparser"
+)
+
+// tableIdentifier はデータベースと同じ規則で解決したテーブル名
+// クォートされていない名前は小文字に畳み、"Users"や`order`のようにクォートされた名前は大文字小文字を保つ
+// 実行時に組み立てられるテーブル名は、分からない部分を"%s"にしたパターンになる
+type tableIdentifier struct {
+	schema  string
+	name    string
+	dynamic bool
+}
+
+// resolveTableIdentifier はschema.tableやcatalog.schema.tableを解決する
+// 修飾されていないテーブルはdefaultSchemaに属するものとみなす
+func resolveTableIdentifier(table *sqlparser.TableName, defaultSchema string) tableIdentifier {
+	id := tableIdentifier{
+		schema: defaultSchema,
+		name:   table.Name().Normalized(),
+	}
+	if qualifiers := table.Qualifiers(); len(qualifiers) > 0 {
+		// カタログ(データベース)はスキーマの区別に使わない
+		id.schema = qualifiers[len(qualifiers)-1].Normalized()
+	}
+	if strings.Contains(id.schema+id.name, sqlparser.Placeholder) {
+		id.schema = strings.ReplaceAll(id.schema, sqlparser.Placeholder, dynamicPattern)
+		id.name = strings.ReplaceAll(id.name, sqlparser.Placeholder, dynamicPattern)
+		id.dynamic = true
+	}
+	return id
+}
+
+// dynamicPattern はテーブル名のうち実行時まで分からない部分の表記
+const dynamicPattern = "%s"
+
+// newTableNode はテーブルNodeを作り、パターンで表されるテーブルには印を付ける
+func newTableNode(id tableIdentifier) *node.DatabaseTableTrackedEntity {
+	opts := []node.TableOption{node.WithSchema(id.schema)}
+	if id.dynamic {
+		opts = append(opts, node.WithDynamic())
+	}
+	return node.NewDatabaseTableTrackedEntity(id.name, []node.TrackedEntity{}, opts...)
+}
+
+// tableStatement は操作とテーブルだけが分かっているアクセスを、そのテーブルを読むためのSQLにする
+// ライブラリの呼び出しから組み立てるSQLで使い、代入する列は実行時まで分からない列として読み飛ばされる
+func tableStatement(mode node.AccessMode, table string) string {
+	switch mode {
+	case node.AccessSelect:
+		return "SELECT * FROM " + table
+	case node.AccessInsert:
+		return "INSERT INTO " + table + " VALUES (NULL)"
+	case node.AccessUpdate:
+		return "UPDATE " + table + " SET " + sqlparser.Placeholder + " = NULL"
+	case node.AccessDelete:
+		return "DELETE FROM " + table
+	case node.AccessUpsert:
+		return "INSERT INTO " + table + " VALUES (NULL) ON CONFLICT DO UPDATE SET " + sqlparser.Placeholder + " = NULL"
+	case node.AccessTruncate:
+		return "TRUNCATE " + table
+	}
+	return ""
+}
+
+// key はテーブルNodeを一意に決めるキー
+// 識別子に現れないNULで区切るため、クォートされた"a.b"とスキーマで修飾したa.bは別のテーブルになる
+func (id tableIdentifier) key() string {
+	if id.schema == "" {
+		return id.name
+	}
+	return id.schema + "\x00" + id.name
+}
+
+// qualifiedName は表示用の名前("schema.table"またはスキーマがなければテーブル名)
+func (id tableIdentifier) qualifiedName() string {
+	if id.schema == "" {
+		return id.name
+	}
+	return id.schema + "." + id.name
+}
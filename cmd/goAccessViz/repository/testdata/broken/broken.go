@@ -0,0 +1,10 @@
+package broken
+
+// Caller still produces a node even though Broken does not type-check
+func Caller() int {
+	return Broken()
+}
+
+func Broken() int {
+	return undefinedValue
+}
@@ -0,0 +1,93 @@
+package builderapp
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/doug-martin/goqu/v9"
+)
+
+// squirrel
+
+func ListPosts(db *sql.DB, userID int) error {
+	query, args, err := sq.Select("posts.id", "posts.title").
+		From("users u").
+		Join("posts ON posts.user_id = u.id").
+		Where("u.id = ?", userID).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+func AddComment(db *sql.DB, postID int, body string) error {
+	_, err := sq.Insert("comments").Columns("post_id", "body").Values(postID, body).RunWith(db).Exec()
+	return err
+}
+
+var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+func PublishPost(ctx context.Context, db *sql.DB, id int) error {
+	_, err := psql.Update("posts").Set("published", true).Where(sq.Eq{"id": id}).RunWith(db).ExecContext(ctx)
+	return err
+}
+
+func PurgeComments(db *sql.DB, postID int) error {
+	_, err := sq.Delete("comments").Where(sq.Eq{"post_id": postID}).RunWith(db).Exec()
+	return err
+}
+
+// A builder extended in branches keeps every table it may touch
+func SearchPosts(db *sql.DB, tag string) error {
+	query := sq.Select("posts.*").From("posts")
+	if tag != "" {
+		query = query.Join("post_tags ON post_tags.post_id = posts.id").Where("post_tags.tag = ?", tag)
+	}
+	rows, err := query.RunWith(db).Query()
+	if err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+// The builder is returned to be run elsewhere
+func archiveQuery(shard string) sq.UpdateBuilder {
+	return sq.Update("archive_"+shard).Set("archived", true)
+}
+
+func ArchiveShard(db *sql.DB, shard string) error {
+	_, err := archiveQuery(shard).RunWith(db).Exec()
+	return err
+}
+
+// goqu
+
+type Order struct {
+	ID     int
+	Status string
+}
+
+func ListOrders(db *goqu.Database) ([]Order, error) {
+	var orders []Order
+	err := db.From("orders").Join(goqu.T("customers"), goqu.On(goqu.Ex{"orders.customer_id": goqu.I("customers.id")})).ScanStructs(&orders)
+	return orders, err
+}
+
+func CancelOrders(ctx context.Context, db *goqu.Database, customerID int) error {
+	_, err := db.From("orders").Where(goqu.Ex{"customer_id": customerID}).Update().Set(goqu.Record{"status": "cancelled"}).Executor().ExecContext(ctx)
+	return err
+}
+
+func InsertInvoiceSQL() (string, []interface{}, error) {
+	return goqu.Dialect("postgres").Insert(goqu.S("billing").Table("Invoices")).Rows(goqu.Record{"total": 0}).ToSQL()
+}
+
+func DeleteOrderSQL(id int) (string, []interface{}, error) {
+	return goqu.Delete("orders").Where(goqu.Ex{"id": id}).ToSQL()
+}
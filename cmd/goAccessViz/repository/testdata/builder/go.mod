@@ -0,0 +1,14 @@
+module builderapp
+
+go 1.23
+
+require (
+	github.com/Masterminds/squirrel v1.5.4
+	github.com/doug-martin/goqu/v9 v9.19.0
+)
+
+// The stubs mirror the signatures of squirrel and goqu that the analysis relies on
+replace (
+	github.com/Masterminds/squirrel => ./stubs/squirrel
+	github.com/doug-martin/goqu/v9 => ./stubs/goqu
+)
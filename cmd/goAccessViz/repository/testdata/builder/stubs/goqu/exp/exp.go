@@ -0,0 +1,24 @@
+// Package exp is a stub of github.com/doug-martin/goqu/v9/exp for tests.
+package exp
+
+type Expression interface {
+	Expression() Expression
+}
+
+type IdentifierExpression interface {
+	Expression
+	Table(table string) IdentifierExpression
+	As(alias interface{}) AliasedExpression
+}
+
+type AliasedExpression interface {
+	Expression
+}
+
+type JoinCondition interface{}
+
+type Ex map[string]interface{}
+
+type Record map[string]interface{}
+
+func (e Ex) Expression() Expression { return e }
@@ -0,0 +1,3 @@
+module github.com/doug-martin/goqu/v9
+
+go 1.23
@@ -0,0 +1,96 @@
+// Package goqu is a stub of github.com/doug-martin/goqu/v9 for tests.
+package goqu
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+type Ex = exp.Ex
+
+type Record = exp.Record
+
+func T(table string) exp.IdentifierExpression            { return nil }
+func S(schema string) exp.IdentifierExpression           { return nil }
+func I(ident string) exp.IdentifierExpression            { return nil }
+func C(column string) exp.IdentifierExpression           { return nil }
+func On(expressions ...exp.Expression) exp.JoinCondition { return nil }
+
+func From(table ...interface{}) *SelectDataset { return &SelectDataset{} }
+func Insert(table interface{}) *InsertDataset  { return &InsertDataset{} }
+func Update(table interface{}) *UpdateDataset  { return &UpdateDataset{} }
+func Delete(table interface{}) *DeleteDataset  { return &DeleteDataset{} }
+
+type DialectWrapper struct{}
+
+func Dialect(dialect string) DialectWrapper                       { return DialectWrapper{} }
+func (d DialectWrapper) From(table ...interface{}) *SelectDataset { return &SelectDataset{} }
+func (d DialectWrapper) Insert(table interface{}) *InsertDataset  { return &InsertDataset{} }
+func (d DialectWrapper) Update(table interface{}) *UpdateDataset  { return &UpdateDataset{} }
+func (d DialectWrapper) Delete(table interface{}) *DeleteDataset  { return &DeleteDataset{} }
+func (d DialectWrapper) DB(db *sql.DB) *Database                  { return &Database{} }
+
+type Database struct{}
+
+func New(dialect string, db *sql.DB) *Database              { return &Database{} }
+func (d *Database) From(from ...interface{}) *SelectDataset { return &SelectDataset{} }
+func (d *Database) Insert(table interface{}) *InsertDataset { return &InsertDataset{} }
+func (d *Database) Update(table interface{}) *UpdateDataset { return &UpdateDataset{} }
+func (d *Database) Delete(table interface{}) *DeleteDataset { return &DeleteDataset{} }
+
+type QueryExecutor struct{}
+
+func (q QueryExecutor) Exec() (sql.Result, error)                                   { return nil, nil }
+func (q QueryExecutor) ExecContext(ctx context.Context) (sql.Result, error)         { return nil, nil }
+func (q QueryExecutor) ScanStructs(i interface{}) error                             { return nil }
+func (q QueryExecutor) ScanStructsContext(ctx context.Context, i interface{}) error { return nil }
+
+type SelectDataset struct{}
+
+func (sd *SelectDataset) From(from ...interface{}) *SelectDataset            { return sd }
+func (sd *SelectDataset) Select(selects ...interface{}) *SelectDataset       { return sd }
+func (sd *SelectDataset) Where(expressions ...exp.Expression) *SelectDataset { return sd }
+func (sd *SelectDataset) Join(table exp.Expression, condition exp.JoinCondition) *SelectDataset {
+	return sd
+}
+func (sd *SelectDataset) InnerJoin(table exp.Expression, condition exp.JoinCondition) *SelectDataset {
+	return sd
+}
+func (sd *SelectDataset) LeftJoin(table exp.Expression, condition exp.JoinCondition) *SelectDataset {
+	return sd
+}
+func (sd *SelectDataset) Order(order ...exp.Expression) *SelectDataset                { return sd }
+func (sd *SelectDataset) Limit(limit uint) *SelectDataset                             { return sd }
+func (sd *SelectDataset) Update() *UpdateDataset                                      { return &UpdateDataset{} }
+func (sd *SelectDataset) Insert() *InsertDataset                                      { return &InsertDataset{} }
+func (sd *SelectDataset) Delete() *DeleteDataset                                      { return &DeleteDataset{} }
+func (sd *SelectDataset) ToSQL() (sql string, params []interface{}, err error)        { return "", nil, nil }
+func (sd *SelectDataset) Executor() QueryExecutor                                     { return QueryExecutor{} }
+func (sd *SelectDataset) ScanStructs(i interface{}) error                             { return nil }
+func (sd *SelectDataset) ScanStructsContext(ctx context.Context, i interface{}) error { return nil }
+func (sd *SelectDataset) ScanStruct(i interface{}) (bool, error)                      { return false, nil }
+func (sd *SelectDataset) Count() (int64, error)                                       { return 0, nil }
+
+type InsertDataset struct{}
+
+func (id *InsertDataset) Into(into interface{}) *InsertDataset                 { return id }
+func (id *InsertDataset) Rows(rows ...interface{}) *InsertDataset              { return id }
+func (id *InsertDataset) ToSQL() (sql string, params []interface{}, err error) { return "", nil, nil }
+func (id *InsertDataset) Executor() QueryExecutor                              { return QueryExecutor{} }
+
+type UpdateDataset struct{}
+
+func (ud *UpdateDataset) Table(table interface{}) *UpdateDataset               { return ud }
+func (ud *UpdateDataset) Set(values interface{}) *UpdateDataset                { return ud }
+func (ud *UpdateDataset) Where(expressions ...exp.Expression) *UpdateDataset   { return ud }
+func (ud *UpdateDataset) ToSQL() (sql string, params []interface{}, err error) { return "", nil, nil }
+func (ud *UpdateDataset) Executor() QueryExecutor                              { return QueryExecutor{} }
+
+type DeleteDataset struct{}
+
+func (dd *DeleteDataset) From(table interface{}) *DeleteDataset                { return dd }
+func (dd *DeleteDataset) Where(expressions ...exp.Expression) *DeleteDataset   { return dd }
+func (dd *DeleteDataset) ToSQL() (sql string, params []interface{}, err error) { return "", nil, nil }
+func (dd *DeleteDataset) Executor() QueryExecutor                              { return QueryExecutor{} }
@@ -0,0 +1,16 @@
+package schema
+
+import (
+	"entgo.io/ent"
+	"entgo.io/ent/schema/edge"
+)
+
+type Group struct {
+	ent.Schema
+}
+
+func (Group) Edges() []ent.Edge {
+	return []ent.Edge{
+		edge.From("users", User.Type).Ref("groups"),
+	}
+}
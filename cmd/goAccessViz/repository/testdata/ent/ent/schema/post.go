@@ -0,0 +1,33 @@
+package schema
+
+import (
+	"entgo.io/ent"
+	"entgo.io/ent/dialect/entsql"
+	"entgo.io/ent/schema"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+)
+
+type Post struct {
+	ent.Schema
+}
+
+func (Post) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("title"),
+	}
+}
+
+func (Post) Edges() []ent.Edge {
+	return []ent.Edge{
+		edge.From("author", User.Type).Ref("posts").Unique(),
+		edge.To("tags", Tag.Type).StorageKey(edge.Table("post_tag_links")),
+	}
+}
+
+// The table is renamed from the default "posts"
+func (Post) Annotations() []schema.Annotation {
+	return []schema.Annotation{
+		entsql.Annotation{Table: "blog_posts"},
+	}
+}
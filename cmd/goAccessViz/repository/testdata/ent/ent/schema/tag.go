@@ -0,0 +1,16 @@
+package schema
+
+import (
+	"entgo.io/ent"
+	"entgo.io/ent/schema/edge"
+)
+
+type Tag struct {
+	ent.Schema
+}
+
+func (Tag) Edges() []ent.Edge {
+	return []ent.Edge{
+		edge.From("posts", Post.Type).Ref("tags"),
+	}
+}
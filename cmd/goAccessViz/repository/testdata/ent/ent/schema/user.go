@@ -0,0 +1,28 @@
+package schema
+
+import (
+	"entgo.io/ent"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+)
+
+type User struct {
+	ent.Schema
+}
+
+func (User) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("name").NotEmpty(),
+	}
+}
+
+func (User) Edges() []ent.Edge {
+	return []ent.Edge{
+		// O2M: the foreign key lives in the posts table
+		edge.To("posts", Post.Type),
+		// M2M with the inverse declared on Group
+		edge.To("groups", Group.Type),
+		// M2M to the same type, declared with its inverse
+		edge.To("following", User.Type).From("followers"),
+	}
+}
@@ -0,0 +1,8 @@
+module entapp
+
+go 1.23
+
+require entgo.io/ent v0.14.1
+
+// The stub mirrors the signatures of ent that the analysis relies on
+replace entgo.io/ent => ./stubs/ent
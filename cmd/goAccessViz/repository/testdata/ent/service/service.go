@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+
+	"entapp/ent"
+)
+
+func ListUsers(ctx context.Context, client *ent.Client) ([]*ent.User, error) {
+	return client.User.Query().Where().All(ctx)
+}
+
+func GetUser(ctx context.Context, client *ent.Client, id int) (*ent.User, error) {
+	return client.User.Get(ctx, id)
+}
+
+func CreateUser(ctx context.Context, client *ent.Client, name string) (*ent.User, error) {
+	return client.User.Create().SetName(name).Save(ctx)
+}
+
+func RenameUser(ctx context.Context, client *ent.Client, id int, name string) error {
+	return client.User.UpdateOneID(id).SetName(name).Exec(ctx)
+}
+
+func DeleteUser(ctx context.Context, client *ent.Client, id int) error {
+	_, err := client.User.DeleteOneID(id).Exec(ctx)
+	return err
+}
+
+// The posts of the selected users are read through the foreign key in blog_posts
+func UserPosts(ctx context.Context, client *ent.Client) ([]*ent.Post, error) {
+	return client.User.Query().Where().QueryPosts().All(ctx)
+}
+
+// A many-to-many traversal reads the join table
+func UserGroups(ctx context.Context, u *ent.User) ([]*ent.Group, error) {
+	return u.QueryGroups().All(ctx)
+}
+
+func JoinGroup(ctx context.Context, client *ent.Client, id, groupID int) error {
+	return client.User.UpdateOneID(id).AddGroupIDs(groupID).Exec(ctx)
+}
+
+func LeaveGroups(ctx context.Context, client *ent.Client, id int) error {
+	return client.User.UpdateOneID(id).ClearGroups().Exec(ctx)
+}
+
+// The join table of tags is renamed with StorageKey
+func TaggedPosts(ctx context.Context, client *ent.Client) ([]*ent.Tag, error) {
+	return client.Post.Query().QueryTags().All(ctx)
+}
+
+func CountFollowers(ctx context.Context, client *ent.Client) (int, error) {
+	return client.User.Query().QueryFollowers().Count(ctx)
+}
+
+func PostTitles(ctx context.Context, client *ent.Client) ([]string, error) {
+	return client.Post.Query().Select("title").Strings(ctx)
+}
+
+// Eager loading reads the posts after the users
+func UsersWithPosts(ctx context.Context, client *ent.Client) ([]*ent.User, error) {
+	return client.User.Query().WithPosts().All(ctx)
+}
+
+func AssignPosts(ctx context.Context, client *ent.Client, id int, postIDs ...int) error {
+	return client.User.UpdateOneID(id).AddPostIDs(postIDs...).Exec(ctx)
+}
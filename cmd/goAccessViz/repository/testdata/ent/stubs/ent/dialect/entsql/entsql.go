@@ -0,0 +1,10 @@
+// Package entsql is a stub of entgo.io/ent/dialect/entsql for tests.
+package entsql
+
+type Annotation struct {
+	Table     string
+	Charset   string
+	Collation string
+}
+
+func (Annotation) Name() string { return "EntSQL" }
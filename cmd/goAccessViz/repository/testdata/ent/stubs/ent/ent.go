@@ -0,0 +1,19 @@
+// Package ent is a stub of entgo.io/ent for tests.
+package ent
+
+import "entgo.io/ent/schema"
+
+type Field interface{ Descriptor() }
+
+type Edge interface{ Descriptor() }
+
+type Index interface{ Descriptor() }
+
+// Schema is embedded by every schema type. Type is referenced as User.Type in edges.
+type Schema struct{}
+
+func (Schema) Type()                            {}
+func (Schema) Fields() []Field                  { return nil }
+func (Schema) Edges() []Edge                    { return nil }
+func (Schema) Indexes() []Index                 { return nil }
+func (Schema) Annotations() []schema.Annotation { return nil }
@@ -0,0 +1,27 @@
+// Package edge is a stub of entgo.io/ent/schema/edge for tests.
+package edge
+
+type assocBuilder struct{}
+
+type inverseBuilder struct{}
+
+type StorageOption func()
+
+func To(name string, t any) *assocBuilder     { return &assocBuilder{} }
+func From(name string, t any) *inverseBuilder { return &inverseBuilder{} }
+func Table(name string) StorageOption         { return func() {} }
+func Columns(to, from string) StorageOption   { return func() {} }
+func Column(name string) StorageOption        { return func() {} }
+
+func (b *assocBuilder) Unique() *assocBuilder                          { return b }
+func (b *assocBuilder) Required() *assocBuilder                        { return b }
+func (b *assocBuilder) Field(f string) *assocBuilder                   { return b }
+func (b *assocBuilder) StorageKey(opts ...StorageOption) *assocBuilder { return b }
+func (b *assocBuilder) From(name string) *inverseBuilder               { return &inverseBuilder{} }
+func (b *assocBuilder) Descriptor()                                    {}
+
+func (b *inverseBuilder) Ref(ref string) *inverseBuilder { return b }
+func (b *inverseBuilder) Unique() *inverseBuilder        { return b }
+func (b *inverseBuilder) Required() *inverseBuilder      { return b }
+func (b *inverseBuilder) Field(f string) *inverseBuilder { return b }
+func (b *inverseBuilder) Descriptor()                    {}
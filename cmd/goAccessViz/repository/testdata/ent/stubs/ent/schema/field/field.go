@@ -0,0 +1,9 @@
+// Package field is a stub of entgo.io/ent/schema/field for tests.
+package field
+
+type stringBuilder struct{}
+
+func String(name string) *stringBuilder           { return &stringBuilder{} }
+func (b *stringBuilder) NotEmpty() *stringBuilder { return b }
+func (b *stringBuilder) Optional() *stringBuilder { return b }
+func (b *stringBuilder) Descriptor()              {}
@@ -0,0 +1,4 @@
+// Package schema is a stub of entgo.io/ent/schema for tests.
+package schema
+
+type Annotation interface{ Name() string }
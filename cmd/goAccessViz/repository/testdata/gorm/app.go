@@ -0,0 +1,118 @@
+package gormapp
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Default naming: snake_case plus plural
+type User struct {
+	ID    uint
+	Email string
+}
+
+type OrderItem struct {
+	ID      uint
+	OrderID uint
+}
+
+type Category struct {
+	ID   uint
+	Name string
+}
+
+type Person struct {
+	ID uint
+}
+
+type HTTPRequest struct {
+	ID uint
+}
+
+// TableName overrides the default naming
+type Invoice struct {
+	ID uint
+}
+
+func (Invoice) TableName() string {
+	return "billing.invoices"
+}
+
+func GetUser(db *gorm.DB, id uint) (User, error) {
+	var user User
+	err := db.Where("id = ?", id).First(&user).Error
+	return user, err
+}
+
+func ListOrderItems(ctx context.Context, db *gorm.DB, orderID uint) ([]OrderItem, error) {
+	var items []OrderItem
+	err := db.WithContext(ctx).Where("order_id = ?", orderID).Find(&items).Error
+	return items, err
+}
+
+func DeactivateUsers(db *gorm.DB) error {
+	return db.Model(&User{}).Where("last_login < now() - interval '1 year'").Updates(map[string]interface{}{"active": false}).Error
+}
+
+func RenameCategory(db *gorm.DB, id uint, name string) error {
+	return db.Model(&Category{ID: id}).Update("name", name).Error
+}
+
+func CreateInvoice(db *gorm.DB, invoice *Invoice) error {
+	return db.Create(invoice).Error
+}
+
+func SavePeople(db *gorm.DB, people []*Person) error {
+	return db.Save(&people).Error
+}
+
+func PurgeRequests(db *gorm.DB) error {
+	return db.Where("created_at < now()").Delete(&HTTPRequest{}).Error
+}
+
+func CountAuditLog(db *gorm.DB) (int64, error) {
+	var count int64
+	err := db.Table("audit_log").Count(&count).Error
+	return count, err
+}
+
+func ShardEvents(db *gorm.DB, shard string) error {
+	var events []map[string]interface{}
+	return db.Table("events_" + shard).Find(&events).Error
+}
+
+func EnsureCategory(db *gorm.DB, name string) error {
+	category := Category{Name: name}
+	return db.FirstOrCreate(&category, Category{Name: name}).Error
+}
+
+// A query split over several statements still resolves the model
+func ArchiveInvoices(db *gorm.DB, ids []uint) error {
+	query := db.Model(&Invoice{})
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	return query.Update("archived", true).Error
+}
+
+const topUsersQuery = "SELECT id FROM users ORDER BY score DESC LIMIT 10"
+
+// Raw SQL is read as SQL, not as a model query
+func TopUsers(db *gorm.DB) ([]uint, error) {
+	var ids []uint
+	err := db.Raw(topUsersQuery).Scan(&ids).Error
+	return ids, err
+}
+
+const resetScoresQuery = "UPDATE leaderboard SET score = 0"
+
+func ResetScores(db *gorm.DB) error {
+	return db.Exec(resetScoresQuery).Error
+}
+
+func CreateUserInTransaction(db *gorm.DB, user *User) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(user).Error
+	})
+}
@@ -0,0 +1,8 @@
+module gormapp
+
+go 1.23
+
+require gorm.io/gorm v1.25.12
+
+// The stub mirrors the signatures of GORM that the analysis relies on
+replace gorm.io/gorm => ./stubs/gorm
@@ -0,0 +1,8 @@
+module pgxapp
+
+go 1.23
+
+require github.com/jackc/pgx/v5 v5.7.1
+
+// The stub mirrors the signatures of pgx that the analysis relies on
+replace github.com/jackc/pgx/v5 => ./stubs/pgx
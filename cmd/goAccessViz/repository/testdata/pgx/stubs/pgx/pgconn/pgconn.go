@@ -0,0 +1,9 @@
+// Package pgconn is a stub of github.com/jackc/pgx/v5/pgconn for tests.
+package pgconn
+
+type CommandTag struct{}
+
+type StatementDescription struct {
+	Name string
+	SQL  string
+}
@@ -0,0 +1,106 @@
+// Package pgx is a stub of github.com/jackc/pgx/v5 for tests.
+package pgx
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type Rows interface {
+	Close()
+	Next() bool
+	Scan(dest ...any) error
+}
+
+type Row interface {
+	Scan(dest ...any) error
+}
+
+type Identifier []string
+
+type CopyFromSource interface {
+	Next() bool
+	Values() ([]any, error)
+	Err() error
+}
+
+func CopyFromRows(rows [][]any) CopyFromSource {
+	return nil
+}
+
+type QueuedQuery struct {
+	SQL       string
+	Arguments []any
+}
+
+type Batch struct {
+	QueuedQueries []*QueuedQuery
+}
+
+func (b *Batch) Queue(query string, arguments ...any) *QueuedQuery {
+	q := &QueuedQuery{SQL: query, Arguments: arguments}
+	b.QueuedQueries = append(b.QueuedQueries, q)
+	return q
+}
+
+func (b *Batch) Len() int {
+	return len(b.QueuedQueries)
+}
+
+type BatchResults interface {
+	Exec() (pgconn.CommandTag, error)
+	Query() (Rows, error)
+	QueryRow() Row
+	Close() error
+}
+
+type Conn struct{}
+
+func Connect(ctx context.Context, connString string) (*Conn, error) {
+	return &Conn{}, nil
+}
+
+func (c *Conn) Close(ctx context.Context) error {
+	return nil
+}
+
+func (c *Conn) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, nil
+}
+
+func (c *Conn) Query(ctx context.Context, sql string, args ...any) (Rows, error) {
+	return nil, nil
+}
+
+func (c *Conn) QueryRow(ctx context.Context, sql string, args ...any) Row {
+	return nil
+}
+
+func (c *Conn) SendBatch(ctx context.Context, b *Batch) BatchResults {
+	return nil
+}
+
+func (c *Conn) CopyFrom(ctx context.Context, tableName Identifier, columnNames []string, rowSrc CopyFromSource) (int64, error) {
+	return 0, nil
+}
+
+func (c *Conn) Prepare(ctx context.Context, name, sql string) (*pgconn.StatementDescription, error) {
+	return &pgconn.StatementDescription{Name: name, SQL: sql}, nil
+}
+
+func (c *Conn) Begin(ctx context.Context) (Tx, error) {
+	return nil, nil
+}
+
+type Tx interface {
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+	CopyFrom(ctx context.Context, tableName Identifier, columnNames []string, rowSrc CopyFromSource) (int64, error)
+	SendBatch(ctx context.Context, b *Batch) BatchResults
+	Prepare(ctx context.Context, name, sql string) (*pgconn.StatementDescription, error)
+	Exec(ctx context.Context, sql string, arguments ...any) (commandTag pgconn.CommandTag, err error)
+	Query(ctx context.Context, sql string, args ...any) (Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) Row
+	Conn() *Conn
+}
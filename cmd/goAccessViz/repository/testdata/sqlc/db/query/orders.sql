@@ -0,0 +1,8 @@
+/* name: ListUserOrders :many */
+SELECT sqlc.embed(orders), users.email
+FROM orders
+JOIN users ON users.id = orders.user_id
+WHERE orders.user_id = @user_id;
+
+-- name: DeleteOrder :exec
+DELETE FROM orders WHERE id = $1;
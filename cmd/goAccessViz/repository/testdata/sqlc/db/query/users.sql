@@ -0,0 +1,7 @@
+-- name: GetUser :one
+SELECT id, email FROM users
+WHERE id = $1 LIMIT 1;
+
+-- name: UpdateUserEmail :exec
+UPDATE users SET email = sqlc.arg(email)
+WHERE id = sqlc.arg(id);
@@ -0,0 +1,10 @@
+CREATE TABLE users (
+  id    BIGSERIAL PRIMARY KEY,
+  email TEXT NOT NULL
+);
+
+CREATE TABLE orders (
+  id      BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users (id),
+  total   BIGINT NOT NULL
+);
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+
+	"sqlcapp/internal/db"
+)
+
+type Service struct {
+	queries *db.Queries
+}
+
+func (s *Service) Profile(ctx context.Context, id int64) (string, error) {
+	user, err := s.queries.GetUser(ctx, id)
+	return user.Email, err
+}
+
+func (s *Service) ChangeEmail(ctx context.Context, id int64, email string) error {
+	return s.queries.UpdateUserEmail(ctx, db.UpdateUserEmailParams{Email: email, ID: id})
+}
+
+// Callers through the generated interface resolve the same queries
+func OrderHistory(ctx context.Context, q db.Querier, userID int64) (int, error) {
+	orders, err := q.ListUserOrders(ctx, userID)
+	return len(orders), err
+}
+
+func CancelOrder(ctx context.Context, q db.Querier, id int64) error {
+	return q.DeleteOrder(ctx, id)
+}
@@ -0,0 +1,69 @@
+package sqlfilesapp
+
+import (
+	"database/sql"
+	"embed"
+	"io/fs"
+	"os"
+)
+
+// A single file embedded into a string
+//
+//go:embed queries/list_users.sql
+var listUsersSQL string
+
+// A single file embedded into a byte slice
+//
+//go:embed queries/count_sessions.sql
+var countSessionsSQL []byte
+
+// Every query file, read by name at runtime
+//
+//go:embed queries/*.sql
+var queries embed.FS
+
+func ListUsers(db *sql.DB) error {
+	rows, err := db.Query(listUsersSQL)
+	if err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+func CountSessions(db *sql.DB) (int, error) {
+	var count int
+	err := db.QueryRow(string(countSessionsSQL)).Scan(&count)
+	return count, err
+}
+
+// The file holds two statements
+func ArchiveOrders(tx *sql.Tx) error {
+	query, err := queries.ReadFile("queries/archive_orders.sql")
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(string(query))
+	return err
+}
+
+func PurgeAudit(db *sql.DB, before string) error {
+	query, err := fs.ReadFile(queries, "queries/purge_audit.sql")
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(string(query), before)
+	return err
+}
+
+// Files on disk are resolved from the package directory
+func MonthlyReport(db *sql.DB) error {
+	query, err := os.ReadFile("reports/monthly.sql")
+	if err != nil {
+		return err
+	}
+	rows, err := db.Query(string(query))
+	if err != nil {
+		return err
+	}
+	return rows.Close()
+}
@@ -0,0 +1,3 @@
+module sqlfilesapp
+
+go 1.23
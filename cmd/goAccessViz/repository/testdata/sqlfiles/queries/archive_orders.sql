@@ -0,0 +1,4 @@
+INSERT INTO archived_orders (id, total)
+SELECT id, total FROM orders WHERE created_at < now() - interval '1 year';
+
+DELETE FROM orders WHERE created_at < now() - interval '1 year';
@@ -0,0 +1 @@
+SELECT count(*) FROM sessions WHERE expires_at > now();
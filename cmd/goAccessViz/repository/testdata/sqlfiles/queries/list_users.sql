@@ -0,0 +1,4 @@
+-- Active users for the dashboard
+SELECT id, name
+FROM users
+WHERE active = true;
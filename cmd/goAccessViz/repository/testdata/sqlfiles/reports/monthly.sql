@@ -0,0 +1,3 @@
+SELECT date_trunc('month', issued_at), sum(total)
+FROM invoices
+GROUP BY 1;
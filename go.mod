@@ -3,12 +3,14 @@ module goAccessViz
 go 1.23.8
 
 require (
+	github.com/jinzhu/inflection v1.0.0
+	github.com/jmoiron/sqlx v1.4.0
 	golang.org/x/tools v0.26.0
 	gonum.org/v1/gonum v0.16.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/jmoiron/sqlx v1.4.0 // indirect
 	golang.org/x/mod v0.21.0 // indirect
 	golang.org/x/sync v0.8.0 // indirect
 )
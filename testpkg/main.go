@@ -1,6 +1,14 @@
 package testpkg
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+
+	"goAccessViz/testpkg/queries"
+
 	"github.com/jmoiron/sqlx"
 )
 
@@ -80,3 +88,336 @@ func GetUsersByStatus(db *sqlx.DB, status string) ([]User, error) {
 	err := db.Select(&users, "SELECT u.* FROM users u JOIN orders o ON u.id = o.user_id WHERE o.status = ?", status)
 	return users, err
 }
+
+// Recursive functions for cycle handling
+func Factorial(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return n * Factorial(n-1)
+}
+
+func IsEven(n int) bool {
+	if n == 0 {
+		return true
+	}
+	return IsOdd(n - 1)
+}
+
+func IsOdd(n int) bool {
+	if n == 0 {
+		return false
+	}
+	return IsEven(n - 1)
+}
+
+// Repositories with same-named methods on different receivers
+type UserRepository struct {
+	db *sqlx.DB
+}
+
+func (r *UserRepository) Get(id int) (*User, error) {
+	var user User
+	err := r.db.Get(&user, "SELECT * FROM accounts WHERE id = ?", id)
+	return &user, err
+}
+
+type CommentRepository struct {
+	db *sqlx.DB
+}
+
+func (r CommentRepository) Get(id int) error {
+	_, err := r.db.Exec("SELECT * FROM comments WHERE id = ?", id)
+	return err
+}
+
+// SQL inside closures belongs to the closure, not the outer function
+func LoadDashboard(db *sqlx.DB) error {
+	var once sync.Once
+	var err error
+	once.Do(func() {
+		_, err = db.Exec("SELECT * FROM dashboards")
+	})
+	defer func() {
+		db.Exec("DELETE FROM sessions")
+	}()
+	return err
+}
+
+// Calls through go, defer, interfaces and function values
+type Notifier interface {
+	Notify()
+}
+
+type MailNotifier struct{}
+
+func (MailNotifier) Notify() {}
+
+func Dispatch(n Notifier, callback func()) {
+	Notify(n)
+	Notify(n)
+	go FunctionC()
+	defer FunctionB()
+	n.Notify()
+	callback()
+}
+
+func Notify(n Notifier) {
+	n.Notify()
+}
+
+// A hook is only reached through the func-typed parameter of RunHook
+func auditHook(event string) error {
+	return nil
+}
+
+func RunHook(hook func(event string) error) error {
+	return hook("run")
+}
+
+func InstallHooks() error {
+	return RunHook(auditHook)
+}
+
+// SQL held in constants, package variables and concatenations
+const queryGetProduct = "SELECT * FROM products WHERE id = ?"
+
+const productColumns = "id, name, price"
+
+var queryListProducts = "SELECT " + productColumns + " FROM product_catalog"
+
+func GetProduct(db *sqlx.DB, id int) error {
+	_, err := db.Exec(queryGetProduct, id)
+	return err
+}
+
+func ListProducts(db *sqlx.DB) error {
+	_, err := db.Exec(queryListProducts)
+	return err
+}
+
+// A package variable reassigned outside its initialization has no single value
+var listAccountsQuery = "SELECT * FROM accounts"
+
+func UseLegacyAccounts() {
+	listAccountsQuery = "SELECT * FROM legacy_accounts"
+}
+
+func ListAccounts(db *sqlx.DB) error {
+	_, err := db.Exec(listAccountsQuery)
+	return err
+}
+
+func ArchiveProducts(db *sqlx.DB) error {
+	source := "products"
+	_, err := db.Exec("INSERT INTO " + "product_archive" + " SELECT * FROM " + source)
+	return err
+}
+
+func GetInvoice(db *sqlx.DB, id int) error {
+	_, err := db.Exec(queries.SelectInvoice, id)
+	return err
+}
+
+func DeleteInvoice(db *sqlx.DB, id int) error {
+	_, err := db.Exec(queries.DeleteInvoice, id)
+	return err
+}
+
+// SQL built with fmt.Sprintf and strings.Builder
+const ledgerTable = "ledger"
+
+func GetLedgerEntry(db *sqlx.DB, id int) error {
+	_, err := db.Exec(fmt.Sprintf("SELECT * FROM %s WHERE id = ?", ledgerTable), id)
+	return err
+}
+
+func CopyLedger(db *sqlx.DB) error {
+	_, err := db.Exec(fmt.Sprintf("INSERT INTO %[1]s_log SELECT * FROM %[1]s", ledgerTable))
+	return err
+}
+
+func WriteAudit(db *sqlx.DB, tenant string) error {
+	_, err := db.Exec(fmt.Sprintf("INSERT INTO audit_%s (action) VALUES (?)", tenant), "login")
+	return err
+}
+
+func CountInvoices(db *sqlx.DB, where string) error {
+	var b strings.Builder
+	b.WriteString("SELECT count(*) FROM ")
+	b.WriteString(ledgerTable + "_archive")
+	if where != "" {
+		b.WriteString(" WHERE ")
+		b.WriteString(where)
+	}
+	_, err := db.Exec(b.String())
+	return err
+}
+
+func ListOrderHistory(db *sqlx.DB, archived bool) error {
+	var b strings.Builder
+	b.WriteString("SELECT * FROM ")
+	if archived {
+		b.WriteString("orders_archive")
+	} else {
+		b.WriteString("orders")
+	}
+	_, err := db.Exec(b.String())
+	return err
+}
+
+func PurgeShard(db *sqlx.DB, shard int) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "DELETE FROM events_%d", shard)
+	_, err := db.Exec(b.String())
+	return err
+}
+
+// Quoted identifiers written with escaped quotes and in raw strings
+func ListQuotedUsers(db *sqlx.DB) error {
+	_, err := db.Exec("SELECT * FROM \"Users\"")
+	return err
+}
+
+func ListQuotedAccounts(db *sqlx.DB) error {
+	_, err := db.Exec(`SELECT * FROM "Accounts"`)
+	return err
+}
+
+func ListQuotedLedgers(db *sql.DB) (*sql.Rows, error) {
+	return db.Query(strings.TrimSpace("SELECT * FROM \"Ledger Lines\" "))
+}
+
+// Methods named like database calls on other types are not SQL
+const cacheNote = "SELECT * FROM cache_entries"
+
+type Cache struct{}
+
+func (Cache) Get(key string, note string) string {
+	return key + note
+}
+
+func LookupCache(c Cache) string {
+	return c.Get("user:1", cacheNote)
+}
+
+func LookupInline(c Cache) string {
+	return c.Get("user:1", "SELECT * FROM cache_entries")
+}
+
+// A literal passed to a database call through a local is kept even when the argument cannot be evaluated
+func ListLedgers(db *sql.DB) (*sql.Rows, error) {
+	query := strings.TrimSpace("SELECT * FROM ledgers ")
+	return db.Query(query)
+}
+
+// Database calls through database/sql and the sqlx interfaces
+const countMembersQuery = "SELECT count(*) FROM members"
+
+const listTagsQuery = "SELECT name FROM tags"
+
+func CountMembers(q sqlx.Queryer) error {
+	_, err := q.Queryx(countMembersQuery)
+	return err
+}
+
+func ListTags(db *sql.DB) error {
+	rows, err := db.Query(listTagsQuery)
+	if err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+// Context, Named and package-level variants of the database/sql and sqlx API
+const (
+	archiveOrdersQuery = "UPDATE orders SET status = 'archived' WHERE id IN (?)"
+	findVendorQuery    = "SELECT * FROM vendors WHERE id = $1"
+	renameVendorQuery  = "UPDATE vendors SET name = :name WHERE id = :id"
+	listRegionsQuery   = "SELECT * FROM regions"
+	insertRegionQuery  = "INSERT INTO regions (name) VALUES ($1)"
+)
+
+func ArchiveOrders(ctx context.Context, db *sqlx.DB, ids []int) error {
+	query, args, err := sqlx.In(archiveOrdersQuery, ids)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, db.Rebind(query), args...)
+	return err
+}
+
+func FindVendor(ctx context.Context, db *sqlx.DB, id int) error {
+	var vendor User
+	return db.GetContext(ctx, &vendor, findVendorQuery, id)
+}
+
+func RenameVendor(tx *sqlx.Tx, vendor User) error {
+	_, err := tx.NamedExec(renameVendorQuery, vendor)
+	return err
+}
+
+func ListRegions(ctx context.Context, q sqlx.QueryerContext) error {
+	var regions []string
+	return sqlx.SelectContext(ctx, q, &regions, listRegionsQuery)
+}
+
+func AddRegion(db *sqlx.DB, name string) {
+	db.MustExec(insertRegionQuery, name)
+}
+
+// Prepared statements are attributed to the functions that execute them
+const (
+	findShipmentQuery  = "SELECT * FROM shipments WHERE id = $1"
+	insertCarrierQuery = "INSERT INTO carriers (name) VALUES (:name)"
+	redeemCouponQuery  = "DELETE FROM coupons WHERE code = $1"
+)
+
+type ShipmentStore struct {
+	find *sqlx.Stmt
+}
+
+func NewShipmentStore(db *sqlx.DB) (*ShipmentStore, error) {
+	find, err := db.Preparex(findShipmentQuery)
+	if err != nil {
+		return nil, err
+	}
+	return &ShipmentStore{find: find}, nil
+}
+
+func (s *ShipmentStore) Find(id int) error {
+	var shipment User
+	return s.find.Get(&shipment, id)
+}
+
+func prepareCarrierInsert(ctx context.Context, db *sqlx.DB) (*sqlx.NamedStmt, error) {
+	return db.PrepareNamedContext(ctx, insertCarrierQuery)
+}
+
+func AddCarrier(ctx context.Context, db *sqlx.DB, carrier User) error {
+	stmt, err := prepareCarrierInsert(ctx, db)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.ExecContext(ctx, carrier)
+	return err
+}
+
+var redeemCoupon *sql.Stmt
+
+func PrepareCoupons(db *sql.DB) error {
+	var err error
+	redeemCoupon, err = db.Prepare(redeemCouponQuery)
+	return err
+}
+
+func RedeemCoupon(tx *sql.Tx, code string) error {
+	_, err := tx.Stmt(redeemCoupon).Exec(code)
+	return err
+}
+
+// A statement that is never executed stays with the function that prepares it
+func PrepareVoucherCheck(db *sql.DB) (*sql.Stmt, error) {
+	return db.Prepare("SELECT code FROM vouchers WHERE code = $1")
+}
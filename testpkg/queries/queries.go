@@ -0,0 +1,8 @@
+// Package queries keeps SQL in another package to test cross-package resolution
+package queries
+
+const SelectInvoice = "SELECT * FROM invoices WHERE id = ?"
+
+const invoicesTable = "invoices"
+
+var DeleteInvoice = "DELETE FROM " + invoicesTable + " WHERE id = ?"